@@ -94,6 +94,19 @@ func RequireRole(roles ...models.UserType) gin.HandlerFunc {
 	}
 }
 
+// RequireRoleWhen applies RequireRole only to requests matching cond, letting
+// other requests through without authentication
+func RequireRoleWhen(cond func(*gin.Context) bool, roles ...models.UserType) gin.HandlerFunc {
+	requireRole := RequireRole(roles...)
+	return func(c *gin.Context) {
+		if cond(c) {
+			requireRole(c)
+			return
+		}
+		c.Next()
+	}
+}
+
 // RequireAdmin middleware ensures the user is an admin
 func RequireAdmin() gin.HandlerFunc {
 	return RequireRole(models.UserTypeAdmin)
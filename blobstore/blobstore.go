@@ -0,0 +1,54 @@
+// Package blobstore stores uploaded files such as proof-of-delivery photos.
+// Stores are pluggable behind the Store interface; files are addressed by
+// slash-separated keys.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"meals/config"
+	"path"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned for keys that hold no file
+	ErrNotFound = errors.New("blobstore: not found")
+	// ErrInvalidKey is returned for keys that are empty, absolute or escape the store
+	ErrInvalidKey = errors.New("blobstore: invalid key")
+)
+
+// Store is a place to keep files
+type Store interface {
+	// Put stores the contents of r under key, replacing any existing file
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open returns the file stored under key; callers must close it
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file stored under key. Deleting a missing file is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New creates the store selected by cfg.Driver
+func New(cfg config.BlobstoreConfig) (Store, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocal(cfg.LocalPath), nil
+	default:
+		return nil, fmt.Errorf("blobstore: unknown driver %q", cfg.Driver)
+	}
+}
+
+// ValidateKey checks that key is a clean relative path inside the store
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Package cart keeps customers' shopping carts in Redis. Each cart is a hash
+// keyed by user whose fields are menu meal IDs; the whole cart expires after
+// a period of inactivity that restarts on every change.
+package cart
+
+import (
+	"meals/models"
+	"sort"
+	"time"
+)
+
+// Item is a menu meal in a cart with the price it was added at
+type Item struct {
+	MenuMealID   uint         `json:"menu_meal_id"`
+	Quantity     int          `json:"quantity"`
+	UnitPrice    models.Money `json:"unit_price"`    // Price when the item was added or last changed
+	DeliveryDate time.Time    `json:"delivery_date"` // Resolved delivery date of the menu meal
+	AddedAt      time.Time    `json:"added_at"`
+}
+
+// LineTotal returns the item's unit price times its quantity
+func (i Item) LineTotal() models.Money {
+	return i.UnitPrice.Mul(i.Quantity)
+}
+
+// Cart is a customer's set of items waiting to be checked out
+type Cart struct {
+	UserID    uint         `json:"user_id"`
+	Items     []Item       `json:"items"`
+	Subtotal  models.Money `json:"subtotal"`
+	ExpiresAt *time.Time   `json:"expires_at,omitempty"` // Nil for an empty cart
+}
+
+// Find returns the item for the menu meal, or nil if it is not in the cart
+func (c *Cart) Find(menuMealID uint) *Item {
+	for i := range c.Items {
+		if c.Items[i].MenuMealID == menuMealID {
+			return &c.Items[i]
+		}
+	}
+	return nil
+}
+
+// Set adds the item or replaces the existing item for the same menu meal
+func (c *Cart) Set(item Item) {
+	if existing := c.Find(item.MenuMealID); existing != nil {
+		*existing = item
+	} else {
+		c.Items = append(c.Items, item)
+	}
+	c.normalize()
+}
+
+// Remove deletes the item for the menu meal, reporting whether it was present
+func (c *Cart) Remove(menuMealID uint) bool {
+	for i := range c.Items {
+		if c.Items[i].MenuMealID == menuMealID {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			c.normalize()
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty checks if the cart has no items
+func (c *Cart) IsEmpty() bool {
+	return len(c.Items) == 0
+}
+
+// OrderItems converts the cart into order items for placement
+func (c *Cart) OrderItems() []models.OrderItem {
+	items := make([]models.OrderItem, 0, len(c.Items))
+	for _, item := range c.Items {
+		items = append(items, models.OrderItem{
+			MenuMealID: item.MenuMealID,
+			Quantity:   item.Quantity,
+		})
+	}
+	return items
+}
+
+// normalize orders the items by when they were added and recomputes the subtotal
+func (c *Cart) normalize() {
+	sort.SliceStable(c.Items, func(i, j int) bool {
+		if c.Items[i].AddedAt.Equal(c.Items[j].AddedAt) {
+			return c.Items[i].MenuMealID < c.Items[j].MenuMealID
+		}
+		return c.Items[i].AddedAt.Before(c.Items[j].AddedAt)
+	})
+
+	c.Subtotal = models.Money{}
+	for _, item := range c.Items {
+		c.Subtotal = c.Subtotal.Add(item.LineTotal())
+	}
+}
@@ -0,0 +1,141 @@
+package cart
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// keyPrefix namespaces cart hashes in Redis
+const keyPrefix = "cart:"
+
+// maxUpdateAttempts bounds how often Update retries after a concurrent change
+const maxUpdateAttempts = 5
+
+// ErrConcurrentUpdate is returned when a cart keeps changing underneath an update
+var ErrConcurrentUpdate = errors.New("cart: changed concurrently, try again")
+
+// Service stores carts in Redis
+type Service struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewService creates a cart service. Carts expire ttl after their last change.
+func NewService(client *redis.Client, ttl time.Duration) *Service {
+	return &Service{client: client, ttl: ttl}
+}
+
+func key(userID uint) string {
+	return keyPrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
+// Get loads a user's cart. A missing or expired cart is returned empty.
+func (s *Service) Get(userID uint) (*Cart, error) {
+	return s.load(s.client, userID)
+}
+
+// Update applies fn to the user's cart and saves the result, restarting the
+// cart's expiry. The cart is watched while fn runs; if another request changes
+// it first, fn is run again on the fresh cart. If fn returns an error the cart
+// is left untouched and the error is returned.
+func (s *Service) Update(userID uint, fn func(*Cart) error) (*Cart, error) {
+	k := key(userID)
+
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		var updated *Cart
+
+		err := s.client.Watch(func(tx *redis.Tx) error {
+			c, err := s.load(tx, userID)
+			if err != nil {
+				return err
+			}
+
+			if err := fn(c); err != nil {
+				return err
+			}
+
+			fields := make(map[string]interface{}, len(c.Items))
+			for _, item := range c.Items {
+				data, err := json.Marshal(item)
+				if err != nil {
+					return err
+				}
+				fields[strconv.FormatUint(uint64(item.MenuMealID), 10)] = data
+			}
+
+			_, err = tx.TxPipelined(func(pipe redis.Pipeliner) error {
+				pipe.Del(k)
+				if len(fields) > 0 {
+					pipe.HMSet(k, fields)
+					pipe.Expire(k, s.ttl)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if !c.IsEmpty() {
+				expiresAt := time.Now().Add(s.ttl)
+				c.ExpiresAt = &expiresAt
+			} else {
+				c.ExpiresAt = nil
+			}
+			updated = c
+			return nil
+		}, k)
+
+		if err == redis.TxFailedErr {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return updated, nil
+	}
+
+	return nil, ErrConcurrentUpdate
+}
+
+// Clear deletes the user's cart
+func (s *Service) Clear(userID uint) error {
+	return s.client.Del(key(userID)).Err()
+}
+
+// load reads a cart through either the client or a watching transaction
+func (s *Service) load(cmd redis.Cmdable, userID uint) (*Cart, error) {
+	k := key(userID)
+	c := &Cart{UserID: userID, Items: []Item{}}
+
+	fields, err := cmd.HGetAll(k).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for field, value := range fields {
+		var item Item
+		if err := json.Unmarshal([]byte(value), &item); err != nil {
+			return nil, fmt.Errorf("cart: corrupt item %s in %s: %w", field, k, err)
+		}
+		c.Items = append(c.Items, item)
+	}
+	c.normalize()
+
+	if !c.IsEmpty() {
+		ttl, err := cmd.TTL(k).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ttl > 0 {
+			expiresAt := time.Now().Add(ttl)
+			c.ExpiresAt = &expiresAt
+		}
+	}
+
+	return c, nil
+}
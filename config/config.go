@@ -9,16 +9,26 @@ import (
 
 	"path/filepath"
 	"runtime"
+	_ "time/tzdata" // Kitchen time zones must resolve in minimal containers
 
 	"github.com/spf13/viper"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	Auth     AuthConfig
+	Server        ServerConfig
+	Database      DatabaseConfig
+	Redis         RedisConfig
+	Auth          AuthConfig
+	Ordering      OrderingConfig
+	Subscriptions SubscriptionsConfig
+	Cart          CartConfig
+	Payments      PaymentsConfig
+	Idempotency   IdempotencyConfig
+	Pricing       PricingConfig
+	Routing       RoutingConfig
+	Tracking      TrackingConfig
+	Blobstore     BlobstoreConfig
 }
 
 // ServerConfig holds all server related configuration
@@ -55,6 +65,118 @@ type AuthConfig struct {
 	SessionSecret     string
 }
 
+// OrderingConfig holds the rules that decide when ordering for a delivery day closes
+type OrderingConfig struct {
+	// CutoffLeadHours is how many hours before CutoffTime on the delivery day ordering closes
+	CutoffLeadHours int
+	// CutoffTime is the kitchen local clock time (HH:MM) the lead time is measured from
+	CutoffTime string
+	// KitchenTimezone is the IANA time zone of the kitchen, e.g. "America/New_York"
+	KitchenTimezone string
+
+	// location is KitchenTimezone, loaded once by InitConfig
+	location *time.Location
+}
+
+// SubscriptionsConfig holds the schedule of the subscription background jobs
+type SubscriptionsConfig struct {
+	// JobInterval is how often menus and draft orders are checked
+	JobInterval time.Duration
+	// AutoPlaceBefore is how long before the ordering cutoff remaining drafts are placed.
+	// It must be longer than JobInterval so every draft gets a chance to be placed.
+	AutoPlaceBefore time.Duration
+}
+
+// CartConfig holds shopping cart related configuration
+type CartConfig struct {
+	// TTL is how long a cart is kept after its last change
+	TTL time.Duration
+}
+
+// PaymentsConfig holds payment provider configuration
+type PaymentsConfig struct {
+	// Provider is the name of the registered provider new payments go through
+	Provider string
+	// FakeWebhookSecret signs webhooks accepted by the fake provider
+	FakeWebhookSecret string
+	// FakeDeclineAmount makes the fake provider decline authorizations of exactly
+	// this many minor units; zero disables declines
+	FakeDeclineAmount int64
+}
+
+// IdempotencyConfig holds configuration for Idempotency-Key handling
+type IdempotencyConfig struct {
+	// TTL is how long the response to an idempotent request is replayed for retries
+	TTL time.Duration
+}
+
+// PricingConfig holds the tax and fee rules applied to order totals. Amounts
+// are in minor units of the order's currency and rates in basis points
+// (825 = 8.25%).
+type PricingConfig struct {
+	// DefaultZone is the delivery zone of orders placed without one
+	DefaultZone string
+	// Zones maps delivery zone codes to their fees and tax jurisdiction
+	Zones map[string]PricingZone
+	// DefaultJurisdiction taxes orders in zones without their own jurisdiction
+	DefaultJurisdiction string
+	// Jurisdictions maps jurisdiction codes to their tax rates
+	Jurisdictions map[string]TaxJurisdiction
+	// SmallOrderThreshold is the discounted subtotal below which SmallOrderSurcharge
+	// is added; zero disables the surcharge
+	SmallOrderThreshold int64
+	SmallOrderSurcharge int64
+}
+
+// PricingZone holds the pricing rules of a delivery zone
+type PricingZone struct {
+	DeliveryFee  int64
+	Jurisdiction string
+}
+
+// TaxJurisdiction holds the tax rates of a jurisdiction by meal category
+type TaxJurisdiction struct {
+	Name string
+	// DefaultRate applies to categories without their own rate
+	DefaultRate int
+	// CategoryRates maps lower case meal categories to their rate
+	CategoryRates map[string]int
+}
+
+// RoutingConfig holds the inputs of delivery route planning
+type RoutingConfig struct {
+	// KitchenLatitude and KitchenLongitude locate the kitchen every run starts and ends at
+	KitchenLatitude  float64
+	KitchenLongitude float64
+	// AverageSpeedKmh is the assumed average driving speed between stops
+	AverageSpeedKmh float64
+	// StopDuration is the time a driver is assumed to spend at each stop
+	StopDuration time.Duration
+}
+
+// TrackingConfig holds live driver tracking configuration
+type TrackingConfig struct {
+	// PositionTTL is how long a driver's last position is shown to new subscribers
+	PositionTTL time.Duration
+	// Heartbeat is how often open tracking streams are kept alive and the
+	// tracked order is checked for delivery
+	Heartbeat time.Duration
+}
+
+// BlobstoreConfig holds uploaded file storage configuration
+type BlobstoreConfig struct {
+	// Driver selects the store implementation; only "local" is built in
+	Driver string
+	// LocalPath is the directory the local store keeps files in
+	LocalPath string
+	// SigningSecret signs the short-lived URLs files are served from
+	SigningSecret string
+	// URLTTL is how long a signed URL stays valid
+	URLTTL time.Duration
+	// MaxUploadBytes is the largest file accepted per upload
+	MaxUploadBytes int64
+}
+
 // AppConfig is the global configuration instance
 var AppConfig Config
 
@@ -105,6 +227,14 @@ func InitConfig() {
 	// Ensure environment is set
 	AppConfig.Server.Environment = env
 
+	// The kitchen time zone is used on every cutoff check, so it is loaded once
+	AppConfig.Ordering.location = AppConfig.Ordering.loadLocation()
+
+	// Signed URLs are only as safe as their secret
+	if AppConfig.Blobstore.SigningSecret == "" {
+		log.Fatal("blobstore.signingSecret must be set; signed file URLs could otherwise be forged")
+	}
+
 	// Print the configuration for debugging (not in production)
 	if env != "production" {
 		log.Printf("Loaded configuration: %+v", AppConfig)
@@ -151,6 +281,52 @@ func setDefaults() {
 	viper.SetDefault("redis.address", "localhost:6379")
 	viper.SetDefault("redis.password", "")
 	viper.SetDefault("redis.db", 0)
+
+	// Ordering defaults: close 48h before delivery day at 18:00 kitchen time,
+	// in the same time zone as the shipped config.yaml
+	viper.SetDefault("ordering.cutoffLeadHours", 48)
+	viper.SetDefault("ordering.cutoffTime", "18:00")
+	viper.SetDefault("ordering.kitchenTimezone", "America/New_York")
+
+	// Subscription job defaults
+	viper.SetDefault("subscriptions.jobInterval", 15*time.Minute)
+	viper.SetDefault("subscriptions.autoPlaceBefore", 2*time.Hour)
+
+	// Cart defaults
+	viper.SetDefault("cart.ttl", 72*time.Hour)
+
+	// Payments defaults: the in-process fake provider, which is only registered
+	// in development and test and has no default webhook secret
+	viper.SetDefault("payments.provider", "fake")
+	viper.SetDefault("payments.fakeWebhookSecret", "") // Only makes PAYMENTS_FAKEWEBHOOKSECRET bind
+	viper.SetDefault("payments.fakeDeclineAmount", 0)
+
+	// Idempotency defaults
+	viper.SetDefault("idempotency.ttl", 24*time.Hour)
+
+	// Pricing defaults: no taxes or fees until zones and jurisdictions are configured
+	viper.SetDefault("pricing.defaultZone", "default")
+	viper.SetDefault("pricing.smallOrderThreshold", 0)
+	viper.SetDefault("pricing.smallOrderSurcharge", 0)
+
+	// Routing defaults: city driving with a short hand-over at each stop
+	viper.SetDefault("routing.kitchenLatitude", 0)
+	viper.SetDefault("routing.kitchenLongitude", 0)
+	viper.SetDefault("routing.averageSpeedKmh", 25)
+	viper.SetDefault("routing.stopDuration", 3*time.Minute)
+
+	// Tracking defaults
+	viper.SetDefault("tracking.positionTTL", 10*time.Minute)
+	viper.SetDefault("tracking.heartbeat", 15*time.Second)
+
+	// Blob storage defaults: files on local disk, links valid for five minutes
+	viper.SetDefault("blobstore.driver", "local")
+	viper.SetDefault("blobstore.localPath", "data/blobs")
+	// No default secret: an empty one would let anyone sign file URLs. The
+	// empty default only makes BLOBSTORE_SIGNINGSECRET bind; InitConfig rejects it.
+	viper.SetDefault("blobstore.signingSecret", "")
+	viper.SetDefault("blobstore.urlTTL", 5*time.Minute)
+	viper.SetDefault("blobstore.maxUploadBytes", 10<<20)
 }
 
 // GetDSN returns the database connection string
@@ -163,3 +339,53 @@ func (c *DatabaseConfig) GetDSN() string {
 func (c *ServerConfig) GetServerAddress() string {
 	return fmt.Sprintf("%s:%s", c.Host, c.Port)
 }
+
+// RateFor returns the tax rate in basis points for a lower case meal category
+func (j *TaxJurisdiction) RateFor(category string) int {
+	if rate, exists := j.CategoryRates[category]; exists {
+		return rate
+	}
+	return j.DefaultRate
+}
+
+// Location returns the kitchen time zone, falling back to UTC if it is unknown
+func (c *OrderingConfig) Location() *time.Location {
+	if c.location != nil {
+		return c.location
+	}
+	return c.loadLocation()
+}
+
+// loadLocation loads KitchenTimezone from the time zone database
+func (c *OrderingConfig) loadLocation() *time.Location {
+	if c.KitchenTimezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(c.KitchenTimezone)
+	if err != nil {
+		log.Printf("Unknown kitchen timezone %q, using UTC: %v", c.KitchenTimezone, err)
+		return time.UTC
+	}
+	return loc
+}
+
+// CutoffFor returns the moment ordering closes for the given delivery date.
+// Only the calendar date of deliveryDate is used; the cutoff is CutoffLeadHours
+// before CutoffTime on that date in the kitchen's time zone. Whole days of the
+// lead are counted on the kitchen's calendar, so a 48 hour lead always closes
+// at CutoffTime local time, even across a daylight saving change.
+func (c *OrderingConfig) CutoffFor(deliveryDate time.Time) time.Time {
+	loc := c.Location()
+
+	hour, minute := 0, 0
+	if clock, err := time.Parse("15:04", c.CutoffTime); err == nil {
+		hour, minute = clock.Hour(), clock.Minute()
+	} else if c.CutoffTime != "" {
+		log.Printf("Invalid ordering cutoff time %q, using midnight: %v", c.CutoffTime, err)
+	}
+
+	year, month, day := deliveryDate.Date()
+	anchor := time.Date(year, month, day, hour, minute, 0, 0, loc)
+	days, hours := c.CutoffLeadHours/24, c.CutoffLeadHours%24
+	return anchor.AddDate(0, 0, -days).Add(-time.Duration(hours) * time.Hour)
+}
@@ -0,0 +1,31 @@
+package geofence
+
+// Feature is a GeoJSON Feature: a geometry with properties describing it
+type Feature struct {
+	Type       string                 `json:"type"` // Always "Feature"
+	ID         interface{}            `json:"id,omitempty"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// FeatureCollection is a GeoJSON FeatureCollection
+type FeatureCollection struct {
+	Type     string    `json:"type"` // Always "FeatureCollection"
+	Features []Feature `json:"features"`
+}
+
+// NewFeature creates a feature for a geometry
+func NewFeature(id interface{}, geometry Geometry, properties map[string]interface{}) Feature {
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	return Feature{Type: "Feature", ID: id, Geometry: geometry, Properties: properties}
+}
+
+// NewFeatureCollection creates a collection of features
+func NewFeatureCollection(features []Feature) FeatureCollection {
+	if features == nil {
+		features = []Feature{}
+	}
+	return FeatureCollection{Type: "FeatureCollection", Features: features}
+}
@@ -0,0 +1,163 @@
+// Package geofence reads GeoJSON boundaries and checks whether locations
+// fall inside them.
+package geofence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// GeoJSON geometry types accepted as boundaries
+const (
+	TypePolygon      = "Polygon"
+	TypeMultiPolygon = "MultiPolygon"
+)
+
+// ErrInvalidGeometry is returned for GeoJSON that is not a valid boundary
+var ErrInvalidGeometry = errors.New("geofence: invalid geometry")
+
+// Position is a GeoJSON position: longitude first, then latitude
+type Position []float64
+
+// Ring is a closed line of positions whose first and last positions are equal
+type Ring []Position
+
+// Polygon is an outer ring followed by the rings of any holes in it
+type Polygon []Ring
+
+// Geometry is a GeoJSON Polygon or MultiPolygon. A Polygon is kept as a
+// MultiPolygon with one polygon, and is written back as a Polygon.
+type Geometry struct {
+	Type     string
+	Polygons []Polygon
+}
+
+// geometryJSON is the GeoJSON encoding of a geometry, or of a Feature wrapping one
+type geometryJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates,omitempty"`
+	Geometry    *geometryJSON   `json:"geometry,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (g Geometry) MarshalJSON() ([]byte, error) {
+	if g.IsEmpty() {
+		return []byte("null"), nil
+	}
+	if g.Type == TypePolygon && len(g.Polygons) == 1 {
+		return json.Marshal(map[string]interface{}{"type": TypePolygon, "coordinates": g.Polygons[0]})
+	}
+	return json.Marshal(map[string]interface{}{"type": TypeMultiPolygon, "coordinates": g.Polygons})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Besides bare geometries it
+// accepts a Feature whose geometry is a Polygon or MultiPolygon, as exported
+// by most map drawing tools. The result must be checked with Validate.
+func (g *Geometry) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*g = Geometry{}
+		return nil
+	}
+
+	var raw geometryJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Type == "Feature" {
+		if raw.Geometry == nil {
+			return fmt.Errorf("%w: feature has no geometry", ErrInvalidGeometry)
+		}
+		raw = *raw.Geometry
+	}
+
+	switch raw.Type {
+	case TypePolygon:
+		var polygon Polygon
+		if err := json.Unmarshal(raw.Coordinates, &polygon); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+		}
+		*g = Geometry{Type: TypePolygon, Polygons: []Polygon{polygon}}
+	case TypeMultiPolygon:
+		var polygons []Polygon
+		if err := json.Unmarshal(raw.Coordinates, &polygons); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+		}
+		*g = Geometry{Type: TypeMultiPolygon, Polygons: polygons}
+	default:
+		return fmt.Errorf("%w: type must be %s or %s, got %q", ErrInvalidGeometry, TypePolygon, TypeMultiPolygon, raw.Type)
+	}
+	return nil
+}
+
+// IsEmpty checks if the geometry has no polygons
+func (g Geometry) IsEmpty() bool {
+	return len(g.Polygons) == 0
+}
+
+// Validate checks that every ring is closed, has at least four positions and
+// only holds positions with a valid longitude and latitude
+func (g Geometry) Validate() error {
+	if g.IsEmpty() {
+		return fmt.Errorf("%w: no polygons", ErrInvalidGeometry)
+	}
+	for _, polygon := range g.Polygons {
+		if len(polygon) == 0 {
+			return fmt.Errorf("%w: polygon has no rings", ErrInvalidGeometry)
+		}
+		for _, ring := range polygon {
+			if len(ring) < 4 {
+				return fmt.Errorf("%w: rings need at least four positions", ErrInvalidGeometry)
+			}
+			for _, position := range ring {
+				if len(position) < 2 {
+					return fmt.Errorf("%w: positions need a longitude and latitude", ErrInvalidGeometry)
+				}
+				if position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+					return fmt.Errorf("%w: position %v is out of range", ErrInvalidGeometry, []float64(position))
+				}
+			}
+			first, last := ring[0], ring[len(ring)-1]
+			if first[0] != last[0] || first[1] != last[1] {
+				return fmt.Errorf("%w: rings must end where they start", ErrInvalidGeometry)
+			}
+		}
+	}
+	return nil
+}
+
+// Contains checks if a location is inside the geometry: inside the outer
+// ring of one of its polygons and outside that polygon's holes
+func (g Geometry) Contains(latitude, longitude float64) bool {
+	for _, polygon := range g.Polygons {
+		if len(polygon) == 0 || !polygon[0].contains(longitude, latitude) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if hole.contains(longitude, latitude) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// contains checks if a point is inside the ring by counting how many of its
+// edges a ray cast from the point crosses. Delivery zones span a few
+// kilometers, so treating degrees as planar coordinates is accurate enough.
+func (r Ring) contains(x, y float64) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		xi, yi := r[i][0], r[i][1]
+		xj, yj := r[j][0], r[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddressRequest represents the request body for creating or updating an address
+type AddressRequest struct {
+	Label        string   `json:"label"`
+	Line1        string   `json:"line1" binding:"required"`
+	Line2        string   `json:"line2"`
+	City         string   `json:"city" binding:"required"`
+	Region       string   `json:"region"`
+	PostalCode   string   `json:"postal_code" binding:"required"`
+	Instructions string   `json:"instructions"`
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
+	IsDefault    bool     `json:"is_default"` // The first address always becomes the default
+}
+
+// applyTo copies the request onto an address, trimming the text fields
+func (r *AddressRequest) applyTo(address *models.Address) {
+	address.Label = strings.TrimSpace(r.Label)
+	address.Line1 = strings.TrimSpace(r.Line1)
+	address.Line2 = strings.TrimSpace(r.Line2)
+	address.City = strings.TrimSpace(r.City)
+	address.Region = strings.TrimSpace(r.Region)
+	address.PostalCode = strings.ToUpper(strings.TrimSpace(r.PostalCode))
+	address.Instructions = strings.TrimSpace(r.Instructions)
+	address.Latitude = r.Latitude
+	address.Longitude = r.Longitude
+}
+
+// parseAddressID parses the :id path parameter or responds with 400
+func parseAddressID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid address ID format"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// lockUserProfile returns a user's profile, creating it if needed. The user
+// row is locked until tx ends, so concurrent changes to the address book,
+// such as two addresses becoming the default at once, are serialized.
+func lockUserProfile(tx *gorm.DB, userID uint) (*models.UserProfile, error) {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	var profile models.UserProfile
+	err := tx.Where("user_id = ?", userID).First(&profile).Error
+	if err == gorm.ErrRecordNotFound {
+		profile = models.UserProfile{UserID: userID}
+		err = tx.Create(&profile).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// addressBook scopes a query to the addresses of a user's profile
+func addressBook(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Where("profile_id IN (?)",
+		db.Session(&gorm.Session{NewDB: true}).Model(&models.UserProfile{}).Select("id").Where("user_id = ?", userID))
+}
+
+// findAddress loads an address from a user's address book
+func findAddress(db *gorm.DB, userID, id uint) (*models.Address, error) {
+	var address models.Address
+	if err := addressBook(db, userID).First(&address, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NotFoundErrorType{Resource: "Address"}
+		}
+		return nil, err
+	}
+	return &address, nil
+}
+
+// resolveAddressZone sets the delivery zone of an address from its location
+func resolveAddressZone(tx *gorm.DB, address *models.Address) error {
+	address.Zone = ""
+	if !address.HasLocation() {
+		return nil
+	}
+
+	zone, _, err := zoneAt(tx, *address.Latitude, *address.Longitude)
+	if err != nil {
+		return err
+	}
+	if zone != nil {
+		address.Zone = zone.Code
+	}
+	return nil
+}
+
+// clearDefaultAddress unsets the default flag of a profile's other addresses,
+// before the address with ID keep becomes the default
+func clearDefaultAddress(tx *gorm.DB, profileID, keep uint) error {
+	return tx.Model(&models.Address{}).
+		Where("profile_id = ? AND id <> ? AND is_default", profileID, keep).
+		Update("is_default", false).Error
+}
+
+// saveAddress validates an address of a locked profile, resolves its zone and
+// creates or updates it, moving the default flag to it if requested
+func saveAddress(tx *gorm.DB, address *models.Address, makeDefault bool) error {
+	if errs := address.ValidateAddress(); len(errs) > 0 {
+		return ValidationErrorType{Message: "Invalid address data", Details: errs}
+	}
+
+	if err := resolveAddressZone(tx, address); err != nil {
+		return err
+	}
+
+	if address.ID == 0 && !makeDefault {
+		// A profile's first address is its default
+		var existing int64
+		if err := tx.Model(&models.Address{}).Where("profile_id = ?", address.ProfileID).Count(&existing).Error; err != nil {
+			return err
+		}
+		makeDefault = existing == 0
+	}
+
+	if makeDefault {
+		if err := clearDefaultAddress(tx, address.ProfileID, address.ID); err != nil {
+			return err
+		}
+		address.IsDefault = true
+	}
+
+	if address.ID == 0 {
+		return tx.Create(address).Error
+	}
+	return tx.Save(address).Error
+}
+
+// applyOrderAddress copies an entry of the customer's address book onto a new
+// order: the one with addressID if set, otherwise the default address if the
+// order has no delivery address of its own. Instructions given with the order
+// take precedence over the address's.
+func applyOrderAddress(tx *gorm.DB, order *models.Order, addressID *uint) error {
+	var address *models.Address
+	switch {
+	case addressID != nil:
+		found, err := findAddress(tx, order.UserID, *addressID)
+		if _, missing := err.(NotFoundErrorType); missing {
+			return ValidationErrorType{
+				Message: "Address does not exist",
+				Details: map[string]interface{}{"address_id": *addressID},
+			}
+		}
+		if err != nil {
+			return err
+		}
+		address = found
+
+	case order.DeliveryAddress == "" && !order.HasDeliveryLocation():
+		var found models.Address
+		err := addressBook(tx, order.UserID).Where("is_default").First(&found).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		address = &found
+
+	default:
+		return nil
+	}
+
+	order.AddressID = &address.ID
+	order.DeliveryAddress = address.Format()
+	order.DeliveryLatitude = address.Latitude
+	order.DeliveryLongitude = address.Longitude
+	if order.DeliveryInstructions == "" {
+		order.DeliveryInstructions = address.Instructions
+	}
+	return nil
+}
+
+// GetAddressesHandler lists the authenticated user's addresses, default first.
+//
+// Route: GET /profile/addresses
+// Response: 200 OK with array of Address objects
+// Error responses: 401 if unauthorized, 500 if database error
+func GetAddressesHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var addresses []models.Address
+	if err := addressBook(store.DB, userID).Order("is_default DESC").Order("id ASC").Find(&addresses).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve addresses"))
+		return
+	}
+
+	c.JSON(http.StatusOK, addresses)
+}
+
+// GetAddressHandler retrieves one of the authenticated user's addresses.
+//
+// Route: GET /profile/addresses/:id
+// Parameters: id (path) - The address ID
+// Response: 200 OK with the Address object
+// Error responses: 400 if invalid ID, 401 if unauthorized, 404 if not found, 500 if database error
+func GetAddressHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAddressID(c)
+	if !ok {
+		return
+	}
+
+	address, err := findAddress(store.DB, userID, id)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// CreateAddressHandler adds an address to the authenticated user's address
+// book, creating their profile if needed. The delivery zone of a geocoded
+// address is resolved from the zone boundaries.
+//
+// Route: POST /profile/addresses
+// Request body: JSON with line1, city, postal_code and optional label, line2, region, instructions,
+// latitude, longitude and is_default
+// Response: 201 Created with the Address object
+// Error responses: 400 if invalid data, 401 if unauthorized, 500 if database error
+func CreateAddressHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid address data", err.Error()))
+		return
+	}
+
+	var address models.Address
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		profile, err := lockUserProfile(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		address.ProfileID = profile.ID
+		req.applyTo(&address)
+		return saveAddress(tx, &address, req.IsDefault)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, address)
+}
+
+// UpdateAddressHandler replaces one of the authenticated user's addresses.
+// Orders already placed keep the address they were placed with. The default
+// flag can be moved to the address but not removed from it; make another
+// address the default instead.
+//
+// Route: PUT /profile/addresses/:id
+// Parameters: id (path) - The address ID
+// Request body: Same as POST /profile/addresses
+// Response: 200 OK with the updated Address object
+// Error responses: 400 if invalid data, 401 if unauthorized, 404 if not found, 500 if database error
+func UpdateAddressHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAddressID(c)
+	if !ok {
+		return
+	}
+
+	var req AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid address data", err.Error()))
+		return
+	}
+
+	var address *models.Address
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		if _, err := lockUserProfile(tx, userID); err != nil {
+			return err
+		}
+
+		var err error
+		if address, err = findAddress(tx, userID, id); err != nil {
+			return err
+		}
+
+		req.applyTo(address)
+		return saveAddress(tx, address, req.IsDefault)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, address)
+}
+
+// DeleteAddressHandler soft deletes one of the authenticated user's addresses.
+// If it was the default, the oldest remaining address becomes the default.
+//
+// Route: DELETE /profile/addresses/:id
+// Parameters: id (path) - The address ID
+// Response: 200 OK with success message
+// Error responses: 400 if invalid ID, 401 if unauthorized, 404 if not found, 500 if database error
+func DeleteAddressHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := parseAddressID(c)
+	if !ok {
+		return
+	}
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		profile, err := lockUserProfile(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		address, err := findAddress(tx, userID, id)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(address).Error; err != nil {
+			return err
+		}
+		if !address.IsDefault {
+			return nil
+		}
+
+		var next models.Address
+		err = tx.Where("profile_id = ?", profile.ID).Order("id ASC").First(&next).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", true).Error
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Address successfully deleted"})
+}
@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"log"
+	"meals/cart"
+	"meals/config"
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPriceChanged is the error code returned when a cart is checked out after
+// the price of one of its meals changed
+const ErrPriceChanged = "PRICE_CHANGED"
+
+// AddCartItemRequest represents the request body for adding a menu meal to the cart
+type AddCartItemRequest struct {
+	MenuMealID uint `json:"menu_meal_id" binding:"required"`
+	Quantity   int  `json:"quantity" binding:"required,min=1"`
+}
+
+// UpdateCartItemRequest represents the request body for changing a cart item's quantity
+type UpdateCartItemRequest struct {
+	Quantity int `json:"quantity" binding:"required,min=1"`
+}
+
+// CheckoutRequest represents the request body for checking out the cart
+type CheckoutRequest struct {
+	Notes        string `json:"notes"`
+	PromoCode    string `json:"promo_code"`
+	DeliveryZone string `json:"delivery_zone"`
+	UseCredit    bool   `json:"use_credit"`
+
+	DeliverySlotID *uint `json:"delivery_slot_id"`
+
+	DeliveryAddress      string   `json:"delivery_address"`
+	DeliveryLatitude     *float64 `json:"delivery_latitude"`
+	DeliveryLongitude    *float64 `json:"delivery_longitude"`
+	DeliveryInstructions string   `json:"delivery_instructions"`
+	AddressID            *uint    `json:"address_id"` // Takes precedence over the delivery address fields
+
+	AcknowledgeAllergens bool `json:"acknowledge_allergens"` // Orders meals with declared allergens anyway
+}
+
+// carts returns the cart service backed by store.RedisClient
+func carts() *cart.Service {
+	return cart.NewService(store.RedisClient, config.AppConfig.Cart.TTL)
+}
+
+// cartError converts errors from the cart service into application errors
+func cartError(err error) error {
+	if err == cart.ErrConcurrentUpdate {
+		return ConflictErrorType{Message: "The cart was changed by another request, please try again"}
+	}
+	return err
+}
+
+// priceCartItem checks that quantity portions of the menu meal can still be
+// ordered and returns it as a cart item priced at the meal's current price
+func priceCartItem(db *gorm.DB, menuMealID uint, quantity int, now time.Time) (cart.Item, error) {
+	var menuMeal models.MenuMeal
+	if err := db.Preload("Menu").Preload("Meal").First(&menuMeal, menuMealID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return cart.Item{}, NotFoundErrorType{Resource: "Menu meal"}
+		}
+		return cart.Item{}, err
+	}
+
+	deliveryDate, err := resolveOrderDeliveryDate([]models.MenuMeal{menuMeal}, now)
+	if err != nil {
+		return cart.Item{}, err
+	}
+
+	if !menuMeal.CanReserve(quantity) {
+		remaining, _ := menuMeal.Remaining()
+		return cart.Item{}, ConflictErrorType{
+			Code:    ErrSoldOut,
+			Message: menuMeal.Meal.Name + " is sold out",
+			Details: map[string]interface{}{
+				"menu_meal_id": menuMeal.ID,
+				"requested":    quantity,
+				"remaining":    remaining,
+			},
+		}
+	}
+
+	return cart.Item{
+		MenuMealID:   menuMeal.ID,
+		Quantity:     quantity,
+		UnitPrice:    menuMeal.Meal.Price,
+		DeliveryDate: deliveryDate,
+		AddedAt:      now,
+	}, nil
+}
+
+// checkCartCompatibility ensures an item can be ordered together with the rest
+// of the cart, which becomes a single order on checkout
+func checkCartCompatibility(c *cart.Cart, item cart.Item) error {
+	for _, other := range c.Items {
+		if other.MenuMealID == item.MenuMealID {
+			continue
+		}
+		if !other.DeliveryDate.Equal(item.DeliveryDate) {
+			return ValidationErrorType{
+				Message: "All items in the cart must be delivered on the same day",
+				Details: map[string]interface{}{
+					"delivery_dates": []string{other.DeliveryDate.Format("2006-01-02"), item.DeliveryDate.Format("2006-01-02")},
+				},
+			}
+		}
+		if other.UnitPrice.Currency != item.UnitPrice.Currency {
+			return ValidationErrorType{
+				Message: "All items in the cart must be priced in the same currency",
+				Details: map[string]interface{}{
+					"currencies": []string{other.UnitPrice.Currency, item.UnitPrice.Currency},
+				},
+			}
+		}
+	}
+	return nil
+}
+
+// verifyCartPrices compares the cart's prices with the current meal prices
+// within tx, locking the meal rows so prices cannot change until the order is
+// placed. Changed items are returned with their current prices.
+func verifyCartPrices(tx *gorm.DB, c *cart.Cart) ([]cart.Item, error) {
+	menuMealIDs := make([]uint, 0, len(c.Items))
+	for _, item := range c.Items {
+		menuMealIDs = append(menuMealIDs, item.MenuMealID)
+	}
+
+	var menuMeals []models.MenuMeal
+	if err := tx.Where("id IN ?", menuMealIDs).Find(&menuMeals).Error; err != nil {
+		return nil, err
+	}
+
+	mealIDs := make([]uint, 0, len(menuMeals))
+	mealByMenuMeal := make(map[uint]uint, len(menuMeals))
+	for _, menuMeal := range menuMeals {
+		mealIDs = append(mealIDs, menuMeal.MealID)
+		mealByMenuMeal[menuMeal.ID] = menuMeal.MealID
+	}
+
+	var meals []models.Meal
+	if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Where("id IN ?", mealIDs).Find(&meals).Error; err != nil {
+		return nil, err
+	}
+
+	prices := make(map[uint]models.Money, len(meals))
+	for _, meal := range meals {
+		prices[meal.ID] = meal.Price
+	}
+
+	var changed []cart.Item
+	for _, item := range c.Items {
+		mealID, exists := mealByMenuMeal[item.MenuMealID]
+		if !exists {
+			// Missing menu meals are reported by order placement
+			continue
+		}
+		if current := prices[mealID]; current != item.UnitPrice {
+			item.UnitPrice = current
+			changed = append(changed, item)
+		}
+	}
+
+	return changed, nil
+}
+
+// parseMenuMealID parses the :menu_meal_id path parameter or responds with 400
+func parseMenuMealID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("menu_meal_id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid menu meal ID format"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetCartHandler retrieves the authenticated user's cart.
+//
+// Route: GET /cart
+// Response: 200 OK with the Cart object (empty if the user has no cart)
+// Error responses: 401 if unauthorized, 500 if Redis error
+func GetCartHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	current, err := carts().Get(userID)
+	if err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve cart"))
+		return
+	}
+
+	c.JSON(http.StatusOK, current)
+}
+
+// AddCartItemHandler adds portions of a menu meal to the cart, or increases the
+// quantity if it is already there.
+//
+// The meal must still be open for ordering, have enough portions left and be
+// delivered on the same day as the rest of the cart. The item's price is
+// refreshed to the meal's current price.
+//
+// Route: POST /cart/items
+// Request body: JSON with menu_meal_id and quantity
+// Response: 200 OK with the updated Cart object
+// Error responses: 400 if invalid data or ordering has closed, 401 if unauthorized, 404 if menu meal not found,
+// 409 if sold out, 500 if database error
+func AddCartItemHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req AddCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid cart item data", err.Error()))
+		return
+	}
+
+	updated, err := carts().Update(userID, func(current *cart.Cart) error {
+		quantity := req.Quantity
+		addedAt := time.Now()
+		if existing := current.Find(req.MenuMealID); existing != nil {
+			quantity += existing.Quantity
+			addedAt = existing.AddedAt
+		}
+
+		item, err := priceCartItem(store.DB, req.MenuMealID, quantity, time.Now())
+		if err != nil {
+			return err
+		}
+		item.AddedAt = addedAt
+
+		if err := checkCartCompatibility(current, item); err != nil {
+			return err
+		}
+
+		current.Set(item)
+		return nil
+	})
+
+	if HandleAppError(c, cartError(err)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// UpdateCartItemHandler sets the quantity of a menu meal already in the cart.
+//
+// Route: PUT /cart/items/:menu_meal_id
+// Parameters: menu_meal_id (path) - The menu meal ID
+// Request body: JSON with quantity
+// Response: 200 OK with the updated Cart object
+// Error responses: 400 if invalid data or ordering has closed, 401 if unauthorized, 404 if the item is not in the cart,
+// 409 if sold out, 500 if database error
+func UpdateCartItemHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	menuMealID, ok := parseMenuMealID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateCartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid cart item data", err.Error()))
+		return
+	}
+
+	updated, err := carts().Update(userID, func(current *cart.Cart) error {
+		existing := current.Find(menuMealID)
+		if existing == nil {
+			return NotFoundErrorType{Resource: "Cart item"}
+		}
+
+		item, err := priceCartItem(store.DB, menuMealID, req.Quantity, time.Now())
+		if err != nil {
+			return err
+		}
+		item.AddedAt = existing.AddedAt
+
+		current.Set(item)
+		return nil
+	})
+
+	if HandleAppError(c, cartError(err)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// RemoveCartItemHandler removes a menu meal from the cart.
+//
+// Route: DELETE /cart/items/:menu_meal_id
+// Parameters: menu_meal_id (path) - The menu meal ID
+// Response: 200 OK with the updated Cart object
+// Error responses: 400 if invalid ID, 401 if unauthorized, 404 if the item is not in the cart, 500 if Redis error
+func RemoveCartItemHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	menuMealID, ok := parseMenuMealID(c)
+	if !ok {
+		return
+	}
+
+	updated, err := carts().Update(userID, func(current *cart.Cart) error {
+		if !current.Remove(menuMealID) {
+			return NotFoundErrorType{Resource: "Cart item"}
+		}
+		return nil
+	})
+
+	if HandleAppError(c, cartError(err)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// ClearCartHandler empties the authenticated user's cart.
+//
+// Route: DELETE /cart
+// Response: 204 No Content
+// Error responses: 401 if unauthorized, 500 if Redis error
+func ClearCartHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := carts().Clear(userID); err != nil {
+		RespondWithError(c, DatabaseError("Failed to clear cart"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CheckoutCartHandler converts the cart into an order.
+//
+// Prices and availability are re-validated against the database within the
+// same transaction that places the order. If any price changed since it was
+// added, nothing is ordered, the cart is updated to the current prices and 409
+// is returned so the customer can review the new total. The cart is cleared
+// once the order is placed.
+//
+// Route: POST /cart/checkout
+// Request body: optional JSON with notes, promo_code, delivery_zone, delivery_slot_id, use_credit, address_id or
+// delivery address and acknowledge_allergens
+// Response: 201 Created with the created Order object
+// Error responses: 400 if the cart is empty or ordering has closed, 401 if unauthorized,
+// 409 if prices changed, a meal sold out, the delivery slot is full or meals contain declared allergens,
+// 500 if database error
+func CheckoutCartHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CheckoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondWithError(c, ValidationError("Invalid checkout data", err.Error()))
+			return
+		}
+	}
+
+	service := carts()
+	current, err := service.Get(userID)
+	if err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve cart"))
+		return
+	}
+
+	if current.IsEmpty() {
+		RespondWithError(c, ValidationError("Cart is empty", nil))
+		return
+	}
+
+	order := models.Order{
+		UserID:       userID,
+		Notes:        req.Notes,
+		PromoCode:    req.PromoCode,
+		DeliveryZone: req.DeliveryZone,
+		Items:        current.OrderItems(),
+
+		DeliverySlotID: req.DeliverySlotID,
+
+		DeliveryAddress:      req.DeliveryAddress,
+		DeliveryLatitude:     req.DeliveryLatitude,
+		DeliveryLongitude:    req.DeliveryLongitude,
+		DeliveryInstructions: req.DeliveryInstructions,
+	}
+
+	actor := actorFromContext(c)
+	var changed []cart.Item
+
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		repriced, err := verifyCartPrices(tx, current)
+		if err != nil {
+			return err
+		}
+		if len(repriced) > 0 {
+			changed = repriced
+			return ConflictErrorType{
+				Code:    ErrPriceChanged,
+				Message: "Prices changed since the items were added to the cart",
+				Details: map[string]interface{}{"items": repriced},
+			}
+		}
+
+		if err := applyOrderAddress(tx, &order, req.AddressID); err != nil {
+			return err
+		}
+		if err := checkOrderAllergens(tx, &order, order.Items, req.AcknowledgeAllergens); err != nil {
+			return err
+		}
+		return placeOrder(tx, &order, actor, req.UseCredit)
+	})
+
+	if len(changed) > 0 {
+		// Reprice the cart so the customer can review and check out again
+		if _, updateErr := service.Update(userID, func(latest *cart.Cart) error {
+			for _, item := range changed {
+				if existing := latest.Find(item.MenuMealID); existing != nil {
+					existing.UnitPrice = item.UnitPrice
+					latest.Set(*existing)
+				}
+			}
+			return nil
+		}); updateErr != nil {
+			log.Printf("Failed to reprice cart for user %d: %v", userID, updateErr)
+		}
+	}
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	if err := service.Clear(userID); err != nil {
+		log.Printf("Failed to clear cart for user %d after placing order %d: %v", userID, order.ID, err)
+	}
+
+	created, err := loadOrder(store.DB, order.ID)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
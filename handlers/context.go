@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"meals/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID returns the authenticated user's ID set by auth.RequireRole
+func currentUserID(c *gin.Context) (uint, bool) {
+	value, exists := c.Get("userID")
+	if !exists {
+		return 0, false
+	}
+
+	userID, ok := value.(uint)
+	return userID, ok
+}
+
+// currentUserType returns the authenticated user's role set by auth.RequireRole
+func currentUserType(c *gin.Context) models.UserType {
+	value, exists := c.Get("userType")
+	if !exists {
+		return ""
+	}
+
+	userType, _ := value.(models.UserType)
+	return userType
+}
+
+// requireUserID returns the authenticated user's ID or responds with 401
+func requireUserID(c *gin.Context) (uint, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		HandleAppError(c, UnauthorizedErrorType{Message: "Authentication required"})
+		return 0, false
+	}
+	return userID, true
+}
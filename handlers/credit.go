@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreditResponse is a user's store credit balances and ledger history
+type CreditResponse struct {
+	Balances []models.Money       `json:"balances"` // One per currency with entries
+	Entries  []models.LedgerEntry `json:"entries"`  // Newest first
+}
+
+// CreditAdjustmentRequest represents the request body for adjusting a user's store credit
+type CreditAdjustmentRequest struct {
+	Amount models.Money `json:"amount"` // Positive to credit, negative to debit
+	Note   string       `json:"note" binding:"required"`
+}
+
+// lockUserLedger locks the user's row so appends to their ledger are
+// serialized and every entry sees the balance left by the previous one
+func lockUserLedger(tx *gorm.DB, userID uint) error {
+	var user models.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userID).Error
+	if err == gorm.ErrRecordNotFound {
+		return NotFoundErrorType{Resource: "User"}
+	}
+	return err
+}
+
+// creditBalance returns the user's store credit in a currency, read from the
+// balance of their latest entry in that currency
+func creditBalance(tx *gorm.DB, userID uint, currency string) (models.Money, error) {
+	var latest models.LedgerEntry
+	err := tx.Where("user_id = ? AND balance_currency = ?", userID, currency).
+		Order("id DESC").
+		First(&latest).Error
+	if err == gorm.ErrRecordNotFound {
+		return models.NewMoney(0, currency), nil
+	}
+	if err != nil {
+		return models.Money{}, err
+	}
+	return latest.Balance, nil
+}
+
+// appendLedgerEntry records a credit or debit on entry.UserID's store credit
+// within tx, setting the entry's running balance. Debits may not take the
+// balance below zero.
+func appendLedgerEntry(tx *gorm.DB, entry *models.LedgerEntry) error {
+	if err := lockUserLedger(tx, entry.UserID); err != nil {
+		return err
+	}
+
+	balance, err := creditBalance(tx, entry.UserID, entry.Amount.Currency)
+	if err != nil {
+		return err
+	}
+
+	entry.Balance = balance.Add(entry.Amount)
+	if entry.Balance.IsNegative() {
+		return ConflictErrorType{
+			Code:    ErrInsufficientCredit,
+			Message: "Not enough store credit",
+			Details: map[string]interface{}{
+				"balance": balance,
+				"amount":  entry.Amount,
+			},
+		}
+	}
+
+	return tx.Create(entry).Error
+}
+
+// applyStoreCredit spends as much of the customer's store credit on a new
+// order as its total allows, recording the debit in their ledger
+func applyStoreCredit(tx *gorm.DB, order *models.Order, actor orderActor) error {
+	if err := lockUserLedger(tx, order.UserID); err != nil {
+		return err
+	}
+
+	balance, err := creditBalance(tx, order.UserID, order.Total.Currency)
+	if err != nil {
+		return err
+	}
+
+	amount := balance.Amount
+	if amount > order.Total.Amount {
+		amount = order.Total.Amount
+	}
+	if amount <= 0 {
+		return nil
+	}
+
+	entry := models.LedgerEntry{
+		UserID:  order.UserID,
+		Kind:    models.LedgerEntryOrderDebit,
+		Amount:  models.NewMoney(-amount, order.Total.Currency),
+		OrderID: &order.ID,
+		ActorID: actor.UserID,
+		Note:    "Applied to order " + strconv.FormatUint(uint64(order.ID), 10),
+	}
+	if err := appendLedgerEntry(tx, &entry); err != nil {
+		return err
+	}
+
+	return setCreditApplied(tx, order, models.NewMoney(amount, order.Total.Currency))
+}
+
+// returnStoreCredit gives back the store credit spent on an order beyond
+// keep, for orders that were cancelled or whose total went down
+func returnStoreCredit(tx *gorm.DB, order *models.Order, keep models.Money, actor orderActor, note string) error {
+	excess := order.CreditApplied.Amount - keep.Amount
+	if excess <= 0 {
+		return nil
+	}
+
+	entry := models.LedgerEntry{
+		UserID:  order.UserID,
+		Kind:    models.LedgerEntryOrderCredit,
+		Amount:  models.NewMoney(excess, order.CreditApplied.Currency),
+		OrderID: &order.ID,
+		ActorID: actor.UserID,
+		Note:    note,
+	}
+	if err := appendLedgerEntry(tx, &entry); err != nil {
+		return err
+	}
+
+	return setCreditApplied(tx, order, models.NewMoney(keep.Amount, order.CreditApplied.Currency))
+}
+
+// setCreditApplied stores the store credit spent on an order
+func setCreditApplied(tx *gorm.DB, order *models.Order, credit models.Money) error {
+	order.CreditApplied = credit
+	return tx.Model(order).Updates(map[string]interface{}{
+		"credit_applied_amount":   credit.Amount,
+		"credit_applied_currency": credit.Currency,
+	}).Error
+}
+
+// loadCredit fetches a user's store credit balances and ledger entries
+func loadCredit(db *gorm.DB, userID uint) (*CreditResponse, error) {
+	response := CreditResponse{
+		Balances: []models.Money{},
+		Entries:  []models.LedgerEntry{},
+	}
+
+	if err := db.Where("user_id = ?", userID).Order("id DESC").Find(&response.Entries).Error; err != nil {
+		return nil, err
+	}
+
+	// Entries are newest first, so the first one per currency holds its balance
+	seen := make(map[string]bool)
+	for _, entry := range response.Entries {
+		if !seen[entry.Balance.Currency] {
+			seen[entry.Balance.Currency] = true
+			response.Balances = append(response.Balances, entry.Balance)
+		}
+	}
+
+	return &response, nil
+}
+
+// GetMyCreditHandler returns the authenticated user's store credit.
+//
+// Route: GET /credit
+// Response: 200 OK with the user's balances and ledger entries, newest first
+// Error responses: 401 if unauthorized, 500 if database error
+func GetMyCreditHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	credit, err := loadCredit(store.DB, userID)
+	if err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve store credit"))
+		return
+	}
+
+	c.JSON(http.StatusOK, credit)
+}
+
+// parseUserID reads the :id path parameter of user routes
+func parseUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid user ID format"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetUserCreditHandler returns a user's store credit.
+//
+// Route: GET /admin/users/:id/credit
+// Parameters: id (path) - The user ID
+// Response: 200 OK with the user's balances and ledger entries, newest first
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not an admin, 500 if database error
+func GetUserCreditHandler(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	credit, err := loadCredit(store.DB, userID)
+	if err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve store credit"))
+		return
+	}
+
+	c.JSON(http.StatusOK, credit)
+}
+
+// AdjustUserCreditHandler credits or debits a user's store credit, for
+// example as a goodwill gesture.
+//
+// Route: POST /admin/users/:id/credit
+// Parameters: id (path) - The user ID
+// Request body: JSON with amount (negative to debit) and note
+// Response: 201 Created with the LedgerEntry
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not an admin, 404 if user not found,
+// 409 if a debit exceeds the balance, 500 if database error
+func AdjustUserCreditHandler(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreditAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid credit adjustment", err.Error()))
+		return
+	}
+
+	amount := models.NewMoney(req.Amount.Amount, req.Amount.Currency)
+	magnitude := amount
+	if magnitude.IsNegative() {
+		magnitude.Amount = -magnitude.Amount
+	}
+	errs := magnitude.ValidateMoney("Amount")
+	if amount.IsZero() {
+		errs = append(errs, "Amount must not be zero")
+	}
+	if len(errs) > 0 {
+		RespondWithError(c, ValidationError("Invalid credit adjustment", errs))
+		return
+	}
+
+	actor := actorFromContext(c)
+	entry := models.LedgerEntry{
+		UserID:  userID,
+		Kind:    models.LedgerEntryAdjustment,
+		Amount:  amount,
+		ActorID: actor.UserID,
+		Note:    req.Note,
+	}
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		return appendLedgerEntry(tx, &entry)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"meals/blobstore"
+	"meals/config"
+	"meals/models"
+	"meals/store"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// blobURLPrefix is the route signed blob URLs are served from
+const blobURLPrefix = "/blobs"
+
+// proofImageTypes maps the image types accepted as proof of delivery to the
+// extension they are stored with
+var proofImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// DeliveryProofResponse is a delivery proof with short-lived links to its files
+type DeliveryProofResponse struct {
+	models.DeliveryProof
+	PhotoURL     string    `json:"photo_url,omitempty"`
+	SignatureURL string    `json:"signature_url,omitempty"`
+	URLsExpireAt time.Time `json:"urls_expire_at"`
+}
+
+// blobs returns the configured blob store
+func blobs() (blobstore.Store, error) {
+	return blobstore.New(config.AppConfig.Blobstore)
+}
+
+// blobSigner returns the signer of blob URLs
+func blobSigner() *blobstore.URLSigner {
+	return blobstore.NewURLSigner(config.AppConfig.Blobstore.SigningSecret)
+}
+
+// limitUploadBody caps the request body at files uploads of the configured
+// maximum size, plus room for the other form fields
+func limitUploadBody(c *gin.Context, files int64) {
+	limit := files*config.AppConfig.Blobstore.MaxUploadBytes + 1<<20
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+}
+
+// receiveDeliveryProof stores the photo and signature files of a multipart
+// request. It returns nil if the driver sent neither files nor a recipient
+// name. The files are stored before the proof is saved, so callers must
+// discard the proof if saving it fails.
+func receiveDeliveryProof(c *gin.Context, recipientName string) (*models.DeliveryProof, error) {
+	proof := &models.DeliveryProof{RecipientName: strings.TrimSpace(recipientName)}
+	if len(proof.RecipientName) > 100 {
+		return nil, ValidationErrorType{
+			Message: "Recipient name must be at most 100 characters",
+			Details: map[string]interface{}{"recipient_name": "too long"},
+		}
+	}
+
+	if c.ContentType() == gin.MIMEMultipartPOSTForm {
+		var err error
+		if proof.Photo, err = receiveProofImage(c, "photo"); err != nil {
+			return nil, err
+		}
+		if proof.Signature, err = receiveProofImage(c, "signature"); err != nil {
+			discardDeliveryProof(c, proof)
+			return nil, err
+		}
+	}
+
+	if proof.RecipientName == "" && len(proof.Blobs()) == 0 {
+		return nil, nil
+	}
+	return proof, nil
+}
+
+// receiveProofImage validates and stores the image uploaded in a form field.
+// The type is sniffed from the content rather than trusted from the client.
+func receiveProofImage(c *gin.Context, field string) (models.BlobRef, error) {
+	header, err := c.FormFile(field)
+	if err == http.ErrMissingFile {
+		return models.BlobRef{}, nil
+	}
+	if err != nil {
+		return models.BlobRef{}, ValidationErrorType{Message: "Invalid " + field + " upload", Details: err.Error()}
+	}
+
+	maxBytes := config.AppConfig.Blobstore.MaxUploadBytes
+	if header.Size > maxBytes {
+		return models.BlobRef{}, ValidationErrorType{
+			Message: "The " + field + " is too large",
+			Details: map[string]interface{}{"field": field, "size": header.Size, "max_bytes": maxBytes},
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return models.BlobRef{}, err
+	}
+	defer file.Close()
+
+	contentType, err := sniffContentType(file)
+	if err != nil {
+		return models.BlobRef{}, err
+	}
+	extension, allowed := proofImageTypes[contentType]
+	if !allowed {
+		return models.BlobRef{}, ValidationErrorType{
+			Message: "The " + field + " must be a JPEG, PNG or WebP image",
+			Details: map[string]interface{}{"field": field, "content_type": contentType},
+		}
+	}
+
+	store, err := blobs()
+	if err != nil {
+		return models.BlobRef{}, err
+	}
+	key := "proofs/" + uuid.New().String() + extension
+	if err := store.Put(c.Request.Context(), key, file); err != nil {
+		return models.BlobRef{}, err
+	}
+
+	return models.BlobRef{Key: key, ContentType: contentType, Size: header.Size}, nil
+}
+
+// sniffContentType detects the type of an upload from its first bytes and
+// rewinds it
+func sniffContentType(file multipart.File) (string, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
+}
+
+// discardDeliveryProof deletes the stored files of a proof that was not saved
+func discardDeliveryProof(c *gin.Context, proof *models.DeliveryProof) {
+	if proof == nil {
+		return
+	}
+	store, err := blobs()
+	if err != nil {
+		return
+	}
+	for _, blob := range proof.Blobs() {
+		if err := store.Delete(c.Request.Context(), blob.Key); err != nil {
+			log.Printf("Failed to delete unused blob %s: %v", blob.Key, err)
+		}
+	}
+}
+
+// GetOrderProofHandler retrieves the proof of delivery of an order with
+// short-lived signed links to its photo and signature.
+//
+// Route: GET /orders/:id/proof
+// Parameters: id (path) - The order ID
+// Response: 200 OK with the DeliveryProof and its signed URLs
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not a customer or admin,
+// 404 if the order or its proof is not found, 500 if database error
+func GetOrderProofHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid order ID format"))
+		return
+	}
+
+	var order models.Order
+	if err := store.DB.Select("id", "user_id").First(&order, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			RespondWithError(c, NotFoundError("Order"))
+			return
+		}
+		RespondWithError(c, DatabaseError("Failed to retrieve order"))
+		return
+	}
+
+	if currentUserType(c) != models.UserTypeAdmin && !order.BelongsTo(userID) {
+		RespondWithError(c, NotFoundError("Order"))
+		return
+	}
+
+	var proof models.DeliveryProof
+	if err := store.DB.Where("order_id = ?", order.ID).First(&proof).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			RespondWithError(c, NotFoundError("Delivery proof"))
+			return
+		}
+		RespondWithError(c, DatabaseError("Failed to retrieve delivery proof"))
+		return
+	}
+
+	expires := time.Now().Add(config.AppConfig.Blobstore.URLTTL)
+	signer := blobSigner()
+	response := DeliveryProofResponse{DeliveryProof: proof, URLsExpireAt: expires}
+	if !proof.Photo.IsEmpty() {
+		response.PhotoURL = signer.SignedURL(blobURLPrefix, proof.Photo.Key, expires)
+	}
+	if !proof.Signature.IsEmpty() {
+		response.SignatureURL = signer.SignedURL(blobURLPrefix, proof.Signature.Key, expires)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ServeBlobHandler serves a stored file to holders of a valid signed URL.
+// No session is needed: the signature proves the link was handed out by the
+// app, and it stops working when it expires.
+//
+// Route: GET /blobs/*key
+// Parameters: key (path) - The blob key, expires (query) - Unix expiry time,
+// signature (query) - URL signature
+// Response: 200 OK with the file
+// Error responses: 403 if the signature is invalid or expired, 404 if not found, 500 if storage error
+func ServeBlobHandler(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if !blobSigner().Verify(key, c.Query("expires"), c.Query("signature"), time.Now()) {
+		HandleAppError(c, ForbiddenErrorType{Message: "Invalid or expired link"})
+		return
+	}
+
+	store, err := blobs()
+	if err != nil {
+		RespondWithError(c, DatabaseError("Failed to open blob store"))
+		return
+	}
+
+	file, err := store.Open(c.Request.Context(), key)
+	if err == blobstore.ErrNotFound || err == blobstore.ErrInvalidKey {
+		RespondWithError(c, NotFoundError("File"))
+		return
+	}
+	if err != nil {
+		RespondWithError(c, DatabaseError("Failed to read file"))
+		return
+	}
+	defer file.Close()
+
+	contentType := "application/octet-stream"
+	for candidate, extension := range proofImageTypes {
+		if strings.HasSuffix(key, extension) {
+			contentType = candidate
+		}
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, -1, contentType, file, nil)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"math"
+	"meals/config"
+	"meals/models"
+	"meals/routing"
+	"meals/store"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// routeOptimizer plans routes from the configured kitchen
+func routeOptimizer() routing.Optimizer {
+	cfg := config.AppConfig.Routing
+	return routing.Optimizer{
+		Depot:    routing.Point{Latitude: cfg.KitchenLatitude, Longitude: cfg.KitchenLongitude},
+		SpeedKmh: cfg.AverageSpeedKmh,
+	}
+}
+
+// planRoute renumbers the stops of a locked run and stores their leg
+// estimates and the run's totals within tx. With reorder the stops with a
+// delivery location are put in optimized order; otherwise the current order
+// is kept. Stops whose order has no location come last, in their current
+// order, without estimates.
+func planRoute(tx *gorm.DB, run *models.DeliveryRun, reorder bool) error {
+	var stops []models.DeliveryStop
+	if err := tx.Preload("Order").
+		Where("run_id = ?", run.ID).
+		Order("sequence ASC").
+		Find(&stops).Error; err != nil {
+		return err
+	}
+
+	var located, unlocated []models.DeliveryStop
+	var points []routing.Point
+	for _, stop := range stops {
+		if stop.Order == nil || !stop.Order.HasDeliveryLocation() {
+			unlocated = append(unlocated, stop)
+			continue
+		}
+		located = append(located, stop)
+		points = append(points, routing.Point{
+			Latitude:  *stop.Order.DeliveryLatitude,
+			Longitude: *stop.Order.DeliveryLongitude,
+		})
+	}
+
+	optimizer := routeOptimizer()
+	var legs []routing.Leg
+	if reorder {
+		route := optimizer.Optimize(points)
+		ordered := make([]models.DeliveryStop, len(located))
+		for i, index := range route.Order {
+			ordered[i] = located[index]
+		}
+		located, legs = ordered, route.Legs
+	} else {
+		legs = optimizer.Legs(points)
+	}
+
+	for i, stop := range append(located, unlocated...) {
+		updates := models.DeliveryStop{Sequence: i + 1}
+		if i < len(located) {
+			updates.LegDistanceMeters = meters(legs[i].Distance)
+			updates.LegDurationSeconds = seconds(legs[i].Duration)
+		}
+		if err := tx.Model(&stop).
+			Select("sequence", "leg_distance_meters", "leg_duration_seconds").
+			Updates(&updates).Error; err != nil {
+			return err
+		}
+	}
+
+	run.RouteDistanceMeters, run.RouteDurationSeconds = nil, nil
+	if len(located) > 0 {
+		route := routing.Route{Legs: legs}
+		stopTime := time.Duration(len(located)) * config.AppConfig.Routing.StopDuration
+		run.RouteDistanceMeters = meters(route.Distance())
+		run.RouteDurationSeconds = seconds(route.Duration() + stopTime)
+	}
+	columns := []string{"route_distance_meters", "route_duration_seconds"}
+	if reorder {
+		now := time.Now()
+		run.OptimizedAt = &now
+		columns = append(columns, "optimized_at")
+	}
+	return tx.Model(run).Select(columns).Updates(run).Error
+}
+
+// meters rounds a distance to whole meters
+func meters(distance float64) *int {
+	rounded := int(math.Round(distance))
+	return &rounded
+}
+
+// seconds rounds a duration to whole seconds
+func seconds(duration time.Duration) *int {
+	rounded := int(duration.Round(time.Second) / time.Second)
+	return &rounded
+}
+
+// OptimizeDeliveryRunHandler reorders the stops of a planned run into a short
+// route from the kitchen and back, for example after stops were added or
+// removed.
+//
+// Route: POST /admin/delivery-runs/:id/optimize
+// Parameters: id (path) - The delivery run ID
+// Response: 200 OK with the updated DeliveryRun
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not an admin, 404 if run not found,
+// 409 if the run has started, 500 if database error
+func OptimizeDeliveryRunHandler(c *gin.Context) {
+	id, ok := parseDeliveryRunID(c)
+	if !ok {
+		return
+	}
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		run, err := lockDeliveryRun(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !run.IsEditable() {
+			return ConflictErrorType{
+				Message: "Only planned delivery runs can be re-optimized",
+				Details: map[string]interface{}{"status": run.Status},
+			}
+		}
+
+		return planRoute(tx, run, true)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	updated, err := loadDeliveryRun(store.DB, id)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
@@ -0,0 +1,486 @@
+package handlers
+
+import (
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateDeliveryRunRequest represents the request body for creating a delivery run
+type CreateDeliveryRunRequest struct {
+	DeliveryDate string `json:"delivery_date" binding:"required"` // YYYY-MM-DD
+	Name         string `json:"name"`
+	DriverID     *uint  `json:"driver_id"`
+	OrderIDs     []uint `json:"order_ids"` // Stops, put in optimized route order
+}
+
+// AssignDriverRequest represents the request body for assigning a run's driver
+type AssignDriverRequest struct {
+	DriverID *uint `json:"driver_id"` // Nil unassigns the run
+}
+
+// AddStopsRequest represents the request body for adding orders to a run
+type AddStopsRequest struct {
+	OrderIDs []uint `json:"order_ids" binding:"required,min=1"`
+}
+
+// parseDeliveryRunID parses the :id path parameter or responds with 400
+func parseDeliveryRunID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid delivery run ID format"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// lockDeliveryRun loads a run with a row lock so concurrent assignment
+// changes to it are serialized
+func lockDeliveryRun(tx *gorm.DB, id uint) (*models.DeliveryRun, error) {
+	var run models.DeliveryRun
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&run, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NotFoundErrorType{Resource: "Delivery run"}
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// loadDeliveryRun fetches a run with its stops in sequence and their orders
+func loadDeliveryRun(db *gorm.DB, id uint) (*models.DeliveryRun, error) {
+	var run models.DeliveryRun
+	err := db.Preload("Stops", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sequence ASC")
+	}).Preload("Stops.Order.Items").First(&run, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NotFoundErrorType{Resource: "Delivery run"}
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+// checkDriver verifies that a user exists and is a driver
+func checkDriver(tx *gorm.DB, driverID uint) error {
+	var driver models.User
+	err := tx.Select("id", "user_type").First(&driver, driverID).Error
+	if err == gorm.ErrRecordNotFound || (err == nil && driver.UserType != models.UserTypeDriver) {
+		return ValidationErrorType{
+			Message: "Delivery runs can only be assigned to drivers",
+			Details: map[string]interface{}{"driver_id": driverID},
+		}
+	}
+	return err
+}
+
+// addRunStops appends orders to the end of a locked run within tx. The
+// orders are locked in ID order, so two admins assigning overlapping orders
+// at once are serialized and the second sees the first's stops.
+func addRunStops(tx *gorm.DB, run *models.DeliveryRun, orderIDs []uint) error {
+	ids := uniqueIDs(orderIDs)
+	sorted := append([]uint(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var orders []models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", sorted).
+		Order("id ASC").
+		Find(&orders).Error; err != nil {
+		return err
+	}
+	if len(orders) != len(sorted) {
+		return RelationshipErrorType{
+			Message: "One or more order IDs do not exist",
+			Details: map[string]interface{}{
+				"provided_ids": ids,
+				"found_count":  len(orders),
+			},
+		}
+	}
+
+	for _, order := range orders {
+		if !order.DeliveryDate.Equal(run.DeliveryDate) {
+			return ValidationErrorType{
+				Message: "Orders must be delivered on the run's delivery date",
+				Details: map[string]interface{}{
+					"order_id":      order.ID,
+					"delivery_date": order.DeliveryDate.Format("2006-01-02"),
+				},
+			}
+		}
+		if !order.Status.AwaitsDispatch() {
+			return ConflictErrorType{
+				Message: "Only placed, confirmed and preparing orders can be put on a delivery run",
+				Details: map[string]interface{}{
+					"order_id": order.ID,
+					"status":   order.Status,
+				},
+			}
+		}
+	}
+
+	var assigned []models.DeliveryStop
+	if err := tx.Where("order_id IN ?", sorted).Find(&assigned).Error; err != nil {
+		return err
+	}
+	if len(assigned) > 0 {
+		runByOrder := make(map[uint]uint, len(assigned))
+		for _, stop := range assigned {
+			runByOrder[stop.OrderID] = stop.RunID
+		}
+		return ConflictErrorType{
+			Code:    ErrAlreadyAssigned,
+			Message: "One or more orders are already on a delivery run",
+			Details: map[string]interface{}{"runs_by_order": runByOrder},
+		}
+	}
+
+	var last int
+	if err := tx.Model(&models.DeliveryStop{}).
+		Where("run_id = ?", run.ID).
+		Select("COALESCE(MAX(sequence), 0)").
+		Scan(&last).Error; err != nil {
+		return err
+	}
+
+	stops := make([]models.DeliveryStop, 0, len(ids))
+	for i, orderID := range ids {
+		stops = append(stops, models.DeliveryStop{
+			RunID:    run.ID,
+			OrderID:  orderID,
+			Sequence: last + i + 1,
+			Status:   models.DeliveryStopStatusPending,
+		})
+	}
+	return tx.Create(&stops).Error
+}
+
+// CreateDeliveryRunHandler groups orders of one delivery day into a run.
+//
+// The run, its driver assignment and its stops are created in one
+// transaction. Orders are locked while they are checked, so an order can
+// never end up on two runs. The stops are put in optimized route order.
+//
+// Route: POST /admin/delivery-runs
+// Request body: JSON with delivery_date, optional name, driver_id and order_ids
+// Response: 201 Created with the DeliveryRun and its stops
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not an admin,
+// 409 if an order is already on a run or cannot be delivered, 500 if database error
+func CreateDeliveryRunHandler(c *gin.Context) {
+	var req CreateDeliveryRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid delivery run data", err.Error()))
+		return
+	}
+
+	deliveryDate, err := parseDate("delivery_date", req.DeliveryDate)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	run := models.DeliveryRun{
+		DeliveryDate: deliveryDate,
+		Name:         req.Name,
+		DriverID:     req.DriverID,
+		Status:       models.DeliveryRunStatusPlanned,
+	}
+
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		if run.DriverID != nil {
+			if err := checkDriver(tx, *run.DriverID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&run).Error; err != nil {
+			return err
+		}
+
+		if len(req.OrderIDs) == 0 {
+			return nil
+		}
+		if err := addRunStops(tx, &run, req.OrderIDs); err != nil {
+			return err
+		}
+		return planRoute(tx, &run, true)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	created, err := loadDeliveryRun(store.DB, run.ID)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// GetDeliveryRunsHandler lists the delivery runs of a day.
+//
+// Route: GET /admin/delivery-runs
+// Parameters: date (query, required) - Delivery date (YYYY-MM-DD)
+// Response: 200 OK with array of DeliveryRun objects and their stops
+// Error responses: 400 if invalid date, 401 if unauthorized, 403 if not an admin, 500 if database error
+func GetDeliveryRunsHandler(c *gin.Context) {
+	date, err := parseDate("date", c.Query("date"))
+	if HandleAppError(c, err) {
+		return
+	}
+
+	var runs []models.DeliveryRun
+	if err := store.DB.Preload("Stops", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sequence ASC")
+	}).Where("delivery_date = ?", date).Order("id ASC").Find(&runs).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve delivery runs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// GetDeliveryRunHandler retrieves a delivery run with its stops and orders.
+// Drivers may only see their own runs.
+//
+// Route: GET /delivery-runs/:id
+// Parameters: id (path) - The delivery run ID
+// Response: 200 OK with the DeliveryRun object
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not an admin or driver,
+// 404 if run not found, 500 if database error
+func GetDeliveryRunHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseDeliveryRunID(c)
+	if !ok {
+		return
+	}
+
+	run, err := loadDeliveryRun(store.DB, id)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	if currentUserType(c) == models.UserTypeDriver && !run.IsAssignedTo(userID) {
+		HandleAppError(c, NotFoundErrorType{Resource: "Delivery run"})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// AssignDeliveryRunDriverHandler assigns, reassigns or unassigns a run's driver.
+//
+// Route: PUT /admin/delivery-runs/:id/driver
+// Parameters: id (path) - The delivery run ID
+// Request body: JSON with driver_id (null to unassign)
+// Response: 200 OK with the updated DeliveryRun
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not an admin, 404 if run not found,
+// 409 if the run has been completed, 500 if database error
+func AssignDeliveryRunDriverHandler(c *gin.Context) {
+	id, ok := parseDeliveryRunID(c)
+	if !ok {
+		return
+	}
+
+	var req AssignDriverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid request data", err.Error()))
+		return
+	}
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		run, err := lockDeliveryRun(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if run.Status == models.DeliveryRunStatusCompleted {
+			return ConflictErrorType{
+				Message: "Completed delivery runs cannot be reassigned",
+				Details: map[string]interface{}{"status": run.Status},
+			}
+		}
+		if req.DriverID == nil && run.Status != models.DeliveryRunStatusPlanned {
+			return ConflictErrorType{
+				Message: "Delivery runs in progress must keep a driver",
+				Details: map[string]interface{}{"status": run.Status},
+			}
+		}
+
+		if req.DriverID != nil {
+			if err := checkDriver(tx, *req.DriverID); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(run).Update("driver_id", req.DriverID).Error
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	updated, err := loadDeliveryRun(store.DB, id)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// AddDeliveryRunStopsHandler appends orders to a planned run. The existing
+// stops keep their order; the route can be re-optimized afterwards.
+//
+// Route: POST /admin/delivery-runs/:id/stops
+// Parameters: id (path) - The delivery run ID
+// Request body: JSON with order_ids (in delivery order)
+// Response: 200 OK with the updated DeliveryRun
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not an admin, 404 if run not found,
+// 409 if the run has started or an order is already on a run, 500 if database error
+func AddDeliveryRunStopsHandler(c *gin.Context) {
+	id, ok := parseDeliveryRunID(c)
+	if !ok {
+		return
+	}
+
+	var req AddStopsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid request data", err.Error()))
+		return
+	}
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		run, err := lockDeliveryRun(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !run.IsEditable() {
+			return ConflictErrorType{
+				Message: "Stops can only be added to planned delivery runs",
+				Details: map[string]interface{}{"status": run.Status},
+			}
+		}
+
+		if err := addRunStops(tx, run, req.OrderIDs); err != nil {
+			return err
+		}
+		return planRoute(tx, run, false)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	updated, err := loadDeliveryRun(store.DB, id)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// RemoveDeliveryRunStopHandler takes an order off a planned run, freeing it
+// for another run.
+//
+// Route: DELETE /admin/delivery-runs/:id/stops/:stop_id
+// Parameters: id (path) - The delivery run ID, stop_id (path) - The stop ID
+// Response: 200 OK with the updated DeliveryRun
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not an admin, 404 if run or stop not found,
+// 409 if the run has started, 500 if database error
+func RemoveDeliveryRunStopHandler(c *gin.Context) {
+	id, ok := parseDeliveryRunID(c)
+	if !ok {
+		return
+	}
+
+	stopID, err := strconv.ParseUint(c.Param("stop_id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid stop ID format"))
+		return
+	}
+
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		run, err := lockDeliveryRun(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !run.IsEditable() {
+			return ConflictErrorType{
+				Message: "Stops can only be removed from planned delivery runs",
+				Details: map[string]interface{}{"status": run.Status},
+			}
+		}
+
+		result := tx.Where("run_id = ?", run.ID).Delete(&models.DeliveryStop{}, stopID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return NotFoundErrorType{Resource: "Delivery stop"}
+		}
+
+		return planRoute(tx, run, false)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	updated, err := loadDeliveryRun(store.DB, id)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteDeliveryRunHandler deletes a planned run, freeing its orders.
+//
+// Route: DELETE /admin/delivery-runs/:id
+// Parameters: id (path) - The delivery run ID
+// Response: 204 No Content
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not an admin, 404 if run not found,
+// 409 if the run has started, 500 if database error
+func DeleteDeliveryRunHandler(c *gin.Context) {
+	id, ok := parseDeliveryRunID(c)
+	if !ok {
+		return
+	}
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		run, err := lockDeliveryRun(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if !run.IsEditable() {
+			return ConflictErrorType{
+				Message: "Only planned delivery runs can be deleted",
+				Details: map[string]interface{}{"status": run.Status},
+			}
+		}
+
+		// The AfterDelete hook soft deletes the stops
+		return tx.Delete(run).Error
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,464 @@
+package handlers
+
+import (
+	"meals/config"
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSlotFull is the error code returned when a delivery slot has no capacity left
+const ErrSlotFull = "SLOT_FULL"
+
+// maxSlotListingDays bounds the date range of a delivery slot listing
+const maxSlotListingDays = 31
+
+// SlotTemplateRequest represents the request body for creating or updating a slot template
+type SlotTemplateRequest struct {
+	Zone            string `json:"zone" binding:"required"`
+	Weekday         string `json:"weekday" binding:"required"`
+	StartTime       string `json:"start_time" binding:"required"`
+	EndTime         string `json:"end_time" binding:"required"`
+	Drivers         int    `json:"drivers"`
+	OrdersPerDriver int    `json:"orders_per_driver"`
+	Active          *bool  `json:"active"` // Defaults to true
+}
+
+// applyTo copies the request onto a slot template, normalizing the zone and weekday
+func (r *SlotTemplateRequest) applyTo(template *models.SlotTemplate) {
+	template.Zone = strings.ToLower(strings.TrimSpace(r.Zone))
+	template.Weekday = r.Weekday
+	if weekday, ok := models.ParseWeekday(r.Weekday); ok {
+		template.Weekday = weekday.String()
+	}
+	template.StartTime = strings.TrimSpace(r.StartTime)
+	template.EndTime = strings.TrimSpace(r.EndTime)
+	template.Drivers = r.Drivers
+	template.OrdersPerDriver = r.OrdersPerDriver
+	template.Active = r.Active == nil || *r.Active
+}
+
+// validateSlotTemplate validates a slot template and checks that its zone is a delivery zone
+func validateSlotTemplate(db *gorm.DB, template *models.SlotTemplate) error {
+	errs := template.ValidateSlotTemplate()
+	if template.Zone != "" {
+		known, err := isDeliveryZone(db, template.Zone)
+		if err != nil {
+			return err
+		}
+		if !known {
+			errs = append(errs, "Zone must be a delivery zone")
+		}
+	}
+	if len(errs) > 0 {
+		return ValidationErrorType{Message: "Invalid slot template data", Details: errs}
+	}
+	return nil
+}
+
+// DeliverySlotResponse is a delivery slot with the orders it can still take
+type DeliverySlotResponse struct {
+	models.DeliverySlot
+	Remaining int  `json:"remaining"`
+	Available bool `json:"available"`
+}
+
+// parseSlotTemplateID parses the :id path parameter or responds with 400
+func parseSlotTemplateID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid slot template ID format"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// generateDeliverySlots creates the missing slots of a zone's active templates
+// for the calendar dates from to to. Slots that already exist are left alone,
+// so concurrent listings generate each slot once.
+func generateDeliverySlots(db *gorm.DB, zone string, from, to time.Time) error {
+	var templates []models.SlotTemplate
+	if err := db.Where("zone = ? AND active = ?", zone, true).Find(&templates).Error; err != nil {
+		return err
+	}
+
+	loc := config.AppConfig.Ordering.Location()
+	var slots []models.DeliverySlot
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		for i := range templates {
+			if slot, ok := templates[i].SlotOn(date, loc); ok {
+				slots = append(slots, slot)
+			}
+		}
+	}
+	if len(slots) == 0 {
+		return nil
+	}
+
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&slots).Error
+}
+
+// syncTemplateSlots applies a changed slot template to its future slots within
+// tx. Slots without bookings are removed and generated again from the new
+// settings; booked slots keep their window and take the new capacity, or are
+// closed to new bookings if the template is no longer active.
+func syncTemplateSlots(tx *gorm.DB, template *models.SlotTemplate, now time.Time) error {
+	future := tx.Model(&models.DeliverySlot{}).
+		Where("template_id = ? AND starts_at > ?", template.ID, now).
+		Session(&gorm.Session{})
+
+	if err := future.Where("booked = 0").Delete(&models.DeliverySlot{}).Error; err != nil {
+		return err
+	}
+
+	var capacity interface{} = gorm.Expr("booked")
+	if template.Active && !template.DeletedAt.Valid {
+		capacity = template.Capacity()
+	}
+	return future.Update("capacity", capacity).Error
+}
+
+// lockDeliverySlot loads a delivery slot by ID with a row lock, so concurrent
+// checkouts are serialized on the slot's booking count
+func lockDeliverySlot(tx *gorm.DB, id uint) (*models.DeliverySlot, error) {
+	var slot models.DeliverySlot
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, ValidationErrorType{
+			Message: "Delivery slot does not exist",
+			Details: map[string]interface{}{"delivery_slot_id": id},
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &slot, nil
+}
+
+// checkDeliverySlotMatch verifies that a slot is in the order's delivery zone and on its delivery date
+func checkDeliverySlotMatch(slot *models.DeliverySlot, order *models.Order) error {
+	if slot.Zone != order.DeliveryZone {
+		return ValidationErrorType{
+			Message: "Delivery slot is not in the order's delivery zone",
+			Details: map[string]interface{}{
+				"delivery_slot_id": slot.ID,
+				"slot_zone":        slot.Zone,
+				"delivery_zone":    order.DeliveryZone,
+			},
+		}
+	}
+
+	if !slot.DeliveryDate.Equal(order.DeliveryDate) {
+		return ValidationErrorType{
+			Message: "Delivery slot is not on the order's delivery date",
+			Details: map[string]interface{}{
+				"delivery_slot_id": slot.ID,
+				"slot_date":        slot.DeliveryDate.Format("2006-01-02"),
+				"delivery_date":    order.DeliveryDate.Format("2006-01-02"),
+			},
+		}
+	}
+
+	return nil
+}
+
+// bookDeliverySlot books the delivery slot of a new, priced order within tx.
+// The slot row is locked before its capacity is checked, so concurrent
+// checkouts cannot overbook it.
+func bookDeliverySlot(tx *gorm.DB, order *models.Order, now time.Time) error {
+	if order.DeliverySlotID == nil {
+		return nil
+	}
+
+	slot, err := lockDeliverySlot(tx, *order.DeliverySlotID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkDeliverySlotMatch(slot, order); err != nil {
+		return err
+	}
+
+	if !now.Before(slot.StartsAt) {
+		return ValidationErrorType{
+			Message: "Delivery slot " + slot.Label + " has already started",
+			Details: map[string]interface{}{"delivery_slot_id": slot.ID},
+		}
+	}
+
+	if !slot.IsAvailableAt(now) {
+		return ConflictErrorType{
+			Code:    ErrSlotFull,
+			Message: "Delivery slot " + slot.Label + " is full",
+			Details: map[string]interface{}{
+				"delivery_slot_id": slot.ID,
+				"capacity":         slot.Capacity,
+			},
+		}
+	}
+
+	return tx.Model(slot).Update("booked", gorm.Expr("booked + 1")).Error
+}
+
+// checkBookedSlotDate verifies that an edited order is still delivered on the
+// date of the slot it booked
+func checkBookedSlotDate(tx *gorm.DB, order *models.Order) error {
+	if order.DeliverySlotID == nil {
+		return nil
+	}
+
+	var slot models.DeliverySlot
+	if err := tx.Unscoped().First(&slot, *order.DeliverySlotID).Error; err != nil {
+		return err
+	}
+	return checkDeliverySlotMatch(&slot, order)
+}
+
+// releaseDeliverySlot gives the delivery slot booking of a cancelled order back
+func releaseDeliverySlot(tx *gorm.DB, order *models.Order) error {
+	if order.DeliverySlotID == nil {
+		return nil
+	}
+
+	return tx.Model(&models.DeliverySlot{}).Unscoped().
+		Where("id = ?", *order.DeliverySlotID).
+		Update("booked", gorm.Expr("GREATEST(booked - 1, 0)")).Error
+}
+
+// GetDeliverySlotsHandler lists the delivery windows customers can pick at
+// checkout. Slots are generated from the zone's active templates on first
+// listing. Slots that have already started are left out; full slots are
+// listed as unavailable unless only available slots are requested.
+//
+// Route: GET /delivery-slots
+// Parameters: zone (query, optional) - Delivery zone, defaults to the configured zone
+// from (query, optional) - First date (YYYY-MM-DD), defaults to today in the kitchen's timezone
+// to (query, optional) - Last date (YYYY-MM-DD), defaults to six days after from; at most 31 days are listed
+// available (query, optional) - "true" to list only slots that can still be booked
+// Response: 200 OK with array of delivery slots ordered by start time
+// Error responses: 400 if invalid parameters, 500 if database error
+func GetDeliverySlotsHandler(c *gin.Context) {
+	zone := strings.ToLower(strings.TrimSpace(c.Query("zone")))
+	if zone == "" {
+		zone = strings.ToLower(config.AppConfig.Pricing.DefaultZone)
+	}
+	known, err := isDeliveryZone(store.DB, zone)
+	if err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve delivery zones"))
+		return
+	}
+	if !known {
+		RespondWithError(c, ValidationError("Unknown delivery zone", map[string]interface{}{"zone": zone}))
+		return
+	}
+
+	now := time.Now()
+	from := models.CalendarDate(now.In(config.AppConfig.Ordering.Location()))
+	if value := c.Query("from"); value != "" {
+		date, err := parseDate("from", value)
+		if HandleAppError(c, err) {
+			return
+		}
+		from = date
+	}
+	to := from.AddDate(0, 0, 6)
+	if value := c.Query("to"); value != "" {
+		date, err := parseDate("to", value)
+		if HandleAppError(c, err) {
+			return
+		}
+		to = date
+	}
+	if to.Before(from) || to.After(from.AddDate(0, 0, maxSlotListingDays-1)) {
+		RespondWithError(c, ValidationError("Invalid date range", map[string]interface{}{
+			"from":     from.Format("2006-01-02"),
+			"to":       to.Format("2006-01-02"),
+			"max_days": maxSlotListingDays,
+		}))
+		return
+	}
+
+	availableOnly := false
+	if value := c.Query("available"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			RespondWithError(c, BadRequestError("Invalid available filter, expected true or false"))
+			return
+		}
+		availableOnly = parsed
+	}
+
+	if err := generateDeliverySlots(store.DB, zone, from, to); err != nil {
+		RespondWithError(c, DatabaseError("Failed to generate delivery slots"))
+		return
+	}
+
+	query := store.DB.Where("zone = ? AND delivery_date BETWEEN ? AND ? AND starts_at > ?", zone, from, to, now)
+	if availableOnly {
+		query = query.Where("booked < capacity")
+	}
+
+	var slots []models.DeliverySlot
+	if err := query.Order("starts_at ASC").Order("id ASC").Find(&slots).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve delivery slots"))
+		return
+	}
+
+	response := make([]DeliverySlotResponse, 0, len(slots))
+	for _, slot := range slots {
+		response = append(response, DeliverySlotResponse{
+			DeliverySlot: slot,
+			Remaining:    slot.Remaining(),
+			Available:    slot.IsAvailableAt(now),
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateSlotTemplateHandler creates a weekly delivery window for a zone.
+// Slots are generated from it when customers list delivery slots.
+//
+// Route: POST /admin/slot-templates
+// Request body: JSON with zone, weekday, start_time and end_time (HH:MM in the kitchen's timezone),
+// drivers, orders_per_driver and optional active
+// Response: 201 Created with the SlotTemplate object
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not an admin, 500 if database error
+func CreateSlotTemplateHandler(c *gin.Context) {
+	var req SlotTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid slot template data", err.Error()))
+		return
+	}
+
+	var template models.SlotTemplate
+	req.applyTo(&template)
+	if HandleAppError(c, validateSlotTemplate(store.DB, &template)) {
+		return
+	}
+
+	if err := store.DB.Create(&template).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to create slot template"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// GetSlotTemplatesHandler lists slot templates by zone, weekday and start time.
+//
+// Route: GET /admin/slot-templates
+// Parameters: zone (query, optional) - Filter by delivery zone
+// Response: 200 OK with array of SlotTemplate objects
+// Error responses: 401 if unauthorized, 403 if not an admin, 500 if database error
+func GetSlotTemplatesHandler(c *gin.Context) {
+	query := store.DB.Order("zone ASC").Order("id ASC")
+	if zone := c.Query("zone"); zone != "" {
+		query = query.Where("zone = ?", strings.ToLower(zone))
+	}
+
+	var templates []models.SlotTemplate
+	if err := query.Find(&templates).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve slot templates"))
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// UpdateSlotTemplateHandler replaces the settings of a slot template. Future
+// slots without bookings follow the new settings; booked slots keep their
+// window and take the new capacity, or stop taking bookings if the template
+// is deactivated.
+//
+// Route: PUT /admin/slot-templates/:id
+// Parameters: id (path) - The slot template ID
+// Request body: Same as POST /admin/slot-templates
+// Response: 200 OK with the updated SlotTemplate object
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not an admin, 404 if not found,
+// 500 if database error
+func UpdateSlotTemplateHandler(c *gin.Context) {
+	id, ok := parseSlotTemplateID(c)
+	if !ok {
+		return
+	}
+
+	var req SlotTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid slot template data", err.Error()))
+		return
+	}
+
+	var template models.SlotTemplate
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&template, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return NotFoundErrorType{Resource: "Slot template"}
+			}
+			return err
+		}
+
+		req.applyTo(&template)
+		if err := validateSlotTemplate(tx, &template); err != nil {
+			return err
+		}
+
+		// Save writes every field, including a false active flag
+		if err := tx.Save(&template).Error; err != nil {
+			return err
+		}
+
+		return syncTemplateSlots(tx, &template, time.Now())
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteSlotTemplateHandler soft deletes a slot template. Its future slots
+// without bookings are removed; orders that booked a slot keep it.
+//
+// Route: DELETE /admin/slot-templates/:id
+// Parameters: id (path) - The slot template ID
+// Response: 200 OK with success message
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not an admin, 404 if not found,
+// 500 if database error
+func DeleteSlotTemplateHandler(c *gin.Context) {
+	id, ok := parseSlotTemplateID(c)
+	if !ok {
+		return
+	}
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		var template models.SlotTemplate
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&template, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return NotFoundErrorType{Resource: "Slot template"}
+			}
+			return err
+		}
+
+		if err := tx.Delete(&template).Error; err != nil {
+			return err
+		}
+
+		template.Active = false
+		return syncTemplateSlots(tx, &template, time.Now())
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Slot template successfully deleted"})
+}
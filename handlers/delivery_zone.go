@@ -0,0 +1,486 @@
+package handlers
+
+import (
+	"meals/config"
+	"meals/geofence"
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeliveryZoneRequest represents the request body for creating or updating a delivery zone
+type DeliveryZoneRequest struct {
+	Code         string            `json:"code" binding:"required"`
+	Name         string            `json:"name"`
+	Boundary     geofence.Geometry `json:"boundary"` // GeoJSON Polygon, MultiPolygon or a Feature wrapping one
+	DeliveryFee  int64             `json:"delivery_fee"`
+	MinimumOrder int64             `json:"minimum_order"`
+	DeliveryDays []string          `json:"delivery_days"`
+	Kitchen      string            `json:"kitchen"`
+	Jurisdiction string            `json:"jurisdiction"`
+}
+
+// applyTo copies the request onto a delivery zone, normalizing the code and weekday names
+func (r *DeliveryZoneRequest) applyTo(zone *models.DeliveryZone) {
+	zone.Code = strings.ToLower(strings.TrimSpace(r.Code))
+	zone.Name = strings.TrimSpace(r.Name)
+	zone.Boundary = r.Boundary
+	zone.DeliveryFee = r.DeliveryFee
+	zone.MinimumOrder = r.MinimumOrder
+	zone.Kitchen = strings.TrimSpace(r.Kitchen)
+	zone.Jurisdiction = strings.ToLower(strings.TrimSpace(r.Jurisdiction))
+
+	zone.DeliveryDays = make([]string, 0, len(r.DeliveryDays))
+	for _, day := range r.DeliveryDays {
+		if weekday, ok := models.ParseWeekday(day); ok {
+			day = weekday.String()
+		}
+		zone.DeliveryDays = append(zone.DeliveryDays, day)
+	}
+}
+
+// checkDeliveryZone validates a delivery zone and verifies that no other live
+// zone uses the same code
+func checkDeliveryZone(tx *gorm.DB, zone *models.DeliveryZone) error {
+	errs := zone.ValidateDeliveryZone()
+	if zone.Jurisdiction != "" {
+		if _, exists := config.AppConfig.Pricing.Jurisdictions[zone.Jurisdiction]; !exists {
+			errs = append(errs, "Jurisdiction is not a configured tax jurisdiction")
+		}
+	}
+	if len(errs) > 0 {
+		return ValidationErrorType{Message: "Invalid delivery zone data", Details: errs}
+	}
+
+	var taken int64
+	if err := tx.Model(&models.DeliveryZone{}).
+		Where("code = ? AND id <> ?", zone.Code, zone.ID).
+		Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ConflictErrorType{
+			Code:    ErrResourceExists,
+			Message: "Delivery zone " + zone.Code + " already exists",
+			Details: map[string]interface{}{"code": zone.Code},
+		}
+	}
+
+	return nil
+}
+
+// findDeliveryZone returns the live delivery zone with the given code, or nil
+// if the code is only configured as a pricing zone or unknown
+func findDeliveryZone(db *gorm.DB, code string) (*models.DeliveryZone, error) {
+	var zone models.DeliveryZone
+	err := db.Where("code = ?", strings.ToLower(strings.TrimSpace(code))).First(&zone).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+// zoneAt returns the delivery zone whose boundary contains a location, or nil
+// if it is outside every zone. Zones should not overlap; if they do, the
+// oldest zone wins.
+func zoneAt(db *gorm.DB, latitude, longitude float64) (*models.DeliveryZone, int, error) {
+	var zones []models.DeliveryZone
+	if err := db.Order("id ASC").Find(&zones).Error; err != nil {
+		return nil, 0, err
+	}
+	for i := range zones {
+		if zones[i].Contains(latitude, longitude) {
+			return &zones[i], len(zones), nil
+		}
+	}
+	return nil, len(zones), nil
+}
+
+// isDeliveryZone checks if code is a delivery zone in the database or in the
+// pricing configuration. Any zone is accepted while no zones exist.
+func isDeliveryZone(db *gorm.DB, code string) (bool, error) {
+	rules, err := pricingRules(db)
+	if err != nil {
+		return false, err
+	}
+	if len(rules.Zones) == 0 {
+		return true, nil
+	}
+	_, exists := rules.Zones[strings.ToLower(strings.TrimSpace(code))]
+	return exists, nil
+}
+
+// pricingRules returns config.Pricing with the fees and jurisdictions of the
+// delivery zones in the database merged into its zones. A database zone
+// without its own jurisdiction keeps the one of the configured zone with the
+// same code.
+func pricingRules(db *gorm.DB) (config.PricingConfig, error) {
+	rules := config.AppConfig.Pricing
+
+	var zones []models.DeliveryZone
+	if err := db.Select("code", "delivery_fee", "jurisdiction").Find(&zones).Error; err != nil {
+		return rules, err
+	}
+	if len(zones) == 0 {
+		return rules, nil
+	}
+
+	// Copy the map so the shared configuration is never modified
+	merged := make(map[string]config.PricingZone, len(rules.Zones)+len(zones))
+	for code, zone := range rules.Zones {
+		merged[code] = zone
+	}
+	for _, zone := range zones {
+		jurisdiction := zone.Jurisdiction
+		if jurisdiction == "" {
+			jurisdiction = merged[zone.Code].Jurisdiction
+		}
+		merged[zone.Code] = config.PricingZone{DeliveryFee: zone.DeliveryFee, Jurisdiction: jurisdiction}
+	}
+	rules.Zones = merged
+
+	return rules, nil
+}
+
+// locateDeliveryZone sets the zone of an order to the delivery zone containing
+// its geocoded delivery address. Orders without a location are only accepted
+// from an address book entry whose zone was resolved when it was saved.
+// Addresses outside every zone are rejected, as are orders that asked for a
+// different zone. Nothing is checked until the first zone has been drawn.
+func locateDeliveryZone(tx *gorm.DB, order *models.Order) error {
+	if !order.HasDeliveryLocation() {
+		return addressDeliveryZone(tx, order)
+	}
+
+	zone, count, err := zoneAt(tx, *order.DeliveryLatitude, *order.DeliveryLongitude)
+	if err != nil || count == 0 {
+		return err
+	}
+	if zone == nil {
+		return ValidationErrorType{
+			Message: "We do not deliver to this address",
+			Details: map[string]interface{}{
+				"delivery_latitude":  *order.DeliveryLatitude,
+				"delivery_longitude": *order.DeliveryLongitude,
+			},
+		}
+	}
+
+	return assignDeliveryZone(order, zone.Code)
+}
+
+// addressDeliveryZone sets the zone of an order without a delivery location
+// from its address book entry. Once zones exist, orders with neither a
+// location nor an entry with a resolved zone cannot be checked against them
+// and are rejected.
+func addressDeliveryZone(tx *gorm.DB, order *models.Order) error {
+	var zones int64
+	if err := tx.Model(&models.DeliveryZone{}).Count(&zones).Error; err != nil || zones == 0 {
+		return err
+	}
+
+	if order.AddressID != nil {
+		var address models.Address
+		err := tx.Unscoped().Select("zone").First(&address, *order.AddressID).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		if address.Zone != "" {
+			return assignDeliveryZone(order, address.Zone)
+		}
+	}
+
+	return ValidationErrorType{
+		Message: "A geocoded delivery address is required to check that we deliver to it",
+		Details: map[string]interface{}{
+			"delivery_address": order.DeliveryAddress,
+			"address_id":       order.AddressID,
+		},
+	}
+}
+
+// assignDeliveryZone sets the order's zone to the one its address is in,
+// rejecting orders that asked for another zone
+func assignDeliveryZone(order *models.Order, code string) error {
+	requested := strings.ToLower(strings.TrimSpace(order.DeliveryZone))
+	if requested != "" && requested != code {
+		return ValidationErrorType{
+			Message: "The delivery address is in zone " + code + ", not " + requested,
+			Details: map[string]interface{}{
+				"delivery_zone": requested,
+				"address_zone":  code,
+			},
+		}
+	}
+
+	order.DeliveryZone = code
+	return nil
+}
+
+// checkDeliveryZoneRules verifies that a priced order meets the minimum order
+// of its delivery zone and is delivered on one of the zone's delivery days
+func checkDeliveryZoneRules(tx *gorm.DB, order *models.Order) error {
+	zone, err := findDeliveryZone(tx, order.DeliveryZone)
+	if err != nil || zone == nil {
+		return err
+	}
+
+	if !zone.DeliversOn(order.DeliveryDate) {
+		return ValidationErrorType{
+			Message: "Zone " + zone.Code + " is not delivered on " + order.DeliveryDate.Weekday().String() + "s",
+			Details: map[string]interface{}{
+				"delivery_zone": zone.Code,
+				"delivery_date": order.DeliveryDate.Format("2006-01-02"),
+				"delivery_days": zone.DeliveryDays,
+			},
+		}
+	}
+
+	if order.Subtotal.Amount < zone.MinimumOrder {
+		minimum := models.NewMoney(zone.MinimumOrder, order.Subtotal.Currency)
+		return ValidationErrorType{
+			Message: "Orders to zone " + zone.Code + " must be at least " + minimum.String(),
+			Details: map[string]interface{}{
+				"delivery_zone": zone.Code,
+				"minimum_order": minimum,
+			},
+		}
+	}
+
+	return nil
+}
+
+// parseDeliveryZoneID parses the :id path parameter or responds with 400
+func parseDeliveryZoneID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid delivery zone ID format"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// wantsGeoJSON checks if the request asked for a GeoJSON Feature or FeatureCollection
+func wantsGeoJSON(c *gin.Context) bool {
+	return c.Query("format") == "geojson" || strings.Contains(c.GetHeader("Accept"), "application/geo+json")
+}
+
+// zoneFeature converts a delivery zone into a GeoJSON Feature with the zone's
+// rules as properties
+func zoneFeature(zone models.DeliveryZone) geofence.Feature {
+	return geofence.NewFeature(zone.ID, zone.Boundary, map[string]interface{}{
+		"code":          zone.Code,
+		"name":          zone.Name,
+		"delivery_fee":  zone.DeliveryFee,
+		"minimum_order": zone.MinimumOrder,
+		"delivery_days": zone.DeliveryDays,
+		"kitchen":       zone.Kitchen,
+		"jurisdiction":  zone.Jurisdiction,
+	})
+}
+
+// GetDeliveryZonesHandler lists the delivery zones by code.
+//
+// Route: GET /admin/delivery-zones
+// Parameters: format (query, optional) - "geojson" for a GeoJSON FeatureCollection, as does an
+// Accept: application/geo+json header
+// Response: 200 OK with array of DeliveryZone objects or a FeatureCollection
+// Error responses: 401 if unauthorized, 403 if not an admin, 500 if database error
+func GetDeliveryZonesHandler(c *gin.Context) {
+	var zones []models.DeliveryZone
+	if err := store.DB.Order("code ASC").Find(&zones).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve delivery zones"))
+		return
+	}
+
+	if wantsGeoJSON(c) {
+		features := make([]geofence.Feature, 0, len(zones))
+		for _, zone := range zones {
+			features = append(features, zoneFeature(zone))
+		}
+		c.Header("Content-Type", "application/geo+json")
+		c.JSON(http.StatusOK, geofence.NewFeatureCollection(features))
+		return
+	}
+
+	c.JSON(http.StatusOK, zones)
+}
+
+// GetDeliveryZoneHandler retrieves a delivery zone by ID.
+//
+// Route: GET /admin/delivery-zones/:id
+// Parameters: id (path) - The delivery zone ID
+// format (query, optional) - "geojson" for a GeoJSON Feature, as does an Accept: application/geo+json header
+// Response: 200 OK with the DeliveryZone object or a Feature
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not an admin, 404 if not found,
+// 500 if database error
+func GetDeliveryZoneHandler(c *gin.Context) {
+	id, ok := parseDeliveryZoneID(c)
+	if !ok {
+		return
+	}
+
+	var zone models.DeliveryZone
+	if err := store.DB.First(&zone, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			RespondWithError(c, NotFoundError("Delivery zone"))
+		} else {
+			RespondWithError(c, DatabaseError("Failed to retrieve delivery zone"))
+		}
+		return
+	}
+
+	if wantsGeoJSON(c) {
+		c.Header("Content-Type", "application/geo+json")
+		c.JSON(http.StatusOK, zoneFeature(zone))
+		return
+	}
+
+	c.JSON(http.StatusOK, zone)
+}
+
+// CreateDeliveryZoneHandler creates a delivery zone.
+//
+// Route: POST /admin/delivery-zones
+// Request body: JSON with code, boundary (GeoJSON Polygon, MultiPolygon or a Feature wrapping one)
+// and optional name, delivery_fee, minimum_order, delivery_days, kitchen and jurisdiction
+// Response: 201 Created with the DeliveryZone object
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not an admin,
+// 409 if the code already exists, 500 if database error
+func CreateDeliveryZoneHandler(c *gin.Context) {
+	var req DeliveryZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid delivery zone data", err.Error()))
+		return
+	}
+
+	var zone models.DeliveryZone
+	req.applyTo(&zone)
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		if err := checkDeliveryZone(tx, &zone); err != nil {
+			return err
+		}
+		return tx.Create(&zone).Error
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, zone)
+}
+
+// UpdateDeliveryZoneHandler replaces the settings of a delivery zone. Orders
+// already placed keep their zone, fees and delivery day.
+//
+// Route: PUT /admin/delivery-zones/:id
+// Parameters: id (path) - The delivery zone ID
+// Request body: Same as POST /admin/delivery-zones
+// Response: 200 OK with the updated DeliveryZone object
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not an admin, 404 if not found,
+// 409 if the new code already exists, 500 if database error
+func UpdateDeliveryZoneHandler(c *gin.Context) {
+	id, ok := parseDeliveryZoneID(c)
+	if !ok {
+		return
+	}
+
+	var req DeliveryZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid delivery zone data", err.Error()))
+		return
+	}
+
+	var zone models.DeliveryZone
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&zone, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return NotFoundErrorType{Resource: "Delivery zone"}
+			}
+			return err
+		}
+
+		req.applyTo(&zone)
+		if err := checkDeliveryZone(tx, &zone); err != nil {
+			return err
+		}
+
+		// Save writes every field, including a cleared minimum order and delivery days
+		return tx.Save(&zone).Error
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, zone)
+}
+
+// DeleteDeliveryZoneHandler soft deletes a delivery zone. Its addresses are no
+// longer delivered to unless another zone covers them.
+//
+// Route: DELETE /admin/delivery-zones/:id
+// Parameters: id (path) - The delivery zone ID
+// Response: 200 OK with success message
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not an admin, 404 if not found,
+// 500 if database error
+func DeleteDeliveryZoneHandler(c *gin.Context) {
+	id, ok := parseDeliveryZoneID(c)
+	if !ok {
+		return
+	}
+
+	result := store.DB.Delete(&models.DeliveryZone{}, id)
+	if result.Error != nil {
+		RespondWithError(c, DatabaseError("Failed to delete delivery zone"))
+		return
+	}
+	if result.RowsAffected == 0 {
+		RespondWithError(c, NotFoundError("Delivery zone"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Delivery zone successfully deleted"})
+}
+
+// LookupDeliveryZoneHandler finds the delivery zone of a geocoded address, so
+// customers can check that an address is delivered to before ordering.
+//
+// Route: GET /delivery-zones/lookup
+// Parameters: latitude, longitude (query) - The geocoded address
+// Response: 200 OK with the DeliveryZone object
+// Error responses: 400 if invalid coordinates, 404 if the address is outside every zone, 500 if database error
+func LookupDeliveryZoneHandler(c *gin.Context) {
+	latitude, latErr := strconv.ParseFloat(c.Query("latitude"), 64)
+	longitude, lngErr := strconv.ParseFloat(c.Query("longitude"), 64)
+	if latErr != nil || lngErr != nil {
+		RespondWithError(c, BadRequestError("latitude and longitude are required decimal degrees"))
+		return
+	}
+	if errs := models.ValidateCoordinates(&latitude, &longitude); len(errs) > 0 {
+		RespondWithError(c, ValidationError("Invalid coordinates", errs))
+		return
+	}
+
+	zone, _, err := zoneAt(store.DB, latitude, longitude)
+	if err != nil {
+		RespondWithError(c, DatabaseError("Failed to look up delivery zone"))
+		return
+	}
+	if zone == nil {
+		RespondWithError(c, NotFoundError("Delivery zone"))
+		return
+	}
+
+	c.JSON(http.StatusOK, zone)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"meals/models"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ErrAllergenConflict is returned for orders containing meals with allergens
+// the customer declared, until the customer acknowledges them
+const ErrAllergenConflict = "ALLERGEN_CONFLICT"
+
+// AllergenConflict is an ordered meal containing allergens the customer declared
+type AllergenConflict struct {
+	MenuMealID uint     `json:"menu_meal_id"`
+	MealID     uint     `json:"meal_id"`
+	MealName   string   `json:"meal_name"`
+	Allergens  []string `json:"allergens"`
+}
+
+// WantsForMe checks if a listing asks for meals checked against the customer's
+// dietary preferences with ?for_me=true. Invalid values are rejected by the
+// handler, not here.
+func WantsForMe(c *gin.Context) bool {
+	forMe, err := strconv.ParseBool(c.Query("for_me"))
+	return err == nil && forMe
+}
+
+// dietaryFilter decides which meals a listing shows the customer
+type dietaryFilter struct {
+	profile *models.UserProfile
+	flag    bool // Keep unsuitable meals, with their conflicts, instead of hiding them
+}
+
+// dietaryFilterFromQuery reads the for_me and unsuitable query parameters,
+// loading the authenticated customer's profile for for_me listings. It returns
+// nil for listings without for_me and responds with 400 for invalid values.
+func dietaryFilterFromQuery(c *gin.Context, db *gorm.DB) (*dietaryFilter, bool) {
+	value := c.Query("for_me")
+	if value == "" {
+		return nil, true
+	}
+	forMe, err := strconv.ParseBool(value)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid for_me filter, expected true or false"))
+		return nil, false
+	}
+	if !forMe {
+		return nil, true
+	}
+
+	filter := &dietaryFilter{}
+	switch c.DefaultQuery("unsuitable", "hide") {
+	case "hide":
+	case "flag":
+		filter.flag = true
+	default:
+		RespondWithError(c, BadRequestError("Invalid unsuitable option, expected hide or flag"))
+		return nil, false
+	}
+
+	userID, ok := requireUserID(c)
+	if !ok {
+		return nil, false
+	}
+
+	profile, err := findDietaryProfile(db, userID)
+	if err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve profile"))
+		return nil, false
+	}
+	filter.profile = profile
+	return filter, true
+}
+
+// findDietaryProfile loads a user's profile, or an empty one if they have none
+func findDietaryProfile(db *gorm.DB, userID uint) (*models.UserProfile, error) {
+	var profile models.UserProfile
+	err := db.Where("user_id = ?", userID).First(&profile).Error
+	if err == gorm.ErrRecordNotFound {
+		return &models.UserProfile{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// keep checks if a listing shows the meal, setting its conflicts if it is
+// shown although unsuitable
+func (f *dietaryFilter) keep(meal *models.Meal) bool {
+	conflicts := f.profile.DietaryConflicts(*meal)
+	if conflicts.IsEmpty() {
+		return true
+	}
+	if !f.flag {
+		return false
+	}
+	meal.DietaryConflicts = &conflicts
+	return true
+}
+
+// suitable restricts a meal query to the meals keep shows without conflicts,
+// so listings that hide unsuitable meals can count and page them in SQL
+func (f *dietaryFilter) suitable(db *gorm.DB) *gorm.DB {
+	if len(f.profile.Allergens) > 0 {
+		db = db.Where("NOT "+hasAnyLabel("meals.allergens"), f.profile.Allergens)
+	}
+	for _, diet := range f.profile.Diets {
+		db = db.Where(hasAnyLabel("meals.diets"), models.DietLabelsSuiting(diet))
+	}
+	return db
+}
+
+// jsonLabels is a set returning SQL expression with a row per label of a JSON
+// array column, such as meals.diets. NULL and JSON null hold no labels.
+func jsonLabels(column string) string {
+	return "jsonb_array_elements_text(CASE WHEN jsonb_typeof(NULLIF(" + column + ", '')::jsonb) = 'array' " +
+		"THEN " + column + "::jsonb ELSE '[]'::jsonb END)"
+}
+
+// hasAnyLabel is an SQL condition on a JSON array column holding any of the
+// labels bound to its one parameter
+func hasAnyLabel(column string) string {
+	return "EXISTS (SELECT 1 FROM " + jsonLabels(column) + " AS meal_label(value) WHERE meal_label.value IN ?)"
+}
+
+// meals filters a meal listing
+func (f *dietaryFilter) meals(meals []models.Meal) []models.Meal {
+	kept := make([]models.Meal, 0, len(meals))
+	for i := range meals {
+		if f.keep(&meals[i]) {
+			kept = append(kept, meals[i])
+		}
+	}
+	return kept
+}
+
+// menu filters the meals of a menu
+func (f *dietaryFilter) menu(menu *models.Menu) {
+	kept := make([]models.MenuMeal, 0, len(menu.MenuMeals))
+	for i := range menu.MenuMeals {
+		if f.keep(&menu.MenuMeals[i].Meal) {
+			kept = append(kept, menu.MenuMeals[i])
+		}
+	}
+	menu.MenuMeals = kept
+}
+
+// checkOrderAllergens checks the items of a customer's order against the
+// allergens declared on their profile. Orders with conflicting meals are
+// rejected with 409 unless acknowledged is set, in which case the acknowledged
+// allergens are recorded on the order. Diets are not enforced on orders.
+func checkOrderAllergens(tx *gorm.DB, order *models.Order, items []models.OrderItem, acknowledged bool) error {
+	order.AcknowledgedAllergens = nil
+
+	profile, err := findDietaryProfile(tx, order.UserID)
+	if err != nil || len(profile.Allergens) == 0 {
+		return err
+	}
+
+	menuMealIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		menuMealIDs = append(menuMealIDs, item.MenuMealID)
+	}
+
+	var menuMeals []models.MenuMeal
+	if err := tx.Preload("Meal").Where("id IN ?", menuMealIDs).Order("id ASC").Find(&menuMeals).Error; err != nil {
+		return err
+	}
+
+	var conflicts []AllergenConflict
+	found := make(map[string]bool)
+	for _, menuMeal := range menuMeals {
+		allergens := profile.DietaryConflicts(menuMeal.Meal).Allergens
+		if len(allergens) == 0 {
+			continue
+		}
+		conflicts = append(conflicts, AllergenConflict{
+			MenuMealID: menuMeal.ID,
+			MealID:     menuMeal.MealID,
+			MealName:   menuMeal.Meal.Name,
+			Allergens:  allergens,
+		})
+		for _, allergen := range allergens {
+			found[allergen] = true
+		}
+	}
+
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	if !acknowledged {
+		return ConflictErrorType{
+			Code:    ErrAllergenConflict,
+			Message: "Some meals contain allergens declared on your profile; set acknowledge_allergens to order them anyway",
+			Details: map[string]interface{}{"conflicts": conflicts},
+		}
+	}
+
+	for allergen := range found {
+		order.AcknowledgedAllergens = append(order.AcknowledgedAllergens, allergen)
+	}
+	sort.Strings(order.AcknowledgedAllergens)
+	return nil
+}
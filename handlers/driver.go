@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DriverResponse is a driver profile with the driver's name and whether they
+// can be assigned deliveries now
+type DriverResponse struct {
+	models.DriverProfile
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Available bool   `json:"available"`
+}
+
+// GetDriversHandler lists driver profiles, by default only the drivers who
+// can be assigned deliveries now: available, with a valid license.
+//
+// Route: GET /admin/drivers
+// Parameters: available (query, optional) - "false" to list all drivers; defaults to "true"
+// status (query, optional) - Filter by status (available, busy, offline)
+// zone (query, optional) - Filter by home zone
+// Response: 200 OK with array of drivers
+// Error responses: 400 if invalid filter, 401 if unauthorized, 403 if not an admin, 500 if database error
+func GetDriversHandler(c *gin.Context) {
+	availableOnly := true
+	if value := c.Query("available"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			RespondWithError(c, BadRequestError("Invalid available filter, expected true or false"))
+			return
+		}
+		availableOnly = parsed
+	}
+
+	query := store.DB.Preload("User").Order("id ASC")
+	if status := models.DriverStatus(c.Query("status")); status != "" {
+		if !status.IsValid() {
+			RespondWithError(c, BadRequestError("Invalid status filter, expected available, busy or offline"))
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+	if availableOnly {
+		query = query.Where("status = ?", models.DriverStatusAvailable)
+	}
+	if zone := c.Query("zone"); zone != "" {
+		query = query.Where("home_zone = ?", strings.ToLower(zone))
+	}
+
+	var drivers []models.DriverProfile
+	if err := query.Find(&drivers).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve drivers"))
+		return
+	}
+
+	now := time.Now()
+	response := make([]DriverResponse, 0, len(drivers))
+	for _, driver := range drivers {
+		available := driver.IsAvailableAt(now)
+		if availableOnly && !available {
+			continue
+		}
+		response = append(response, DriverResponse{
+			DriverProfile: driver,
+			Name:          driver.User.Name,
+			Email:         driver.User.Email,
+			Available:     available,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"meals/config"
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StopUpdateRequest represents the optional request body of a driver's stop
+// update, sent as JSON or, with proof-of-delivery files, as multipart form data
+type StopUpdateRequest struct {
+	Reason        string `json:"reason" form:"reason"` // Required when a stop fails
+	Note          string `json:"note" form:"note"`
+	RecipientName string `json:"recipient_name" form:"recipient_name"` // Only used when a stop is delivered
+}
+
+// GetDriverManifestHandler lists the driver's runs for a day with their stops
+// in delivery order. Stops that are not done carry an estimated arrival,
+// counting from now.
+//
+// Route: GET /driver/manifest
+// Parameters: date (query, optional) - Delivery date (YYYY-MM-DD), defaults to today in the kitchen's timezone
+// Response: 200 OK with array of DeliveryRun objects, their stops and orders
+// Error responses: 400 if invalid date, 401 if unauthorized, 403 if not a driver, 500 if database error
+func GetDriverManifestHandler(c *gin.Context) {
+	driverID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	date := models.CalendarDate(time.Now().In(config.AppConfig.Ordering.Location()))
+	if value := c.Query("date"); value != "" {
+		parsed, err := parseDate("date", value)
+		if HandleAppError(c, err) {
+			return
+		}
+		date = parsed
+	}
+
+	var runs []models.DeliveryRun
+	if err := store.DB.Preload("Stops", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sequence ASC")
+	}).Preload("Stops.Order.Items").
+		Where("driver_id = ? AND delivery_date = ?", driverID, date).
+		Order("id ASC").
+		Find(&runs).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve manifest"))
+		return
+	}
+
+	now := time.Now()
+	for i := range runs {
+		runs[i].EstimateArrivals(now, config.AppConfig.Routing.StopDuration)
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// MarkStopArrivedHandler records that the driver reached a stop.
+//
+// Route: POST /driver/stops/:id/arrived
+// Parameters: id (path) - The delivery stop ID
+// Request body: optional JSON with note
+// Response: 200 OK with the updated DeliveryStop
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not a driver,
+// 404 if the stop is not on one of the driver's runs, 409 if the stop has moved on, 500 if database error
+func MarkStopArrivedHandler(c *gin.Context) {
+	updateDeliveryStop(c, models.DeliveryStopStatusArrived)
+}
+
+// MarkStopDeliveredHandler records that a stop's order was handed over and
+// marks the order delivered. Proof of delivery can be attached by sending the
+// request as multipart form data with a photo, a signature image and the
+// recipient's name; it is stored with the order in the same transaction.
+//
+// Route: POST /driver/stops/:id/delivered
+// Parameters: id (path) - The delivery stop ID
+// Request body: optional JSON with note and recipient_name, or multipart form data with
+// note, recipient_name and photo and signature files (JPEG, PNG or WebP)
+// Response: 200 OK with the updated DeliveryStop
+// Error responses: 400 if invalid data or files, 401 if unauthorized, 403 if not a driver,
+// 404 if the stop is not on one of the driver's runs, 409 if the stop or order cannot be delivered,
+// 500 if database error
+func MarkStopDeliveredHandler(c *gin.Context) {
+	updateDeliveryStop(c, models.DeliveryStopStatusDelivered)
+}
+
+// MarkStopFailedHandler records that a stop's order could not be delivered and
+// marks the order failed.
+//
+// Route: POST /driver/stops/:id/failed
+// Parameters: id (path) - The delivery stop ID
+// Request body: JSON with reason and optional note
+// Response: 200 OK with the updated DeliveryStop
+// Error responses: 400 if invalid data or no reason, 401 if unauthorized, 403 if not a driver,
+// 404 if the stop is not on one of the driver's runs, 409 if the stop or order cannot fail,
+// 500 if database error
+func MarkStopFailedHandler(c *gin.Context) {
+	updateDeliveryStop(c, models.DeliveryStopStatusFailed)
+}
+
+// updateDeliveryStop moves one of the driver's stops to next. The stop, its
+// run and its order change in one transaction: the order is sent out for
+// delivery the first time the driver acts on it and then follows the stop to
+// delivered or failed, and the run starts with its first stop and completes
+// with its last.
+func updateDeliveryStop(c *gin.Context, next models.DeliveryStopStatus) {
+	driverID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid stop ID format"))
+		return
+	}
+
+	var req StopUpdateRequest
+	if c.Request.ContentLength != 0 {
+		if c.ContentType() == gin.MIMEMultipartPOSTForm {
+			limitUploadBody(c, 2)
+		}
+		if err := c.ShouldBind(&req); err != nil {
+			RespondWithError(c, ValidationError("Invalid request data", err.Error()))
+			return
+		}
+	}
+	if next == models.DeliveryStopStatusFailed && req.Reason == "" {
+		HandleAppError(c, ValidationErrorType{
+			Message: "A reason is required when a delivery fails",
+			Details: map[string]interface{}{"reason": "required"},
+		})
+		return
+	}
+
+	var proof *models.DeliveryProof
+	if next == models.DeliveryStopStatusDelivered {
+		if proof, err = receiveDeliveryProof(c, req.RecipientName); HandleAppError(c, err) {
+			return
+		}
+	}
+
+	actor := actorFromContext(c)
+	var stop models.DeliveryStop
+
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stop, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return NotFoundErrorType{Resource: "Delivery stop"}
+			}
+			return err
+		}
+
+		run, err := lockDeliveryRun(tx, stop.RunID)
+		if err != nil {
+			return err
+		}
+		// Stops of other drivers' runs are reported as missing rather than forbidden
+		if !run.IsAssignedTo(driverID) {
+			return NotFoundErrorType{Resource: "Delivery stop"}
+		}
+
+		if !stop.Status.CanMoveTo(next) {
+			return ConflictErrorType{
+				Code:    ErrInvalidTransition,
+				Message: "Delivery stop cannot move from " + string(stop.Status) + " to " + string(next),
+				Details: map[string]interface{}{
+					"from": stop.Status,
+					"to":   next,
+				},
+			}
+		}
+
+		order, err := lockOrder(tx, stop.OrderID)
+		if err != nil {
+			return err
+		}
+
+		if order.Status != models.OrderStatusOutForDelivery {
+			if err := transitionOrder(tx, order, models.OrderStatusOutForDelivery, actor, "Dispatched on delivery run"); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		updates := models.DeliveryStop{Status: next}
+		columns := []string{"status"}
+		switch next {
+		case models.DeliveryStopStatusArrived:
+			updates.ArrivedAt = &now
+			columns = append(columns, "arrived_at")
+		case models.DeliveryStopStatusDelivered, models.DeliveryStopStatusFailed:
+			orderStatus, note := models.OrderStatusDelivered, req.Note
+			if next == models.DeliveryStopStatusFailed {
+				orderStatus, note = models.OrderStatusFailed, req.Reason
+				updates.FailureReason = req.Reason
+				columns = append(columns, "failure_reason")
+			}
+			if err := transitionOrder(tx, order, orderStatus, actor, note); err != nil {
+				return err
+			}
+			updates.CompletedAt = &now
+			columns = append(columns, "completed_at")
+		}
+		if err := tx.Model(&stop).Select(columns).Updates(&updates).Error; err != nil {
+			return err
+		}
+
+		if proof != nil {
+			proof.OrderID, proof.StopID, proof.DriverID = stop.OrderID, stop.ID, driverID
+			if err := tx.Create(proof).Error; err != nil {
+				return err
+			}
+		}
+
+		return advanceDeliveryRun(tx, run, now)
+	})
+
+	if err != nil {
+		// Nothing references the uploaded files once the transaction is rolled back
+		discardDeliveryProof(c, proof)
+	}
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, stop)
+}
+
+// advanceDeliveryRun starts a planned run and completes a run whose stops
+// are all done
+func advanceDeliveryRun(tx *gorm.DB, run *models.DeliveryRun, now time.Time) error {
+	if run.Status == models.DeliveryRunStatusPlanned {
+		run.Status = models.DeliveryRunStatusInProgress
+		run.StartedAt = &now
+		if err := tx.Model(run).Select("status", "started_at").Updates(run).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("run_id = ?", run.ID).Find(&run.Stops).Error; err != nil {
+		return err
+	}
+	if !run.IsComplete() {
+		return nil
+	}
+
+	run.Status = models.DeliveryRunStatusCompleted
+	run.CompletedAt = &now
+	return tx.Model(run).Select("status", "completed_at").Updates(run).Error
+}
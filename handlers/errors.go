@@ -24,15 +24,20 @@ func (e ErrorResponse) Error() string {
 
 // Common error codes
 const (
-	ErrBadRequest        = "BAD_REQUEST"
-	ErrNotFound          = "NOT_FOUND"
-	ErrInternalServer    = "INTERNAL_SERVER_ERROR"
-	ErrUnauthorized      = "UNAUTHORIZED"
-	ErrForbidden         = "FORBIDDEN"
-	ErrValidation        = "VALIDATION_ERROR"
-	ErrDatabaseOperation = "DATABASE_ERROR"
-	ErrResourceExists    = "RESOURCE_EXISTS"
-	ErrRelationship      = "RELATIONSHIP_ERROR"
+	ErrBadRequest         = "BAD_REQUEST"
+	ErrNotFound           = "NOT_FOUND"
+	ErrInternalServer     = "INTERNAL_SERVER_ERROR"
+	ErrUnauthorized       = "UNAUTHORIZED"
+	ErrForbidden          = "FORBIDDEN"
+	ErrValidation         = "VALIDATION_ERROR"
+	ErrDatabaseOperation  = "DATABASE_ERROR"
+	ErrResourceExists     = "RESOURCE_EXISTS"
+	ErrRelationship       = "RELATIONSHIP_ERROR"
+	ErrConflict           = "CONFLICT"
+	ErrInvalidTransition  = "INVALID_STATUS_TRANSITION"
+	ErrPaymentDeclined    = "PAYMENT_DECLINED"
+	ErrInsufficientCredit = "INSUFFICIENT_CREDIT"
+	ErrAlreadyAssigned    = "ORDER_ALREADY_ASSIGNED"
 )
 
 // RespondWithError sends a standardized error response
@@ -101,6 +106,30 @@ func RelationshipError(message string, details any) ErrorResponse {
 	}
 }
 
+// ConflictError returns a standardized conflict error for requests that clash
+// with the current state of a resource
+func ConflictError(code string, message string, details any) ErrorResponse {
+	if code == "" {
+		code = ErrConflict
+	}
+	return ErrorResponse{
+		Status:  http.StatusConflict,
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
+
+// PaymentRequiredError returns a standardized error for payments the provider refused
+func PaymentRequiredError(message string, details any) ErrorResponse {
+	return ErrorResponse{
+		Status:  http.StatusPaymentRequired,
+		Code:    ErrPaymentDeclined,
+		Message: message,
+		Details: details,
+	}
+}
+
 // Custom error types for transaction-compatible errors
 
 // AppError is an interface for all application errors
@@ -194,6 +223,53 @@ func (e BadRequestErrorType) ToResponse() ErrorResponse {
 	return BadRequestError(e.Message)
 }
 
+// ForbiddenErrorType represents errors for authenticated users lacking permission
+type ForbiddenErrorType struct {
+	Message string
+}
+
+func (e ForbiddenErrorType) Error() string {
+	return e.Message
+}
+
+func (e ForbiddenErrorType) ToResponse() ErrorResponse {
+	return ErrorResponse{
+		Status:  http.StatusForbidden,
+		Code:    ErrForbidden,
+		Message: e.Message,
+	}
+}
+
+// ConflictErrorType represents requests that conflict with the current state of
+// a resource, such as an illegal order status transition
+type ConflictErrorType struct {
+	Code    string
+	Message string
+	Details any
+}
+
+func (e ConflictErrorType) Error() string {
+	return e.Message
+}
+
+func (e ConflictErrorType) ToResponse() ErrorResponse {
+	return ConflictError(e.Code, e.Message, e.Details)
+}
+
+// PaymentErrorType represents a payment the provider refused to authorize
+type PaymentErrorType struct {
+	Message string
+	Details any
+}
+
+func (e PaymentErrorType) Error() string {
+	return e.Message
+}
+
+func (e PaymentErrorType) ToResponse() ErrorResponse {
+	return PaymentRequiredError(e.Message, e.Details)
+}
+
 // HandleAppError handles all application errors in a uniform way
 func HandleAppError(c *gin.Context, err error) bool {
 	if err == nil {
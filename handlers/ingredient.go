@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"fmt"
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mealFactColumns are the meal columns derived from its ingredients
+var mealFactColumns = []string{
+	"allergens",
+	"nutrition_calories", "nutrition_protein", "nutrition_carbohydrates", "nutrition_sugar",
+	"nutrition_fat", "nutrition_saturated_fat", "nutrition_fiber", "nutrition_sodium",
+	"ingredient_names",
+}
+
+// IngredientRequest represents the request body for creating or updating an ingredient
+type IngredientRequest struct {
+	Name          string                `json:"name" binding:"required"`
+	Allergens     []string              `json:"allergens"`
+	Nutrition     models.NutritionFacts `json:"nutrition"` // Per 100g
+	GramsPerPiece float64               `json:"grams_per_piece"`
+}
+
+// applyTo copies the request onto an ingredient, normalizing the allergens
+func (r *IngredientRequest) applyTo(ingredient *models.Ingredient) {
+	ingredient.Name = strings.TrimSpace(r.Name)
+	ingredient.Allergens = models.NormalizeLabels(r.Allergens)
+	ingredient.Nutrition = r.Nutrition
+	ingredient.GramsPerPiece = r.GramsPerPiece
+}
+
+// checkIngredient validates an ingredient and rejects names already in the
+// catalog, ignoring case
+func checkIngredient(tx *gorm.DB, ingredient *models.Ingredient) error {
+	if errs := ingredient.ValidateIngredient(); len(errs) > 0 {
+		return ValidationErrorType{Message: "Invalid ingredient data", Details: errs}
+	}
+
+	var taken int64
+	if err := tx.Model(&models.Ingredient{}).
+		Where("LOWER(name) = LOWER(?) AND id <> ?", ingredient.Name, ingredient.ID).
+		Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ConflictErrorType{
+			Code:    ErrResourceExists,
+			Message: "Ingredient " + ingredient.Name + " already exists",
+			Details: map[string]interface{}{"name": ingredient.Name},
+		}
+	}
+
+	return nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// parseIngredientID parses the :id path parameter or responds with 400
+func parseIngredientID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid ingredient ID format"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// setMealIngredients replaces the ingredients of a meal within tx. The facts
+// derived from them are not updated; call refreshMealFacts afterwards.
+func setMealIngredients(tx *gorm.DB, mealID uint, lines []models.MealIngredient) error {
+	var errs []string
+	ingredientIDs := make([]uint, 0, len(lines))
+	seen := make(map[uint]bool, len(lines))
+	for i := range lines {
+		for _, err := range lines[i].ValidateMealIngredient() {
+			errs = append(errs, fmt.Sprintf("Ingredient %d: %s", i+1, err))
+		}
+		if seen[lines[i].IngredientID] {
+			errs = append(errs, fmt.Sprintf("Ingredient %d: IngredientID %d is listed twice", i+1, lines[i].IngredientID))
+		}
+		seen[lines[i].IngredientID] = true
+		ingredientIDs = append(ingredientIDs, lines[i].IngredientID)
+	}
+	if len(errs) > 0 {
+		return ValidationErrorType{Message: "Invalid meal ingredients", Details: errs}
+	}
+
+	if len(ingredientIDs) > 0 {
+		var found int64
+		if err := tx.Model(&models.Ingredient{}).Where("id IN ?", ingredientIDs).Count(&found).Error; err != nil {
+			return err
+		}
+		if int(found) != len(ingredientIDs) {
+			return RelationshipErrorType{
+				Message: "One or more ingredient IDs do not exist",
+				Details: map[string]interface{}{
+					"provided_ids": ingredientIDs,
+					"found_count":  found,
+				},
+			}
+		}
+	}
+
+	if err := tx.Where("meal_id = ?", mealID).Delete(&models.MealIngredient{}).Error; err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	ingredients := make([]models.MealIngredient, 0, len(lines))
+	for _, line := range lines {
+		ingredients = append(ingredients, models.MealIngredient{
+			MealID:       mealID,
+			IngredientID: line.IngredientID,
+			Quantity:     line.Quantity,
+			Unit:         line.Unit,
+		})
+	}
+	return tx.Create(&ingredients).Error
+}
+
+// refreshMealFacts derives the allergens and nutrition facts of meals from
+// their current ingredients
+func refreshMealFacts(tx *gorm.DB, mealIDs ...uint) error {
+	for _, id := range mealIDs {
+		meal, err := loadMeal(tx, id)
+		if err != nil {
+			return err
+		}
+
+		meal.DeriveFromIngredients()
+
+		// Meals without ingredients keep the allergens they were given. Updating
+		// from the struct applies the JSON serializer and writes zero values.
+		columns := mealFactColumns
+		if len(meal.Ingredients) == 0 {
+			columns = mealFactColumns[1:]
+		}
+		if err := tx.Model(meal).Select(columns).Updates(meal).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadMeal fetches a meal with its ingredients preloaded
+func loadMeal(db *gorm.DB, id uint) (*models.Meal, error) {
+	var meal models.Meal
+	err := db.Preload("Ingredients", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Preload("Ingredients.Ingredient").First(&meal, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NotFoundErrorType{Resource: "Meal"}
+		}
+		return nil, err
+	}
+	return &meal, nil
+}
+
+// GetIngredientsHandler lists the ingredient catalog by name.
+//
+// Route: GET /admin/ingredients
+// Query parameters: q (optional) - Only ingredients whose name contains q, ignoring case
+// Response: 200 OK with array of Ingredient objects
+// Error responses: 401 if unauthorized, 403 if not an admin, 500 if database error
+func GetIngredientsHandler(c *gin.Context) {
+	query := store.DB.Order("name ASC")
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("name ILIKE ?", "%"+escapeLike(q)+"%")
+	}
+
+	var ingredients []models.Ingredient
+	if err := query.Find(&ingredients).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve ingredients"))
+		return
+	}
+
+	c.JSON(http.StatusOK, ingredients)
+}
+
+// GetIngredientHandler retrieves an ingredient by ID.
+//
+// Route: GET /admin/ingredients/:id
+// Parameters: id (path) - The ingredient ID
+// Response: 200 OK with the Ingredient object
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not an admin, 404 if not found, 500 if database error
+func GetIngredientHandler(c *gin.Context) {
+	id, ok := parseIngredientID(c)
+	if !ok {
+		return
+	}
+
+	var ingredient models.Ingredient
+	if err := store.DB.First(&ingredient, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			RespondWithError(c, NotFoundError("Ingredient"))
+		} else {
+			RespondWithError(c, DatabaseError("Failed to retrieve ingredient"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, ingredient)
+}
+
+// CreateIngredientHandler adds an ingredient to the catalog.
+//
+// Route: POST /admin/ingredients
+// Request body: JSON with name and optional allergens, nutrition (per 100g) and grams_per_piece
+// Response: 201 Created with the Ingredient object
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not an admin, 409 if the name is taken,
+// 500 if database error
+func CreateIngredientHandler(c *gin.Context) {
+	var req IngredientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid ingredient data", err.Error()))
+		return
+	}
+
+	var ingredient models.Ingredient
+	req.applyTo(&ingredient)
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		if err := checkIngredient(tx, &ingredient); err != nil {
+			return err
+		}
+		return tx.Create(&ingredient).Error
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, ingredient)
+}
+
+// UpdateIngredientHandler replaces an ingredient. The allergens and nutrition
+// facts of every meal using it are derived again in the same transaction.
+//
+// Route: PUT /admin/ingredients/:id
+// Parameters: id (path) - The ingredient ID
+// Request body: Same as POST /admin/ingredients
+// Response: 200 OK with the updated Ingredient object
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not an admin, 404 if not found,
+// 409 if the name is taken, 500 if database error
+func UpdateIngredientHandler(c *gin.Context) {
+	id, ok := parseIngredientID(c)
+	if !ok {
+		return
+	}
+
+	var req IngredientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid ingredient data", err.Error()))
+		return
+	}
+
+	var ingredient models.Ingredient
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ingredient, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return NotFoundErrorType{Resource: "Ingredient"}
+			}
+			return err
+		}
+
+		req.applyTo(&ingredient)
+		if err := checkIngredient(tx, &ingredient); err != nil {
+			return err
+		}
+
+		// Save writes every field, including cleared allergens and nutrition values
+		if err := tx.Save(&ingredient).Error; err != nil {
+			return err
+		}
+
+		var mealIDs []uint
+		if err := tx.Model(&models.MealIngredient{}).
+			Where("ingredient_id = ?", ingredient.ID).
+			Distinct().Order("meal_id").Pluck("meal_id", &mealIDs).Error; err != nil {
+			return err
+		}
+		return refreshMealFacts(tx, mealIDs...)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, ingredient)
+}
+
+// DeleteIngredientHandler soft deletes an ingredient that no meal uses.
+//
+// Route: DELETE /admin/ingredients/:id
+// Parameters: id (path) - The ingredient ID
+// Response: 200 OK with success message
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not an admin, 404 if not found,
+// 409 if meals use the ingredient, 500 if database error
+func DeleteIngredientHandler(c *gin.Context) {
+	id, ok := parseIngredientID(c)
+	if !ok {
+		return
+	}
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		var ingredient models.Ingredient
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ingredient, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return NotFoundErrorType{Resource: "Ingredient"}
+			}
+			return err
+		}
+
+		var mealIDs []uint
+		if err := tx.Model(&models.MealIngredient{}).
+			Where("ingredient_id = ?", ingredient.ID).
+			Distinct().Order("meal_id").Pluck("meal_id", &mealIDs).Error; err != nil {
+			return err
+		}
+		if len(mealIDs) > 0 {
+			return ConflictErrorType{
+				Message: "Ingredient " + ingredient.Name + " is used by meals; remove it from them first",
+				Details: map[string]interface{}{"meal_ids": mealIDs},
+			}
+		}
+
+		return tx.Delete(&ingredient).Error
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ingredient successfully deleted"})
+}
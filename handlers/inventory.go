@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"meals/models"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSoldOut is the error code returned when a menu meal has no portions left
+const ErrSoldOut = "SOLD_OUT"
+
+// portionsByMenuMeal totals the requested quantity per menu meal, returning the
+// menu meal IDs in ascending order so rows are always locked in the same order
+func portionsByMenuMeal(items []models.OrderItem) ([]uint, map[uint]int) {
+	quantities := make(map[uint]int)
+	for _, item := range items {
+		quantities[item.MenuMealID] += item.Quantity
+	}
+
+	ids := make([]uint, 0, len(quantities))
+	for id := range quantities {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return ids, quantities
+}
+
+// reservePortions atomically reserves stock for the given items within tx.
+// Each menu meal row is locked with SELECT ... FOR UPDATE before its remaining
+// capacity is checked, so concurrent orders cannot oversell.
+func reservePortions(tx *gorm.DB, items []models.OrderItem) error {
+	ids, quantities := portionsByMenuMeal(items)
+
+	for _, id := range ids {
+		var menuMeal models.MenuMeal
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Meal").First(&menuMeal, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return NotFoundErrorType{Resource: "Menu meal"}
+			}
+			return err
+		}
+
+		quantity := quantities[id]
+		if !menuMeal.CanReserve(quantity) {
+			remaining, _ := menuMeal.Remaining()
+			return ConflictErrorType{
+				Code:    ErrSoldOut,
+				Message: menuMeal.Meal.Name + " is sold out",
+				Details: map[string]interface{}{
+					"menu_meal_id": id,
+					"requested":    quantity,
+					"remaining":    remaining,
+				},
+			}
+		}
+
+		if err := tx.Model(&models.MenuMeal{}).Where("id = ?", id).
+			Update("reserved_portions", gorm.Expr("reserved_portions + ?", quantity)).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releasePortions returns previously reserved stock for the given items within tx
+func releasePortions(tx *gorm.DB, items []models.OrderItem) error {
+	ids, quantities := portionsByMenuMeal(items)
+
+	for _, id := range ids {
+		if err := tx.Model(&models.MenuMeal{}).Where("id = ?", id).
+			Update("reserved_portions", gorm.Expr("GREATEST(reserved_portions - ?, 0)", quantities[id])).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Items returned per page by default and at most
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// listOperators maps filter suffixes to SQL comparisons; a filter named after
+// the field alone compares for equality
+var listOperators = map[string]string{
+	"lt":  "<",
+	"lte": "<=",
+	"gt":  ">",
+	"gte": ">=",
+	"ne":  "<>",
+}
+
+// listField is a field a list endpoint can be sorted and filtered on. Only the
+// column given here reaches SQL; request values are always bound as parameters.
+type listField struct {
+	column string
+	parse  func(string) (interface{}, error) // Parses filter and cursor values
+	value  func(row interface{}) string      // Formats a row's value for a cursor, as parse reads it
+}
+
+// listSpec describes the sort fields and filters a list endpoint accepts
+type listSpec struct {
+	fields      map[string]listField
+	defaultSort string
+	params      []string // Other query parameters the handler reads itself
+}
+
+// listFilter is a filter expression such as price_lte=1500
+type listFilter struct {
+	column   string
+	operator string
+	value    interface{}
+}
+
+// listCursor is the position after the last row of a page. It is sent to
+// clients base64 encoded, so they treat it as opaque.
+type listCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// listQuery is a parsed list request: the page size, sort order, position
+// and filters
+type listQuery struct {
+	spec    *listSpec
+	limit   int
+	sort    string // Field name, prefixed with - for descending order
+	after   *listCursor
+	filters []listFilter
+
+	// afterValue is the cursor's sort value, parsed by the sort field
+	afterValue interface{}
+}
+
+// listPage is the response envelope of paginated list endpoints. NextCursor
+// is null on the last page.
+type listPage struct {
+	Data       interface{} `json:"data"`
+	NextCursor *string     `json:"next_cursor"`
+}
+
+// parseListQuery reads limit, sort, cursor and filter expressions from the
+// query string. Parameters that are neither these, one of the spec's params
+// nor a filter on one of its fields are rejected.
+func parseListQuery(c *gin.Context, spec *listSpec) (*listQuery, error) {
+	query := &listQuery{spec: spec, limit: defaultListLimit, sort: spec.defaultSort}
+	values := c.Request.URL.Query()
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return nil, ValidationErrorType{
+				Message: fmt.Sprintf("Invalid limit, expected 1 to %d", maxListLimit),
+				Details: map[string]interface{}{"limit": value},
+			}
+		}
+		query.limit = limit
+	}
+
+	if value := values.Get("sort"); value != "" {
+		if _, ok := spec.fields[strings.TrimPrefix(value, "-")]; !ok {
+			return nil, ValidationErrorType{
+				Message: "Unknown sort field " + strings.TrimPrefix(value, "-"),
+				Details: map[string]interface{}{"sort": value, "allowed": spec.fieldNames()},
+			}
+		}
+		query.sort = value
+	}
+
+	if value := values.Get("cursor"); value != "" {
+		cursor, err := decodeListCursor(value)
+		if err == nil && cursor.Sort == query.sort {
+			query.afterValue, err = spec.fields[strings.TrimPrefix(cursor.Sort, "-")].parse(cursor.Value)
+		}
+		if err != nil || cursor.Sort != query.sort {
+			return nil, ValidationErrorType{
+				Message: "Invalid cursor; cursors are only valid with the sort they were returned for",
+				Details: map[string]interface{}{"cursor": value},
+			}
+		}
+		query.after = cursor
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "limit" || name == "sort" || name == "cursor" || containsString(spec.params, name) {
+			continue
+		}
+
+		field, operator, ok := spec.filterField(name)
+		if !ok {
+			return nil, ValidationErrorType{
+				Message: "Unknown filter " + name,
+				Details: map[string]interface{}{
+					"filter":    name,
+					"fields":    spec.fieldNames(),
+					"operators": []string{"lt", "lte", "gt", "gte", "ne"},
+				},
+			}
+		}
+		if len(values[name]) > 1 {
+			return nil, ValidationErrorType{
+				Message: "Filter " + name + " is given more than once",
+				Details: map[string]interface{}{"filter": name},
+			}
+		}
+
+		value, err := field.parse(values.Get(name))
+		if err != nil {
+			return nil, ValidationErrorType{
+				Message: "Invalid value for filter " + name,
+				Details: map[string]interface{}{"filter": name, "value": values.Get(name), "error": err.Error()},
+			}
+		}
+		query.filters = append(query.filters, listFilter{column: field.column, operator: operator, value: value})
+	}
+
+	return query, nil
+}
+
+// filterField resolves a filter name such as price_lte to its field and SQL operator
+func (s *listSpec) filterField(name string) (listField, string, bool) {
+	if field, ok := s.fields[name]; ok {
+		return field, "=", true
+	}
+	i := strings.LastIndex(name, "_")
+	if i < 0 {
+		return listField{}, "", false
+	}
+	field, ok := s.fields[name[:i]]
+	operator, known := listOperators[name[i+1:]]
+	return field, operator, ok && known
+}
+
+// fieldNames lists the spec's fields in order, for error details
+func (s *listSpec) fieldNames() []string {
+	names := make([]string, 0, len(s.fields))
+	for name := range s.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// find loads a page into dest, a pointer to a slice of the listed model, and
+// returns the cursor of the next page, or nil if this is the last one. The
+// id column breaks ties, so rows with equal sort values are neither skipped
+// nor repeated.
+func (q *listQuery) find(db *gorm.DB, dest interface{}) (*string, error) {
+	field := q.spec.fields[strings.TrimPrefix(q.sort, "-")]
+	direction, comparison := "ASC", ">"
+	if strings.HasPrefix(q.sort, "-") {
+		direction, comparison = "DESC", "<"
+	}
+
+	for _, filter := range q.filters {
+		db = db.Where(filter.column+" "+filter.operator+" ?", filter.value)
+	}
+	if q.after != nil {
+		db = db.Where("("+field.column+", id) "+comparison+" (?, ?)", q.afterValue, q.after.ID)
+	}
+
+	order := field.column + " " + direction
+	if field.column != "id" {
+		order += ", id " + direction
+	}
+	if err := db.Order(order).Limit(q.limit + 1).Find(dest).Error; err != nil {
+		return nil, err
+	}
+
+	// One row more than the limit was loaded to tell if another page follows
+	rows := reflect.ValueOf(dest).Elem()
+	if rows.Len() <= q.limit {
+		return nil, nil
+	}
+	rows.Set(rows.Slice(0, q.limit))
+
+	last := rows.Index(q.limit - 1)
+	id := last.FieldByName("ID").Interface().(uint)
+	cursor := encodeListCursor(listCursor{Sort: q.sort, Value: field.value(last.Addr().Interface()), ID: id})
+	return &cursor, nil
+}
+
+// encodeListCursor encodes a cursor for clients
+func encodeListCursor(cursor listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListCursor decodes a cursor returned by encodeListCursor
+func decodeListCursor(value string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// parseListUint parses ID filter values
+func parseListUint(value string) (interface{}, error) {
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// parseListInt parses integer filter values such as amounts in minor units
+func parseListInt(value string) (interface{}, error) {
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// parseListString accepts any text filter value
+func parseListString(value string) (interface{}, error) {
+	return value, nil
+}
+
+// parseListTime parses timestamp filter values, either RFC 3339 or a date
+// (YYYY-MM-DD) meaning midnight UTC
+func parseListTime(value string) (interface{}, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("expected YYYY-MM-DD or an RFC 3339 timestamp")
+	}
+	return t, nil
+}
+
+// formatListTime formats a timestamp for a cursor, as parseListTime reads it
+func formatListTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// containsString checks if values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
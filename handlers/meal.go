@@ -2,6 +2,7 @@
 //
 // This package contains handlers for meal management operations including:
 // - Retrieving all meals (GET /meals)
+// - Searching meals (GET /meals/search)
 // - Retrieving a specific meal (GET /meals/:id)
 // - Creating new meals (POST /meals)
 // - Updating existing meals (PUT /meals/:id)
@@ -19,44 +20,249 @@ import (
 	"meals/store"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// GetMealsHandler retrieves all meals from the database.
+// Search results returned by default and at most
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// mealListSpec is what GET /meals can be sorted and filtered on
+var mealListSpec = listSpec{
+	fields: map[string]listField{
+		"id": {column: "id", parse: parseListUint, value: func(row interface{}) string {
+			return strconv.FormatUint(uint64(row.(*models.Meal).ID), 10)
+		}},
+		"name": {column: "name", parse: parseListString, value: func(row interface{}) string {
+			return row.(*models.Meal).Name
+		}},
+		"price": {column: "price_amount", parse: parseListInt, value: func(row interface{}) string {
+			return strconv.FormatInt(row.(*models.Meal).Price.Amount, 10)
+		}},
+		"category": {column: "category", parse: func(value string) (interface{}, error) {
+			return models.NormalizeCategory(value), nil
+		}, value: func(row interface{}) string {
+			return row.(*models.Meal).Category
+		}},
+		"created_at": {column: "created_at", parse: parseListTime, value: func(row interface{}) string {
+			return formatListTime(row.(*models.Meal).CreatedAt)
+		}},
+	},
+	defaultSort: "id",
+	params:      []string{"for_me", "unsuitable"},
+}
+
+// GetMealsHandler lists meals a page at a time.
 //
-// This endpoint is publicly accessible and returns all meals without filtering.
-// In the future, this might be enhanced to support pagination and filtering.
+// This endpoint is publicly accessible. With for_me=true it requires
+// authentication and hides meals unsuitable for the customer's diets and
+// allergens, or keeps them with their dietary_conflicts with unsuitable=flag.
+// Hidden meals are filtered out before paging, so every page but the last is full.
 //
 // Route: GET /meals
-// Response: 200 OK with array of Meal objects
-// Error responses: 500 if database error occurs
+// Query parameters: limit (optional) - Meals per page, 20 by default and at most 100;
+// sort (optional) - id (default), name, price, category or created_at, prefixed with - for descending order;
+// cursor (optional) - next_cursor of the previous page;
+// filters (optional) - <field>=value or <field>_{lt,lte,gt,gte,ne}=value on the sort fields, e.g. price_lte=1500;
+// for_me (optional) - true to check meals against the customer's profile; unsuitable (optional) - hide (default) or flag
+// Response: 200 OK with data (array of Meal objects) and next_cursor, null on the last page
+// Error responses: 400 if invalid or unknown parameters, 401 if for_me without authentication,
+// 500 if database error occurs
 func GetMealsHandler(c *gin.Context) {
+	query, err := parseListQuery(c, &mealListSpec)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	filter, ok := dietaryFilterFromQuery(c, store.DB)
+	if !ok {
+		return
+	}
+
+	// Unsuitable meals are hidden in SQL so pages stay full and cursors exact
+	db := store.DB
+	if filter != nil && !filter.flag {
+		db = filter.suitable(db.Model(&models.Meal{}))
+	}
+
 	var meals []models.Meal
-	if err := store.DB.Find(&meals).Error; err != nil {
+	next, err := query.find(db, &meals)
+	if err != nil {
 		RespondWithError(c, DatabaseError("Failed to retrieve meals"))
 		return
 	}
-	c.JSON(http.StatusOK, meals)
+
+	// Flagged listings keep unsuitable meals, with their conflicts
+	if filter != nil {
+		meals = filter.meals(meals)
+	}
+	c.JSON(http.StatusOK, listPage{Data: meals, NextCursor: next})
+}
+
+// SearchMealsHandler searches meals by name, description and ingredient names
+// with Postgres full-text search, best matches first. The total and the facets
+// count tags, price ranges and dietary labels over every match, not only those
+// returned; they are computed in SQL so only one page of meals is loaded.
+// Deleted meals are never found. for_me and unsuitable filter the matches as
+// on GET /meals.
+//
+// Route: GET /meals/search
+// Query parameters: q (required) - Search terms; quoted phrases, OR and -word are supported;
+// limit (optional) - Meals returned, 20 by default and at most 100; for_me and unsuitable (optional)
+// Response: 200 OK with the query, total number of matches, meals and facets
+// Error responses: 400 if invalid query or limit, 401 if for_me without authentication, 500 if database error
+func SearchMealsHandler(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		RespondWithError(c, BadRequestError("q is required"))
+		return
+	}
+	if len(q) > 200 {
+		RespondWithError(c, BadRequestError("q must be at most 200 characters"))
+		return
+	}
+
+	limit := defaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			RespondWithError(c, BadRequestError("Invalid limit, expected 1 to "+strconv.Itoa(maxSearchLimit)))
+			return
+		}
+		limit = parsed
+	}
+
+	filter, ok := dietaryFilterFromQuery(c, store.DB)
+	if !ok {
+		return
+	}
+
+	// The search column and its language are set up by store.migrateMealSearch
+	matches := store.DB.Model(&models.Meal{}).Where("search_vector @@ websearch_to_tsquery('english', ?)", q)
+	if filter != nil && !filter.flag {
+		matches = filter.suitable(matches)
+	}
+	// A new session lets the page and facet queries share the conditions
+	matches = matches.Session(&gorm.Session{})
+
+	total, facets, err := countMealFacets(matches)
+	if err != nil {
+		RespondWithError(c, DatabaseError("Failed to search meals"))
+		return
+	}
+
+	var meals []models.Meal
+	if err := matches.
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(search_vector, websearch_to_tsquery('english', ?)) DESC, id ASC",
+			Vars:               []interface{}{q},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&meals).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to search meals"))
+		return
+	}
+
+	// Unsuitable meals were left out above unless they are to be flagged
+	if filter != nil {
+		meals = filter.meals(meals)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":  q,
+		"total":  total,
+		"meals":  meals,
+		"facets": facets,
+	})
+}
+
+// countMealFacets counts the meals a query matches and their facets with SQL
+// aggregates: one row for the total, price ranges and diets, and a grouped
+// query each for tags and allergens
+func countMealFacets(matches *gorm.DB) (int64, models.MealFacets, error) {
+	facets := models.NewMealFacets()
+
+	columns := []string{"COUNT(*)"}
+	var vars []interface{}
+	for _, priceRange := range facets.PriceRanges {
+		if priceRange.Max == nil {
+			columns = append(columns, "COUNT(*) FILTER (WHERE meals.price_amount >= ?)")
+			vars = append(vars, priceRange.Min)
+			continue
+		}
+		columns = append(columns, "COUNT(*) FILTER (WHERE meals.price_amount >= ? AND meals.price_amount < ?)")
+		vars = append(vars, priceRange.Min, *priceRange.Max)
+	}
+	for _, diet := range models.Diets {
+		columns = append(columns, "COUNT(*) FILTER (WHERE "+hasAnyLabel("meals.diets")+")")
+		vars = append(vars, models.DietLabelsSuiting(diet))
+	}
+
+	var total int64
+	counts := make([]int64, len(columns)-1)
+	dest := []interface{}{&total}
+	for i := range counts {
+		dest = append(dest, &counts[i])
+	}
+	if err := matches.Select(strings.Join(columns, ", "), vars...).Row().Scan(dest...); err != nil {
+		return 0, facets, err
+	}
+
+	for i := range facets.PriceRanges {
+		facets.PriceRanges[i].Count = int(counts[i])
+	}
+	for i, diet := range models.Diets {
+		if count := counts[len(facets.PriceRanges)+i]; count > 0 {
+			facets.Diets[diet] = int(count)
+		}
+	}
+
+	for column, labels := range map[string]map[string]int{
+		"meals.tags":      facets.Tags,
+		"meals.allergens": facets.Allergens,
+	} {
+		var rows []struct {
+			Label string
+			Count int
+		}
+		if err := matches.
+			Joins("CROSS JOIN LATERAL " + jsonLabels(column) + " AS facet(value)").
+			Select("facet.value AS label, COUNT(*) AS count").
+			Group("facet.value").
+			Scan(&rows).Error; err != nil {
+			return 0, facets, err
+		}
+		for _, row := range rows {
+			labels[row.Label] = row.Count
+		}
+	}
+
+	return total, facets, nil
 }
 
-// GetMealHandler retrieves a specific meal by ID.
+// GetMealHandler retrieves a specific meal by ID, with its ingredients and
+// the allergens and nutrition facts derived from them.
 //
 // Route: GET /meals/:id
 // Parameters: id (path) - The meal ID
 // Response: 200 OK with Meal object
-// Error responses: 404 if meal not found, 500 if database error
+// Error responses: 400 if invalid ID, 404 if meal not found, 500 if database error
 func GetMealHandler(c *gin.Context) {
-	id := c.Param("id")
-	var meal models.Meal
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid meal ID format"))
+		return
+	}
 
-	if err := store.DB.First(&meal, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			RespondWithError(c, NotFoundError("Meal"))
-		} else {
-			RespondWithError(c, DatabaseError("Failed to retrieve meal"))
-		}
+	meal, err := loadMeal(store.DB, uint(id))
+	if HandleAppError(c, err) {
 		return
 	}
 
@@ -68,10 +274,13 @@ func GetMealHandler(c *gin.Context) {
 // This endpoint requires authentication and creates a meal within a database transaction
 // to ensure data integrity. The meal data is validated before creation.
 //
+// Meals with ingredients derive their allergens and nutrition facts from them.
+//
 // Route: POST /meals
-// Request body: JSON with meal data (name, price, etc.)
+// Request body: JSON with meal data (name, price as {amount, currency} in minor units, optional description, tax
+// category, tags, diets, allergens and ingredients as {ingredient_id, quantity, unit} per portion)
 // Response: 201 Created with the created Meal object
-// Error responses: 400 if invalid data, 401 if unauthorized, 500 if database error
+// Error responses: 400 if invalid data or unknown ingredients, 401 if unauthorized, 500 if database error
 func CreateMealHandler(c *gin.Context) {
 	var newMeal models.Meal
 
@@ -80,16 +289,45 @@ func CreateMealHandler(c *gin.Context) {
 		return
 	}
 
+	newMeal.Price = models.NewMoney(newMeal.Price.Amount, newMeal.Price.Currency)
+	newMeal.Category = models.NormalizeCategory(newMeal.Category)
+	newMeal.Tags = models.NormalizeLabels(newMeal.Tags)
+	newMeal.Diets = models.NormalizeLabels(newMeal.Diets)
+	newMeal.Allergens = models.NormalizeLabels(newMeal.Allergens)
+	if errs := newMeal.ValidateMeal(); len(errs) > 0 {
+		RespondWithError(c, ValidationError("Invalid meal data", errs))
+		return
+	}
+
+	// Ingredients are stored once the meal exists; nutrition is always derived
+	ingredients := newMeal.Ingredients
+	newMeal.Ingredients = nil
+	newMeal.Nutrition = models.NutritionFacts{}
+
 	// Use transaction to ensure data integrity
+	var created *models.Meal
 	err := store.WithTransaction(c, func(tx *gorm.DB) error {
-		return tx.Create(&newMeal).Error
+		if err := tx.Create(&newMeal).Error; err != nil {
+			return err
+		}
+
+		if err := setMealIngredients(tx, newMeal.ID, ingredients); err != nil {
+			return err
+		}
+		if err := refreshMealFacts(tx, newMeal.ID); err != nil {
+			return err
+		}
+
+		var err error
+		created, err = loadMeal(tx, newMeal.ID)
+		return err
 	})
 
 	if HandleAppError(c, err) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, newMeal)
+	c.JSON(http.StatusCreated, created)
 }
 
 // UpdateMealHandler updates an existing meal with new data.
@@ -99,7 +337,7 @@ func CreateMealHandler(c *gin.Context) {
 //
 // Route: PUT /meals/:id
 // Parameters: id (path) - The meal ID to update
-// Request body: JSON with updated meal data
+// Request body: JSON with updated meal data; omitting ingredients keeps the current ones
 // Response: 200 OK with the updated Meal object
 // Error responses: 400 if invalid data/ID, 401 if unauthorized, 404 if meal not found, 500 if database error
 func UpdateMealHandler(c *gin.Context) {
@@ -117,7 +355,18 @@ func UpdateMealHandler(c *gin.Context) {
 		return
 	}
 
+	updatedMeal.Price = models.NewMoney(updatedMeal.Price.Amount, updatedMeal.Price.Currency)
+	updatedMeal.Category = models.NormalizeCategory(updatedMeal.Category)
+	updatedMeal.Tags = models.NormalizeLabels(updatedMeal.Tags)
+	updatedMeal.Diets = models.NormalizeLabels(updatedMeal.Diets)
+	updatedMeal.Allergens = models.NormalizeLabels(updatedMeal.Allergens)
+	if errs := updatedMeal.ValidateMeal(); len(errs) > 0 {
+		RespondWithError(c, ValidationError("Invalid meal data", errs))
+		return
+	}
+
 	// Use transaction to ensure data integrity
+	var updated *models.Meal
 	err = store.WithTransaction(c, func(tx *gorm.DB) error {
 		// First check if meal exists
 		var existingMeal models.Meal
@@ -128,15 +377,45 @@ func UpdateMealHandler(c *gin.Context) {
 			return result.Error
 		}
 
-		// Then update it
-		return tx.Updates(&updatedMeal).Error
+		// Then update it; existing orders keep their own price snapshots
+		if err := tx.Model(&existingMeal).Updates(map[string]interface{}{
+			"name":           updatedMeal.Name,
+			"description":    updatedMeal.Description,
+			"price_amount":   updatedMeal.Price.Amount,
+			"price_currency": updatedMeal.Price.Currency,
+			"category":       updatedMeal.Category,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Map updates skip the JSON serializer, so the labels are updated from a struct
+		if err := tx.Model(&existingMeal).Select("tags", "diets", "allergens").Updates(&models.Meal{
+			Tags:      updatedMeal.Tags,
+			Diets:     updatedMeal.Diets,
+			Allergens: updatedMeal.Allergens,
+		}).Error; err != nil {
+			return err
+		}
+
+		if updatedMeal.Ingredients != nil {
+			if err := setMealIngredients(tx, existingMeal.ID, updatedMeal.Ingredients); err != nil {
+				return err
+			}
+		}
+		if err := refreshMealFacts(tx, existingMeal.ID); err != nil {
+			return err
+		}
+
+		var err error
+		updated, err = loadMeal(tx, existingMeal.ID)
+		return err
 	})
 
 	if HandleAppError(c, err) {
 		return
 	}
 
-	c.JSON(http.StatusOK, updatedMeal)
+	c.JSON(http.StatusOK, updated)
 }
 
 // DeleteMealHandler deletes a meal by ID.
@@ -1,14 +1,143 @@
 package handlers
 
 import (
+	"meals/jobs"
 	"meals/models"
 	"meals/store"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrMenuMealReserved is returned when a menu update would drop or shrink a
+// menu meal below the portions customers have already reserved
+const ErrMenuMealReserved = "MENU_MEAL_RESERVED"
+
+// menuMealInputs keeps only the fields clients may set on menu meals, so IDs
+// and reserved portions stay server-maintained
+func menuMealInputs(menuMeals []models.MenuMeal) []models.MenuMeal {
+	inputs := make([]models.MenuMeal, 0, len(menuMeals))
+	for _, menuMeal := range menuMeals {
+		inputs = append(inputs, models.MenuMeal{
+			MealID:      menuMeal.MealID,
+			DeliveryDay: menuMeal.DeliveryDay,
+			Capacity:    menuMeal.Capacity,
+		})
+	}
+	return inputs
+}
+
+// checkMenuMeals verifies that every referenced meal exists and that no meal
+// is offered twice on the same delivery day
+func checkMenuMeals(tx *gorm.DB, menuMeals []models.MenuMeal) error {
+	var mealIDs []uint
+	seen := make(map[uint]bool)
+	for i, menuMeal := range menuMeals {
+		for _, other := range menuMeals[:i] {
+			if menuMeal.SameSlot(other) {
+				return ValidationErrorType{
+					Message: "A meal is listed twice for the same delivery day",
+					Details: map[string]interface{}{"meal_id": menuMeal.MealID, "delivery_day": menuMeal.DeliveryDay},
+				}
+			}
+		}
+		if !seen[menuMeal.MealID] {
+			seen[menuMeal.MealID] = true
+			mealIDs = append(mealIDs, menuMeal.MealID)
+		}
+	}
+
+	var count int64
+	if err := tx.Model(&models.Meal{}).Where("id IN ?", mealIDs).Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(mealIDs) {
+		return RelationshipErrorType{
+			Message: "One or more meal IDs do not exist",
+			Details: map[string]interface{}{
+				"provided_ids": mealIDs,
+				"found_count":  count,
+			},
+		}
+	}
+	return nil
+}
+
+// syncMenuMeals brings a menu's meals in line with menuMeals. Meals already on
+// the menu for the same delivery day are updated in place and keep their
+// reserved portions; capacity may not drop below them, and meals with
+// reservations may not be removed.
+func syncMenuMeals(tx *gorm.DB, menuID uint, menuMeals []models.MenuMeal) error {
+	var existing []models.MenuMeal
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("menu_id = ?", menuID).Order("id ASC").Find(&existing).Error; err != nil {
+		return err
+	}
+
+	kept := make(map[uint]bool, len(existing))
+	for _, menuMeal := range menuMeals {
+		var current *models.MenuMeal
+		for i := range existing {
+			if existing[i].SameSlot(menuMeal) {
+				current = &existing[i]
+				break
+			}
+		}
+
+		if current == nil {
+			menuMeal.MenuID = menuID
+			if err := tx.Create(&menuMeal).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		kept[current.ID] = true
+		if menuMeal.Capacity != nil && *menuMeal.Capacity < current.ReservedPortions {
+			return ConflictErrorType{
+				Code:    ErrMenuMealReserved,
+				Message: "Capacity cannot be lower than the portions already reserved",
+				Details: map[string]interface{}{
+					"menu_meal_id": current.ID,
+					"capacity":     *menuMeal.Capacity,
+					"reserved":     current.ReservedPortions,
+				},
+			}
+		}
+		if err := tx.Model(current).Updates(map[string]interface{}{
+			"delivery_day": menuMeal.DeliveryDay,
+			"capacity":     menuMeal.Capacity,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, menuMeal := range existing {
+		if kept[menuMeal.ID] {
+			continue
+		}
+		if menuMeal.ReservedPortions > 0 {
+			return ConflictErrorType{
+				Code:    ErrMenuMealReserved,
+				Message: "Meals with reserved portions cannot be removed from the menu",
+				Details: map[string]interface{}{
+					"menu_meal_id": menuMeal.ID,
+					"reserved":     menuMeal.ReservedPortions,
+				},
+			}
+		}
+		if err := tx.Delete(&menuMeal).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func CreateMenuHandler(c *gin.Context) {
 	var newMenu models.Menu
 	if err := c.BindJSON(&newMenu); err != nil {
@@ -16,41 +145,36 @@ func CreateMenuHandler(c *gin.Context) {
 		return
 	}
 
+	// Menus are published on creation unless a publish time is given
+	if newMenu.PublishedAt == nil {
+		now := time.Now()
+		newMenu.PublishedAt = &now
+	}
+	newMenu.DraftsGeneratedAt = nil
+
+	menuMeals := menuMealInputs(newMenu.MenuMeals)
+	newMenu.MenuMeals = nil
+
 	// Use transaction to ensure data integrity
 	err := store.WithTransaction(c, func(tx *gorm.DB) error {
-		// Create the menu
-		if err := tx.Create(&newMenu).Error; err != nil {
+		// Create the menu; its meals are inserted once below, with every field set
+		if err := tx.Omit("MenuMeals").Create(&newMenu).Error; err != nil {
 			return err
 		}
 
 		// If menu has associated meals, validate and handle the relationships
-		if len(newMenu.MenuMeals) > 0 {
-			// Verify all referenced meal IDs exist
-			var count int64
-			if err := tx.Model(&models.Meal{}).Where("id IN ?", newMenu.MenuMeals).Count(&count).Error; err != nil {
+		if len(menuMeals) > 0 {
+			if err := checkMenuMeals(tx, menuMeals); err != nil {
 				return err
 			}
 
-			if int(count) != len(newMenu.MenuMeals) {
-				return RelationshipErrorType{
-					Message: "One or more meal IDs do not exist",
-					Details: map[string]interface{}{
-						"provided_ids": newMenu.MenuMeals,
-						"found_count":  count,
-					},
-				}
+			for i := range menuMeals {
+				menuMeals[i].MenuID = newMenu.ID
 			}
-
-			// Create menu-meal associations
-			for _, meal := range newMenu.MenuMeals {
-				menuMeal := models.MenuMeal{
-					MenuID: newMenu.ID,
-					MealID: meal.MealID,
-				}
-				if err := tx.Create(&menuMeal).Error; err != nil {
-					return err
-				}
+			if err := tx.Create(&menuMeals).Error; err != nil {
+				return err
 			}
+			newMenu.MenuMeals = menuMeals
 		}
 
 		return nil
@@ -60,9 +184,53 @@ func CreateMenuHandler(c *gin.Context) {
 		return
 	}
 
+	// Generate subscription drafts without waiting for the next scheduled run
+	jobs.Trigger(SubscriptionDraftsJob)
+
 	c.JSON(http.StatusCreated, newMenu)
 }
 
+// PublishMenuHandler publishes a menu immediately, making it available to
+// subscriptions. Publishing an already published menu has no effect.
+//
+// Route: POST /admin/menus/:id/publish
+// Parameters: id (path) - The menu ID
+// Response: 200 OK with the published Menu object
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not admin, 404 if menu not found, 500 if database error
+func PublishMenuHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid menu ID format"))
+		return
+	}
+
+	var menu models.Menu
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		if err := tx.First(&menu, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return NotFoundErrorType{Resource: "Menu"}
+			}
+			return err
+		}
+
+		now := time.Now()
+		if menu.IsPublished(now) {
+			return nil
+		}
+
+		menu.PublishedAt = &now
+		return tx.Model(&menu).Update("published_at", now).Error
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	jobs.Trigger(SubscriptionDraftsJob)
+
+	c.JSON(http.StatusOK, menu)
+}
+
 func UpdateMenuHandler(c *gin.Context) {
 	var updatedMenu models.Menu
 	if err := c.BindJSON(&updatedMenu); err != nil {
@@ -87,7 +255,7 @@ func UpdateMenuHandler(c *gin.Context) {
 		}
 
 		// Update the menu basic properties
-		if err := tx.Model(&updatedMenu).Updates(map[string]interface{}{
+		if err := tx.Model(&existingMenu).Updates(map[string]interface{}{
 			"name":        updatedMenu.Name,
 			"description": updatedMenu.Description,
 			// Add other fields as needed
@@ -95,39 +263,16 @@ func UpdateMenuHandler(c *gin.Context) {
 			return err
 		}
 
-		// If meal associations have changed, update them
+		// If meal associations have changed, update them in place so existing
+		// reservations and the orders pointing at them are kept
 		if len(updatedMenu.MenuMeals) > 0 {
-			// Verify all referenced meal IDs exist
-			var count int64
-			if err := tx.Model(&models.Meal{}).Where("id IN ?", updatedMenu.MenuMeals).Count(&count).Error; err != nil {
+			menuMeals := menuMealInputs(updatedMenu.MenuMeals)
+			if err := checkMenuMeals(tx, menuMeals); err != nil {
 				return err
 			}
-
-			if int(count) != len(updatedMenu.MenuMeals) {
-				return RelationshipErrorType{
-					Message: "One or more meal IDs do not exist",
-					Details: map[string]interface{}{
-						"provided_ids": updatedMenu.MenuMeals,
-						"found_count":  count,
-					},
-				}
-			}
-
-			// Delete existing associations
-			if err := tx.Where("menu_id = ?", updatedMenu.ID).Delete(&models.MenuMeal{}).Error; err != nil {
+			if err := syncMenuMeals(tx, updatedMenu.ID, menuMeals); err != nil {
 				return err
 			}
-
-			// Create new associations
-			for _, mealID := range updatedMenu.MenuMeals {
-				menuMeal := models.MenuMeal{
-					MenuID: updatedMenu.ID,
-					MealID: mealID.MealID,
-				}
-				if err := tx.Create(&menuMeal).Error; err != nil {
-					return err
-				}
-			}
 		}
 
 		return nil
@@ -147,22 +292,75 @@ func UpdateMenuHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, refreshedMenu)
 }
 
-// GetMenusHandler retrieves all menus with their associated meals
+// menuListSpec is what GET /menus can be sorted and filtered on
+var menuListSpec = listSpec{
+	fields: map[string]listField{
+		"id": {column: "id", parse: parseListUint, value: func(row interface{}) string {
+			return strconv.FormatUint(uint64(row.(*models.Menu).ID), 10)
+		}},
+		"name": {column: "name", parse: parseListString, value: func(row interface{}) string {
+			return row.(*models.Menu).Name
+		}},
+		"week_start": {column: "week_start_date", parse: parseListTime, value: func(row interface{}) string {
+			return formatListTime(row.(*models.Menu).WeekStartDate)
+		}},
+		"week_end": {column: "week_end_date", parse: parseListTime, value: func(row interface{}) string {
+			return formatListTime(row.(*models.Menu).WeekEndDate)
+		}},
+		"created_at": {column: "created_at", parse: parseListTime, value: func(row interface{}) string {
+			return formatListTime(row.(*models.Menu).CreatedAt)
+		}},
+	},
+	defaultSort: "id",
+	params:      []string{"for_me", "unsuitable"},
+}
+
+// GetMenusHandler lists menus a page at a time with their associated meals,
+// including each meal's resolved delivery date, ordering cutoff and remaining
+// portions. Only the meals of the menus on the page are loaded. With
+// for_me=true, meals unsuitable for the authenticated customer are hidden, or
+// flagged with unsuitable=flag, as in GetMealsHandler.
+//
+// Route: GET /menus
+// Query parameters: limit, cursor and for_me as on GET /meals;
+// sort (optional) - id (default), name, week_start, week_end or created_at, prefixed with - for descending order;
+// filters (optional) - <field>=value or <field>_{lt,lte,gt,gte,ne}=value on the sort fields, e.g. week_start_gte=2025-06-02
+// Response: 200 OK with data (array of Menu objects) and next_cursor, null on the last page
+// Error responses: 400 if invalid or unknown parameters, 401 if for_me without authentication,
+// 500 if database error occurs
 func GetMenusHandler(c *gin.Context) {
+	query, err := parseListQuery(c, &menuListSpec)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	filter, ok := dietaryFilterFromQuery(c, store.DB)
+	if !ok {
+		return
+	}
+
 	var menus []models.Menu
+	var next *string
 
 	// Use transaction to ensure data consistency
-	err := store.WithTransaction(c, func(tx *gorm.DB) error {
-		// Get all menus with their menu-meal associations and the associated meals
-		if err := tx.Preload("MenuMeals.Meal").Find(&menus).Error; err != nil {
-			return err
-		}
-		return nil
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		// Get a page of menus with their menu-meal associations and the associated meals
+		var err error
+		next, err = query.find(tx.Preload("MenuMeals.Meal"), &menus)
+		return err
 	})
 
 	if HandleAppError(c, err) {
 		return
 	}
 
-	c.JSON(http.StatusOK, menus)
+	// Expose each meal's delivery date and ordering cutoff
+	for i := range menus {
+		annotateMenuSchedule(&menus[i])
+		if filter != nil {
+			filter.menu(&menus[i])
+		}
+	}
+
+	c.JSON(http.StatusOK, listPage{Data: menus, NextCursor: next})
 }
@@ -0,0 +1,593 @@
+package handlers
+
+import (
+	"errors"
+	"meals/models"
+	"meals/pricing"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// OrderItemRequest represents a single line in an order request
+type OrderItemRequest struct {
+	MenuMealID uint `json:"menu_meal_id" binding:"required"`
+	Quantity   int  `json:"quantity" binding:"required,min=1"`
+}
+
+// CreateOrderRequest represents the request body for placing an order
+type CreateOrderRequest struct {
+	Notes        string             `json:"notes"`
+	PromoCode    string             `json:"promo_code"`    // Only used when placing an order
+	DeliveryZone string             `json:"delivery_zone"` // Only used when placing an order; defaults to the configured zone
+	UseCredit    bool               `json:"use_credit"`    // Only used when placing an order; spends store credit first
+	Items        []OrderItemRequest `json:"items" binding:"required,min=1,dive"`
+
+	// Only used when placing an order; the slot must be in the delivery zone on the items' delivery date
+	DeliverySlotID *uint `json:"delivery_slot_id"`
+
+	// Only used when placing an order; the geocoded location is used to plan delivery routes
+	DeliveryAddress      string   `json:"delivery_address"`
+	DeliveryLatitude     *float64 `json:"delivery_latitude"`
+	DeliveryLongitude    *float64 `json:"delivery_longitude"`
+	DeliveryInstructions string   `json:"delivery_instructions"`
+
+	// Only used when placing an order; copies an address book entry over the delivery
+	// address fields. Without either, the default address is used.
+	AddressID *uint `json:"address_id"`
+
+	// Orders meals containing allergens declared on the customer's profile anyway
+	AcknowledgeAllergens bool `json:"acknowledge_allergens"`
+}
+
+// orderItemsFromRequest merges duplicate menu meal lines into order items
+func orderItemsFromRequest(lines []OrderItemRequest) []models.OrderItem {
+	var items []models.OrderItem
+	indexByMenuMeal := make(map[uint]int)
+
+	for _, line := range lines {
+		if idx, exists := indexByMenuMeal[line.MenuMealID]; exists {
+			items[idx].Quantity += line.Quantity
+			continue
+		}
+		indexByMenuMeal[line.MenuMealID] = len(items)
+		items = append(items, models.OrderItem{
+			MenuMealID: line.MenuMealID,
+			Quantity:   line.Quantity,
+		})
+	}
+
+	return items
+}
+
+// prepareOrderItems validates an order's items against the menu meals they
+// reference and resolves the order's delivery date, rejecting items whose
+// ordering cutoff has passed
+func prepareOrderItems(tx *gorm.DB, order *models.Order, now time.Time) error {
+	if errs := order.ValidateOrder(); len(errs) > 0 {
+		return ValidationErrorType{
+			Message: "Invalid order data",
+			Details: errs,
+		}
+	}
+
+	if err := locateDeliveryZone(tx, order); err != nil {
+		return err
+	}
+
+	// Verify all referenced menu meals exist
+	menuMealIDs := make([]uint, 0, len(order.Items))
+	for _, item := range order.Items {
+		menuMealIDs = append(menuMealIDs, item.MenuMealID)
+	}
+
+	var menuMeals []models.MenuMeal
+	if err := tx.Preload("Menu").Preload("Meal").Where("id IN ?", menuMealIDs).Find(&menuMeals).Error; err != nil {
+		return err
+	}
+
+	if len(menuMeals) != len(menuMealIDs) {
+		return RelationshipErrorType{
+			Message: "One or more menu meal IDs do not exist",
+			Details: map[string]interface{}{
+				"provided_ids": menuMealIDs,
+				"found_count":  len(menuMeals),
+			},
+		}
+	}
+
+	deliveryDate, err := resolveOrderDeliveryDate(menuMeals, now)
+	if err != nil {
+		return err
+	}
+	order.DeliveryDate = deliveryDate
+
+	if err := snapshotOrderPrices(tx, order, menuMeals); err != nil {
+		return err
+	}
+
+	return checkDeliveryZoneRules(tx, order)
+}
+
+// snapshotOrderPrices copies each meal's current name and price onto the order
+// items and prices the order. All meals must share a currency.
+func snapshotOrderPrices(tx *gorm.DB, order *models.Order, menuMeals []models.MenuMeal) error {
+	menuMealsByID := make(map[uint]models.MenuMeal, len(menuMeals))
+	for _, menuMeal := range menuMeals {
+		menuMealsByID[menuMeal.ID] = menuMeal
+	}
+
+	currency := ""
+	for i := range order.Items {
+		meal := menuMealsByID[order.Items[i].MenuMealID].Meal
+		if currency == "" {
+			currency = meal.Price.Currency
+		} else if meal.Price.Currency != currency {
+			return ValidationErrorType{
+				Message: "All items in an order must be priced in the same currency",
+				Details: map[string]interface{}{
+					"currencies": []string{currency, meal.Price.Currency},
+				},
+			}
+		}
+		order.Items[i].SnapshotMeal(meal)
+	}
+
+	return priceOrder(tx, order)
+}
+
+// priceOrder computes the line taxes, fees, total and price breakdown of an
+// order with the rules in config.Pricing and the delivery zones in tx
+func priceOrder(tx *gorm.DB, order *models.Order) error {
+	rules, err := pricingRules(tx)
+	if err != nil {
+		return err
+	}
+
+	err = pricing.NewCalculator(rules).Price(order)
+	if errors.Is(err, pricing.ErrUnknownZone) {
+		return ValidationErrorType{
+			Message: "Orders cannot be delivered to this zone",
+			Details: map[string]interface{}{"delivery_zone": order.DeliveryZone},
+		}
+	}
+	return err
+}
+
+// placeOrder validates an order and its items and persists them within tx,
+// redeeming order.PromoCode if set, spending the customer's store credit if
+// useCredit is set and recording the initial placement in the order's status
+// history
+func placeOrder(tx *gorm.DB, order *models.Order, actor orderActor, useCredit bool) error {
+	now := time.Now()
+	order.Status = models.OrderStatusPlaced
+
+	if err := prepareOrderItems(tx, order, now); err != nil {
+		return err
+	}
+
+	var promo *models.PromoCode
+	if order.PromoCode != "" {
+		var err error
+		if promo, err = applyPromoCode(tx, order, now); err != nil {
+			return err
+		}
+	}
+
+	if err := bookDeliverySlot(tx, order, now); err != nil {
+		return err
+	}
+
+	if err := reservePortions(tx, order.Items); err != nil {
+		return err
+	}
+
+	// Creating the order also creates its items through the association
+	if err := tx.Create(order).Error; err != nil {
+		return err
+	}
+
+	if promo != nil {
+		if err := redeemPromoCode(tx, promo, order); err != nil {
+			return err
+		}
+	}
+
+	if useCredit {
+		if err := applyStoreCredit(tx, order, actor); err != nil {
+			return err
+		}
+	}
+
+	if err := recordStatusEvent(tx, order.ID, "", order.Status, actor, ""); err != nil {
+		return err
+	}
+
+	// Authorize last so a failure in any earlier step never holds the customer's funds
+	return authorizeOrderPayment(tx, order)
+}
+
+// replaceOrderItems swaps the items of an existing, locked order within tx.
+// The discount of a redeemed promo code is recomputed for the new items and
+// store credit beyond the new total is returned. For orders that hold stock,
+// the portions of the old items are released before the new ones are reserved
+// and the payment is re-authorized for the new amount due; drafts reserve
+// nothing until submitted.
+func replaceOrderItems(tx *gorm.DB, order *models.Order, items []models.OrderItem, actor orderActor) error {
+	order.Items = items
+	if err := prepareOrderItems(tx, order, time.Now()); err != nil {
+		return err
+	}
+
+	// The booked delivery slot is kept, so the new items must be delivered on its date
+	if err := checkBookedSlotDate(tx, order); err != nil {
+		return err
+	}
+
+	if err := repricePromoDiscount(tx, order); err != nil {
+		return err
+	}
+
+	if order.CreditApplied.Amount > order.Total.Amount {
+		if err := returnStoreCredit(tx, order, order.Total, actor, "Order "+strconv.FormatUint(uint64(order.ID), 10)+" total reduced"); err != nil {
+			return err
+		}
+	}
+
+	if !order.Status.ReservesStock() {
+		return saveOrderItems(tx, order)
+	}
+
+	var previous []models.OrderItem
+	if err := tx.Where("order_id = ?", order.ID).Find(&previous).Error; err != nil {
+		return err
+	}
+
+	if err := releasePortions(tx, previous); err != nil {
+		return err
+	}
+
+	if err := reservePortions(tx, order.Items); err != nil {
+		return err
+	}
+
+	if err := saveOrderItems(tx, order); err != nil {
+		return err
+	}
+
+	return reauthorizeOrderPayment(tx, order)
+}
+
+// submitDraftOrder re-prices a locked draft order's items against the current
+// menu and reserves their portions, ahead of it moving to placed. Drafts
+// generated before the customer saved an address get their default address.
+func submitDraftOrder(tx *gorm.DB, order *models.Order) error {
+	if err := applyOrderAddress(tx, order, nil); err != nil {
+		return err
+	}
+	if err := tx.Model(order).Select(
+		"address_id", "delivery_address", "delivery_latitude", "delivery_longitude", "delivery_instructions",
+	).Updates(order).Error; err != nil {
+		return err
+	}
+
+	var current []models.OrderItem
+	if err := tx.Where("order_id = ?", order.ID).Find(&current).Error; err != nil {
+		return err
+	}
+
+	order.Items = make([]models.OrderItem, 0, len(current))
+	for _, item := range current {
+		order.Items = append(order.Items, models.OrderItem{
+			MenuMealID: item.MenuMealID,
+			Quantity:   item.Quantity,
+		})
+	}
+
+	if err := prepareOrderItems(tx, order, time.Now()); err != nil {
+		return err
+	}
+
+	if err := reservePortions(tx, order.Items); err != nil {
+		return err
+	}
+
+	return saveOrderItems(tx, order)
+}
+
+// saveOrderItems replaces the stored items of an order with order.Items and
+// updates the columns derived from them
+func saveOrderItems(tx *gorm.DB, order *models.Order) error {
+	if err := tx.Where("order_id = ?", order.ID).Delete(&models.OrderItem{}).Error; err != nil {
+		return err
+	}
+
+	for i := range order.Items {
+		order.Items[i].OrderID = order.ID
+	}
+	if err := tx.Create(&order.Items).Error; err != nil {
+		return err
+	}
+
+	// Updating from the struct applies the breakdown's JSON serializer; Select
+	// also writes zero amounts and keeps the items from being saved again
+	return tx.Model(order).Select(
+		"notes", "delivery_date", "delivery_zone",
+		"subtotal_amount", "subtotal_currency",
+		"discount_amount", "discount_currency",
+		"tax_amount", "tax_currency",
+		"fees_amount", "fees_currency",
+		"total_amount", "total_currency",
+		"breakdown", "acknowledged_allergens",
+	).Updates(order).Error
+}
+
+// loadOrder fetches an order with its items, meals and status history preloaded
+func loadOrder(db *gorm.DB, id uint) (*models.Order, error) {
+	var order models.Order
+	err := db.Preload("Items.MenuMeal.Meal").
+		Preload("DeliverySlot", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Preload("Payment").
+		Preload("Refunds").
+		Preload("StatusEvents", func(db *gorm.DB) *gorm.DB {
+			return db.Order("occurred_at ASC")
+		}).
+		First(&order, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NotFoundErrorType{Resource: "Order"}
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// CreateOrderHandler places a new order for the authenticated user.
+//
+// The order and all of its items are created within a single transaction, and
+// every referenced MenuMeal must exist. An optional promo code is validated and
+// its use counted in the same transaction, as is the booking of an optional
+// delivery slot. Meals containing allergens declared on the customer's profile
+// are only ordered with acknowledge_allergens set.
+//
+// Route: POST /orders
+// Request body: JSON with notes, optional promo_code, delivery_zone, delivery_slot_id, address_id or delivery address
+// and acknowledge_allergens, and items (menu_meal_id, quantity)
+// Response: 201 Created with the created Order object
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if forbidden, 409 if the delivery slot is full
+// or meals contain declared allergens, 500 if database error
+func CreateOrderHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid order data", err.Error()))
+		return
+	}
+
+	order := models.Order{
+		UserID:       userID,
+		Notes:        req.Notes,
+		PromoCode:    req.PromoCode,
+		DeliveryZone: req.DeliveryZone,
+		Items:        orderItemsFromRequest(req.Items),
+
+		DeliverySlotID: req.DeliverySlotID,
+
+		DeliveryAddress:      req.DeliveryAddress,
+		DeliveryLatitude:     req.DeliveryLatitude,
+		DeliveryLongitude:    req.DeliveryLongitude,
+		DeliveryInstructions: req.DeliveryInstructions,
+	}
+
+	actor := actorFromContext(c)
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		if err := applyOrderAddress(tx, &order, req.AddressID); err != nil {
+			return err
+		}
+		if err := checkOrderAllergens(tx, &order, order.Items, req.AcknowledgeAllergens); err != nil {
+			return err
+		}
+		return placeOrder(tx, &order, actor, req.UseCredit)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	created, err := loadOrder(store.DB, order.ID)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateOrderHandler replaces the notes and items of a draft or placed order
+// that has not yet been confirmed.
+//
+// Edits are rejected once the ordering cutoff of the order's current delivery
+// date has passed, and the new items are subject to the same cutoff checks as
+// a new order, including the allergen check.
+//
+// Route: PUT /orders/:id
+// Parameters: id (path) - The order ID
+// Request body: JSON with notes, optional acknowledge_allergens and items (menu_meal_id, quantity)
+// Response: 200 OK with the updated Order object
+// Error responses: 400 if invalid data or past the cutoff, 401 if unauthorized, 404 if order not found,
+// 409 if the order can no longer be edited or meals contain declared allergens, 500 if database error
+func UpdateOrderHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid order ID format"))
+		return
+	}
+
+	var req CreateOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid order data", err.Error()))
+		return
+	}
+
+	isAdmin := currentUserType(c) == models.UserTypeAdmin
+	actor := actorFromContext(c)
+
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, uint(id))
+		if err != nil {
+			return err
+		}
+
+		if !isAdmin && !order.BelongsTo(userID) {
+			return NotFoundErrorType{Resource: "Order"}
+		}
+
+		if !order.IsEditable() {
+			return ConflictErrorType{
+				Message: "Only " + string(models.OrderStatusDraft) + " and " + string(models.OrderStatusPlaced) + " orders can be edited",
+				Details: map[string]interface{}{"status": order.Status},
+			}
+		}
+
+		if cutoff := orderCutoff(order); !time.Now().Before(cutoff) {
+			return ValidationErrorType{
+				Message: "Ordering for " + order.DeliveryDate.Format("Monday, January 2") + " has closed",
+				Details: map[string]interface{}{
+					"delivery_date": order.DeliveryDate.Format("2006-01-02"),
+					"cutoff":        cutoff,
+				},
+			}
+		}
+
+		items := orderItemsFromRequest(req.Items)
+		if err := checkOrderAllergens(tx, order, items, req.AcknowledgeAllergens); err != nil {
+			return err
+		}
+
+		order.Notes = req.Notes
+		return replaceOrderItems(tx, order, items, actor)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	updated, err := loadOrder(store.DB, uint(id))
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// GetOrdersHandler lists the orders visible to the authenticated user.
+//
+// Admins see every order and may narrow the list with the user_id query
+// parameter; all other users only see their own orders.
+//
+// Route: GET /orders
+// Parameters: user_id (query, admin only) - Filter orders by user
+// Response: 200 OK with array of Order objects
+// Error responses: 400 if invalid user_id, 401 if unauthorized, 500 if database error
+func GetOrdersHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	query := store.DB.Preload("Items.MenuMeal.Meal").Order("created_at DESC")
+
+	if currentUserType(c) == models.UserTypeAdmin {
+		if filter := c.Query("user_id"); filter != "" {
+			filterID, err := strconv.ParseUint(filter, 10, 64)
+			if err != nil {
+				RespondWithError(c, BadRequestError("Invalid user ID format"))
+				return
+			}
+			query = query.Where("user_id = ?", filterID)
+		}
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var orders []models.Order
+	if err := query.Find(&orders).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve orders"))
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetMyOrdersHandler lists the orders placed by the authenticated user,
+// regardless of role.
+//
+// Route: GET /orders/mine
+// Response: 200 OK with array of Order objects
+// Error responses: 401 if unauthorized, 500 if database error
+func GetMyOrdersHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var orders []models.Order
+	if err := store.DB.Preload("Items.MenuMeal.Meal").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&orders).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve orders"))
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetOrderHandler retrieves a specific order by ID.
+//
+// Non-admin users can only retrieve their own orders; other orders are
+// reported as not found so their existence is not leaked.
+//
+// Route: GET /orders/:id
+// Parameters: id (path) - The order ID
+// Response: 200 OK with Order object
+// Error responses: 400 if invalid ID, 401 if unauthorized, 404 if order not found, 500 if database error
+func GetOrderHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid order ID format"))
+		return
+	}
+
+	order, err := loadOrder(store.DB, uint(id))
+	if HandleAppError(c, err) {
+		return
+	}
+
+	if currentUserType(c) != models.UserTypeAdmin && !order.BelongsTo(userID) {
+		RespondWithError(c, NotFoundError("Order"))
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
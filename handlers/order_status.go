@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"meals/middleware"
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// orderActor identifies who is changing an order and the request they did it in
+type orderActor struct {
+	UserID    uint
+	UserType  models.UserType
+	RequestID string
+}
+
+// actorFromContext builds an orderActor from the values set by auth.RequireRole
+// and middleware.RequestID
+func actorFromContext(c *gin.Context) orderActor {
+	userID, _ := currentUserID(c)
+	return orderActor{
+		UserID:    userID,
+		UserType:  currentUserType(c),
+		RequestID: middleware.GetRequestID(c),
+	}
+}
+
+// UpdateOrderStatusRequest represents the request body for changing an order's status
+type UpdateOrderStatusRequest struct {
+	Status models.OrderStatus `json:"status" binding:"required"`
+	Note   string             `json:"note"`
+}
+
+// recordStatusEvent appends a transition to the order's status history
+func recordStatusEvent(tx *gorm.DB, orderID uint, from, to models.OrderStatus, actor orderActor, note string) error {
+	event := models.OrderStatusEvent{
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actor.UserID,
+		ActorType:  actor.UserType,
+		RequestID:  actor.RequestID,
+		Note:       note,
+		OccurredAt: time.Now(),
+	}
+	return tx.Create(&event).Error
+}
+
+// lockOrder loads an order with a row lock so concurrent transitions are serialized
+func lockOrder(tx *gorm.DB, id uint) (*models.Order, error) {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NotFoundErrorType{Resource: "Order"}
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// transitionOrder moves a locked order to the next status if the lifecycle and
+// the actor's role allow it, and records the transition
+func transitionOrder(tx *gorm.DB, order *models.Order, next models.OrderStatus, actor orderActor, note string) error {
+	if !next.IsValid() {
+		return ValidationErrorType{
+			Message: "Invalid order status",
+			Details: map[string]interface{}{"status": next},
+		}
+	}
+
+	if !order.Status.CanTransitionTo(next) {
+		return ConflictErrorType{
+			Code:    ErrInvalidTransition,
+			Message: "Order cannot move from " + string(order.Status) + " to " + string(next),
+			Details: map[string]interface{}{
+				"from":    order.Status,
+				"to":      next,
+				"allowed": order.Status.NextStatuses(actor.UserType),
+			},
+		}
+	}
+
+	if !order.Status.CanBeTransitionedBy(next, actor.UserType) {
+		return ForbiddenErrorType{
+			Message: "You are not allowed to move this order to " + string(next),
+		}
+	}
+
+	// Customers may cancel placed orders only until ordering closes, since the
+	// kitchen plans its production from the orders it has at the cutoff
+	if actor.UserType == models.UserTypeCustomer && order.Status == models.OrderStatusPlaced &&
+		next == models.OrderStatusCancelled {
+		if cutoff := orderCutoff(order); !time.Now().Before(cutoff) {
+			return ValidationErrorType{
+				Message: "Orders for " + order.DeliveryDate.Format("Monday, January 2") + " can no longer be cancelled",
+				Details: map[string]interface{}{
+					"delivery_date": order.DeliveryDate.Format("2006-01-02"),
+					"cutoff":        cutoff,
+				},
+			}
+		}
+	}
+
+	return applyTransition(tx, order, next, actor, note)
+}
+
+// applyTransition moves a locked order to the next status without checking the
+// actor's role, adjusting reserved stock and payments and recording the
+// transition. Callers must have checked that the lifecycle allows the transition.
+func applyTransition(tx *gorm.DB, order *models.Order, next models.OrderStatus, actor orderActor, note string) error {
+	previous := order.Status
+
+	// Submitting a draft prices it against the current menu and reserves its portions
+	if previous == models.OrderStatusDraft && next == models.OrderStatusPlaced {
+		if err := submitDraftOrder(tx, order); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(order).Update("status", next).Error; err != nil {
+		return err
+	}
+
+	switch next {
+	case models.OrderStatusPlaced:
+		if previous == models.OrderStatusDraft {
+			if err := authorizeOrderPayment(tx, order); err != nil {
+				return err
+			}
+		}
+
+	case models.OrderStatusDelivered:
+		if err := captureOrderPayment(tx, order); err != nil {
+			return err
+		}
+
+	case models.OrderStatusCancelled, models.OrderStatusFailed:
+		// Cancelled orders give their portions back to the menu
+		if next == models.OrderStatusCancelled && previous.ReservesStock() {
+			var items []models.OrderItem
+			if err := tx.Where("order_id = ?", order.ID).Find(&items).Error; err != nil {
+				return err
+			}
+			if err := releasePortions(tx, items); err != nil {
+				return err
+			}
+		}
+
+		// Their promo code use no longer counts towards the code's limits
+		if next == models.OrderStatusCancelled {
+			if err := releasePromoCode(tx, order); err != nil {
+				return err
+			}
+
+			// The delivery window opens up for other customers
+			if err := releaseDeliverySlot(tx, order); err != nil {
+				return err
+			}
+		}
+
+		if err := voidOrderPayment(tx, order); err != nil {
+			return err
+		}
+
+		// The customer gets back any store credit spent on the order
+		note := "Order " + strconv.FormatUint(uint64(order.ID), 10) + " " + string(next)
+		if err := returnStoreCredit(tx, order, models.Money{}, actor, note); err != nil {
+			return err
+		}
+	}
+
+	return recordStatusEvent(tx, order.ID, previous, next, actor, note)
+}
+
+// UpdateOrderStatusHandler moves an order through its lifecycle.
+//
+// Allowed transitions depend on the caller's role: admins confirm and prepare
+// orders, and customers may submit their own drafts and cancel their own
+// orders while they are still placed, until the ordering cutoff. Drivers move
+// orders through the stops of the runs assigned to them instead, so they are
+// rejected here. Submitting a draft re-prices it and reserves its portions.
+// Every transition is recorded in the order's status history with the actor
+// and request ID.
+//
+// Route: PUT /orders/:id/status
+// Parameters: id (path) - The order ID
+// Request body: JSON with status and optional note
+// Response: 200 OK with the updated Order object
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if the role may not perform the transition,
+// 404 if order not found, 409 if the transition is not allowed from the current status, 500 if database error
+func UpdateOrderStatusHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid order ID format"))
+		return
+	}
+
+	var req UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid request data", err.Error()))
+		return
+	}
+
+	actor := actorFromContext(c)
+
+	// Deliveries are recorded against the driver's own run stops, which check
+	// that the order is on a run assigned to them
+	if actor.UserType == models.UserTypeDriver {
+		HandleAppError(c, ForbiddenErrorType{Message: "Drivers update orders through the stops of their delivery runs"})
+		return
+	}
+
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, uint(id))
+		if err != nil {
+			return err
+		}
+
+		// Customers may only act on their own orders
+		if actor.UserType == models.UserTypeCustomer && !order.BelongsTo(userID) {
+			return NotFoundErrorType{Resource: "Order"}
+		}
+
+		return transitionOrder(tx, order, req.Status, actor, req.Note)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	updated, err := loadOrder(store.DB, uint(id))
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// CancelOrderRequest represents the optional request body for cancelling an order
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CancelOrderHandler cancels an order.
+//
+// Customers may cancel their own draft orders, and placed orders until the
+// ordering cutoff; admins may cancel any order that is not out for delivery.
+// In one transaction the order's reserved portions are released, its promo
+// code use is given back, its payment authorization is voided and any store
+// credit spent on it is returned to the customer's ledger.
+//
+// Route: POST /orders/:id/cancel
+// Parameters: id (path) - The order ID
+// Request body: optional JSON with reason
+// Response: 200 OK with the cancelled Order object
+// Error responses: 400 if invalid ID or past the cutoff, 401 if unauthorized, 403 if the role may not cancel,
+// 404 if order not found, 409 if the order can no longer be cancelled, 500 if database error
+func CancelOrderHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid order ID format"))
+		return
+	}
+
+	var req CancelOrderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondWithError(c, ValidationError("Invalid request data", err.Error()))
+			return
+		}
+	}
+
+	actor := actorFromContext(c)
+
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, uint(id))
+		if err != nil {
+			return err
+		}
+
+		if actor.UserType == models.UserTypeCustomer && !order.BelongsTo(userID) {
+			return NotFoundErrorType{Resource: "Order"}
+		}
+
+		return transitionOrder(tx, order, models.OrderStatusCancelled, actor, req.Reason)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	cancelled, err := loadOrder(store.DB, uint(id))
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, cancelled)
+}
@@ -0,0 +1,411 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"meals/config"
+	"meals/models"
+	"meals/payments"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// paymentProvider returns the registered provider with the given name
+func paymentProvider(name string) (payments.Provider, error) {
+	provider, exists := payments.Get(name)
+	if !exists {
+		return nil, fmt.Errorf("payment provider %q is not registered", name)
+	}
+	return provider, nil
+}
+
+// lockOrderPayment loads an order's payment with a row lock, returning nil if
+// the order has none
+func lockOrderPayment(tx *gorm.DB, orderID uint) (*models.Payment, error) {
+	var payment models.Payment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", orderID).First(&payment).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// PaymentCaptureJob is the background job that retries captures of delivered
+// orders that did not complete after their transaction committed
+const PaymentCaptureJob = "payment-captures"
+
+// releaseHold voids an authorization that is no longer needed. Failures are
+// logged and returned, but never retried, since uncaptured authorizations also
+// lapse at the provider.
+func releaseHold(providerName, reference string, amount models.Money) error {
+	provider, err := paymentProvider(providerName)
+	if err == nil {
+		_, err = provider.Refund(context.Background(), reference, amount)
+	}
+	if err != nil {
+		log.Printf("Failed to void authorization %s: %v", reference, err)
+	}
+	return err
+}
+
+// authorize holds amount with the configured provider, converting declines
+// into a PaymentErrorType. The hold is released again if tx rolls back, so
+// every attempt gets its own idempotency key starting with keyPrefix rather
+// than being handed a hold released by an earlier attempt.
+func authorize(tx *gorm.DB, amount models.Money, keyPrefix, description string) (payments.Provider, *payments.Result, error) {
+	provider, err := paymentProvider(config.AppConfig.Payments.Provider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := provider.Authorize(tx.Statement.Context, payments.AuthorizeRequest{
+		Amount:         amount,
+		IdempotencyKey: keyPrefix + "-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		Description:    description,
+	})
+	if errors.Is(err, payments.ErrDeclined) {
+		return nil, nil, PaymentErrorType{
+			Message: "The payment was declined",
+			Details: map[string]interface{}{"amount": amount},
+		}
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("authorize payment: %w", err)
+	}
+
+	store.AfterRollback(tx, func() {
+		releaseHold(provider.Name(), result.Reference, result.Amount)
+	})
+	return provider, result, nil
+}
+
+// authorizeOrderPayment holds the amount due on the order (its total less any
+// store credit) with the payment provider and records the Payment within tx.
+// Orders with nothing to pay get no payment.
+func authorizeOrderPayment(tx *gorm.DB, order *models.Order) error {
+	due := order.AmountDue()
+	if due.IsZero() {
+		return nil
+	}
+
+	provider, result, err := authorize(tx, due,
+		fmt.Sprintf("order-%d", order.ID), fmt.Sprintf("Order %d", order.ID))
+	if err != nil {
+		return err
+	}
+
+	payment := models.Payment{
+		OrderID:        order.ID,
+		Provider:       provider.Name(),
+		Reference:      result.Reference,
+		Status:         models.PaymentStatusAuthorized,
+		Amount:         result.Amount,
+		CapturedAmount: models.NewMoney(0, result.Amount.Currency),
+		RefundedAmount: models.NewMoney(0, result.Amount.Currency),
+		AuthorizedAt:   time.Now(),
+	}
+	return tx.Create(&payment).Error
+}
+
+// reauthorizeOrderPayment replaces the authorization of an edited order whose
+// amount due changed, voiding it if nothing is due any more. The new amount is
+// authorized first, so a declined payment leaves the original authorization in
+// place, and the old hold is only voided once the edit has been committed.
+func reauthorizeOrderPayment(tx *gorm.DB, order *models.Order) error {
+	payment, err := lockOrderPayment(tx, order.ID)
+	if err != nil {
+		return err
+	}
+	if payment == nil {
+		return authorizeOrderPayment(tx, order)
+	}
+	if payment.Amount == order.AmountDue() || !payment.CanCapture() {
+		return nil
+	}
+	if order.AmountDue().IsZero() {
+		return voidOrderPayment(tx, order)
+	}
+
+	provider, result, err := authorize(tx, order.AmountDue(),
+		"reauthorize-"+payment.Reference, fmt.Sprintf("Order %d", order.ID))
+	if err != nil {
+		return err
+	}
+
+	replaced := *payment
+	store.AfterCommit(tx, func() {
+		releaseHold(replaced.Provider, replaced.Reference, replaced.Amount)
+	})
+
+	return tx.Model(payment).Updates(map[string]interface{}{
+		"provider":          provider.Name(),
+		"reference":         result.Reference,
+		"amount_amount":     result.Amount.Amount,
+		"amount_currency":   result.Amount.Currency,
+		"captured_currency": result.Amount.Currency,
+		"refunded_currency": result.Amount.Currency,
+		"authorized_at":     time.Now(),
+		"failure_reason":    "",
+	}).Error
+}
+
+// captureOrderPayment records that the payment of a delivered order is to be
+// captured, and captures it once the delivery has been committed, so a
+// rolled back transition never charges the customer. A failed capture does not
+// undo the delivery; it is recorded on the payment and retried by
+// CapturePendingPayments.
+func captureOrderPayment(tx *gorm.DB, order *models.Order) error {
+	payment, err := lockOrderPayment(tx, order.ID)
+	if err != nil || payment == nil || !payment.CanCapture() {
+		return err
+	}
+
+	if err := tx.Model(payment).Update("capture_requested_at", time.Now()).Error; err != nil {
+		return err
+	}
+
+	paymentID := payment.ID
+	store.AfterCommit(tx, func() {
+		if err := capturePayment(store.DB, paymentID); err != nil {
+			log.Printf("Failed to capture payment %d: %v", paymentID, err)
+		}
+	})
+	return nil
+}
+
+// capturePayment charges a payment whose capture was requested and records
+// the outcome. Providers return the original capture for payments captured
+// already, so it is safe to call again after a crash.
+func capturePayment(db *gorm.DB, paymentID uint) error {
+	return store.Transaction(db, func(tx *gorm.DB) error {
+		var payment models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentID).Error; err != nil {
+			return err
+		}
+		if !payment.CanCapture() || payment.CaptureRequestedAt == nil {
+			return nil
+		}
+
+		provider, err := paymentProvider(payment.Provider)
+		if err == nil {
+			var result *payments.Result
+			result, err = provider.Capture(tx.Statement.Context, payment.Reference, payment.Amount)
+			if err == nil {
+				return tx.Model(&payment).Updates(map[string]interface{}{
+					"status":            models.PaymentStatusCaptured,
+					"captured_amount":   result.Amount.Amount,
+					"captured_currency": result.Amount.Currency,
+					"captured_at":       time.Now(),
+					"failure_reason":    "",
+				}).Error
+			}
+		}
+
+		log.Printf("Failed to capture payment %s of order %d: %v", payment.Reference, payment.OrderID, err)
+		return tx.Model(&payment).Update("failure_reason", "capture failed: "+err.Error()).Error
+	})
+}
+
+// CapturePendingPayments captures the payments of delivered orders whose
+// capture failed or never ran, e.g. because the server stopped right after
+// the delivery was committed
+func CapturePendingPayments() error {
+	var pending []uint
+	if err := store.DB.Model(&models.Payment{}).
+		Where("status = ? AND capture_requested_at IS NOT NULL", models.PaymentStatusAuthorized).
+		Order("capture_requested_at ASC").
+		Pluck("id", &pending).Error; err != nil {
+		return err
+	}
+
+	for _, id := range pending {
+		if err := capturePayment(store.DB, id); err != nil {
+			log.Printf("Failed to capture payment %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// voidOrderPayment releases the authorization of an order that will not be
+// delivered. The payment is marked voided within tx and the hold is released
+// once tx commits, so a rolled back cancellation keeps its authorization.
+// Failed voids are recorded rather than retried; uncaptured authorizations
+// also lapse at the provider.
+func voidOrderPayment(tx *gorm.DB, order *models.Order) error {
+	payment, err := lockOrderPayment(tx, order.ID)
+	if err != nil || payment == nil || !payment.CanCapture() {
+		return err
+	}
+
+	if err := tx.Model(payment).Updates(map[string]interface{}{
+		"status":         models.PaymentStatusVoided,
+		"failure_reason": "",
+	}).Error; err != nil {
+		return err
+	}
+
+	voided := *payment
+	store.AfterCommit(tx, func() {
+		if err := releaseHold(voided.Provider, voided.Reference, voided.Amount); err != nil {
+			if err := store.DB.Model(&voided).Update("failure_reason", "void failed: "+err.Error()).Error; err != nil {
+				log.Printf("Failed to record void failure of payment %d: %v", voided.ID, err)
+			}
+		}
+	})
+	return nil
+}
+
+// recordPaymentRefund adds amount to a locked captured payment's refunded
+// amount, marking it refunded once nothing captured is left
+func recordPaymentRefund(tx *gorm.DB, payment *models.Payment, amount int64) error {
+	updates := map[string]interface{}{
+		"refunded_amount":   payment.RefundedAmount.Amount + amount,
+		"refunded_currency": payment.CapturedAmount.Currency,
+	}
+	if amount == payment.Refundable().Amount {
+		updates["status"] = models.PaymentStatusRefunded
+	}
+	return tx.Model(payment).Updates(updates).Error
+}
+
+// applyPaymentEvent updates a locked payment from a provider webhook. Events
+// that do not fit the payment's current state are ignored, so out-of-order
+// deliveries cannot move a payment backwards.
+func applyPaymentEvent(tx *gorm.DB, payment *models.Payment, event *payments.WebhookEvent) error {
+	switch event.Type {
+	case payments.EventCaptured:
+		if !payment.CanCapture() {
+			return nil
+		}
+		return tx.Model(payment).Updates(map[string]interface{}{
+			"status":            models.PaymentStatusCaptured,
+			"captured_amount":   event.Amount.Amount,
+			"captured_currency": event.Amount.Currency,
+			"captured_at":       event.OccurredAt,
+		}).Error
+
+	case payments.EventRefunded:
+		// The event carries the provider's total refunded so far, so refunds
+		// already recorded by refundToPayment are not counted again
+		if payment.Status != models.PaymentStatusCaptured {
+			return nil
+		}
+		refunded := event.Amount.Amount
+		if refunded > payment.CapturedAmount.Amount {
+			refunded = payment.CapturedAmount.Amount
+		}
+		if refunded <= payment.RefundedAmount.Amount {
+			return nil
+		}
+		return recordPaymentRefund(tx, payment, refunded-payment.RefundedAmount.Amount)
+
+	case payments.EventVoided:
+		if !payment.CanCapture() {
+			return nil
+		}
+		return tx.Model(payment).Update("status", models.PaymentStatusVoided).Error
+
+	case payments.EventFailed:
+		if !payment.CanCapture() {
+			return nil
+		}
+		return tx.Model(payment).Updates(map[string]interface{}{
+			"status":         models.PaymentStatusFailed,
+			"failure_reason": "reported failed by provider",
+		}).Error
+	}
+
+	return nil
+}
+
+// PaymentWebhookHandler receives payment provider callbacks.
+//
+// Every verified event is persisted before it is applied, and the unique
+// (provider, event_id) index makes replays no-ops, so a redelivered event can
+// never capture or refund a payment twice.
+//
+// Route: POST /payments/webhook/:provider
+// Parameters: provider (path) - The payment provider name
+// Request body: Provider-specific signed payload
+// Response: 200 OK with {"received": true, "duplicate": bool}
+// Error responses: 400 if the payload is malformed, 401 if the signature is invalid,
+// 404 if the provider is unknown, 500 if database error
+func PaymentWebhookHandler(c *gin.Context) {
+	provider, exists := payments.Get(c.Param("provider"))
+	if !exists {
+		RespondWithError(c, NotFoundError("Payment provider"))
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Failed to read webhook body"))
+		return
+	}
+
+	event, err := provider.VerifyWebhook(body, c.Request.Header)
+	if errors.Is(err, payments.ErrInvalidSignature) {
+		HandleAppError(c, UnauthorizedErrorType{Message: "Invalid webhook signature"})
+		return
+	}
+	if err != nil {
+		RespondWithError(c, BadRequestError(err.Error()))
+		return
+	}
+
+	duplicate := false
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		record := models.PaymentWebhookEvent{
+			Provider:    provider.Name(),
+			EventID:     event.ID,
+			Type:        string(event.Type),
+			Payload:     string(body),
+			ProcessedAt: time.Now(),
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			duplicate = true
+			return nil
+		}
+
+		var payment models.Payment
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("provider = ? AND reference = ?", provider.Name(), event.Reference).
+			First(&payment).Error
+		if err == gorm.ErrRecordNotFound {
+			// Kept for auditing; there is nothing to apply it to
+			log.Printf("Payment webhook %s references unknown payment %s", event.ID, event.Reference)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&record).Update("payment_id", payment.ID).Error; err != nil {
+			return err
+		}
+
+		return applyPaymentEvent(tx, &payment, event)
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"received": true, "duplicate": duplicate})
+}
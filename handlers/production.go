@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"meals/config"
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// wantsCSV checks if a report was asked for as CSV with ?format=csv or an
+// Accept: text/csv header
+func wantsCSV(c *gin.Context) bool {
+	return c.Query("format") == "csv" || strings.Contains(c.GetHeader("Accept"), "text/csv")
+}
+
+// orderedPortions totals the portions of cooked orders per menu meal
+func orderedPortions(db *gorm.DB, menuMealIDs []uint) (map[uint]int, error) {
+	var rows []struct {
+		MenuMealID uint
+		Portions   int
+	}
+	err := db.Model(&models.OrderItem{}).
+		Select("order_items.menu_meal_id, SUM(order_items.quantity) AS portions").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("order_items.menu_meal_id IN ? AND orders.status IN ?", menuMealIDs, models.ProductionStatuses).
+		Group("order_items.menu_meal_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	portions := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		portions[row.MenuMealID] = row.Portions
+	}
+	return portions, nil
+}
+
+// buildProductionPlan plans the meals of a menu delivered on the given day
+// from its confirmed orders
+func buildProductionPlan(db *gorm.DB, menuID uint, deliveryDay string) (*models.ProductionPlan, error) {
+	var menu models.Menu
+	if err := db.Preload("MenuMeals").Preload("MenuMeals.Meal", func(db *gorm.DB) *gorm.DB {
+		// Meals deleted after they were ordered are still cooked
+		return db.Unscoped()
+	}).Preload("MenuMeals.Meal.Ingredients", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Preload("MenuMeals.Meal.Ingredients.Ingredient").First(&menu, menuID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NotFoundErrorType{Resource: "Menu"}
+		}
+		return nil, err
+	}
+
+	deliveryDate, err := menu.DeliveryDateFor(deliveryDay, config.AppConfig.Ordering.Location())
+	if err != nil {
+		return nil, ValidationErrorType{
+			Message: "Invalid delivery_day for this menu",
+			Details: map[string]interface{}{"delivery_day": deliveryDay, "error": err.Error()},
+		}
+	}
+
+	var menuMeals []models.MenuMeal
+	for i := range menu.MenuMeals {
+		date, _, err := menuMealSchedule(&menu.MenuMeals[i], &menu)
+		if err == nil && date.Equal(deliveryDate) {
+			menuMeals = append(menuMeals, menu.MenuMeals[i])
+		}
+	}
+
+	plan := &models.ProductionPlan{
+		MenuID:       menu.ID,
+		MenuName:     menu.Name,
+		DeliveryDate: models.CalendarDate(deliveryDate),
+	}
+	if len(menuMeals) == 0 {
+		plan.PlanMeals(nil, nil)
+		return plan, nil
+	}
+
+	menuMealIDs := make([]uint, 0, len(menuMeals))
+	for _, menuMeal := range menuMeals {
+		menuMealIDs = append(menuMealIDs, menuMeal.ID)
+	}
+	byMenuMeal, err := orderedPortions(db, menuMealIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// A meal offered more than once on the day is cooked as one
+	var meals []models.Meal
+	portions := make(map[uint]int)
+	for _, menuMeal := range menuMeals {
+		if _, listed := portions[menuMeal.MealID]; !listed {
+			meals = append(meals, menuMeal.Meal)
+		}
+		portions[menuMeal.MealID] += byMenuMeal[menuMeal.ID]
+	}
+
+	plan.PlanMeals(meals, portions)
+	return plan, nil
+}
+
+// writeProductionCSV writes a plan as one CSV table, with a row per meal
+// followed by a row per ingredient total
+func writeProductionCSV(c *gin.Context, plan *models.ProductionPlan) {
+	filename := fmt.Sprintf("production-%d-%s.csv", plan.MenuID, plan.DeliveryDate.Format("2006-01-02"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"section", "id", "name", "quantity", "unit"})
+	for _, meal := range plan.Meals {
+		_ = w.Write([]string{
+			"meal", strconv.FormatUint(uint64(meal.MealID), 10), meal.MealName,
+			strconv.Itoa(meal.Portions), "portion",
+		})
+	}
+	for _, total := range plan.Ingredients {
+		_ = w.Write([]string{
+			"ingredient", strconv.FormatUint(uint64(total.IngredientID), 10), total.Name,
+			strconv.FormatFloat(total.Quantity, 'f', -1, 64), total.Unit,
+		})
+	}
+	w.Flush()
+}
+
+// GetProductionPlanHandler reports what the kitchen cooks for a menu's delivery
+// day: the portions per meal from confirmed orders and the total quantity of
+// every ingredient they need.
+//
+// Route: GET /admin/production
+// Parameters: menu_id (query, required) - The menu ID
+// delivery_day (query, required) - Weekday name or date (YYYY-MM-DD) within the menu's week
+// format (query, optional) - "csv" for a CSV purchasing sheet, as does an Accept: text/csv header
+// Response: 200 OK with the ProductionPlan object or a CSV file
+// Error responses: 400 if invalid parameters, 401 if unauthorized, 403 if not an admin,
+// 404 if menu not found, 500 if database error
+func GetProductionPlanHandler(c *gin.Context) {
+	menuID, err := strconv.ParseUint(c.Query("menu_id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid menu_id, expected a menu ID"))
+		return
+	}
+	deliveryDay := strings.TrimSpace(c.Query("delivery_day"))
+	if deliveryDay == "" {
+		RespondWithError(c, BadRequestError("delivery_day is required"))
+		return
+	}
+
+	plan, err := buildProductionPlan(store.DB, uint(menuID), deliveryDay)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	if wantsCSV(c) {
+		writeProductionCSV(c, plan)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
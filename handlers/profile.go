@@ -4,22 +4,47 @@ import (
 	"meals/models"
 	"meals/store"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ProfileResponse represents the response for profile operations
 type ProfileResponse struct {
-	Profile models.UserProfile `json:"profile"`
+	Profile       models.UserProfile    `json:"profile"`
+	DriverProfile *models.DriverProfile `json:"driver_profile,omitempty"` // Only for drivers with a driver profile
 }
 
 // CreateProfileRequest represents the request body for creating a user profile
 type CreateProfileRequest struct {
 }
 
-// UpdateProfileRequest represents the request body for updating a user profile
+// UpdateProfileRequest represents the request body for updating a user
+// profile. Omitted fields keep their current value.
 type UpdateProfileRequest struct {
+	Diets     *[]string `json:"diets"`     // Diets followed, e.g. vegan or halal; empty clears them
+	Allergens *[]string `json:"allergens"` // Allergens declared, e.g. peanut or shellfish; empty clears them
+}
+
+// applyTo copies the request onto a profile and validates the result
+func (r *UpdateProfileRequest) applyTo(profile *models.UserProfile) error {
+	if r.Diets != nil {
+		profile.Diets = models.NormalizeLabels(*r.Diets)
+	}
+	if r.Allergens != nil {
+		profile.Allergens = models.NormalizeLabels(*r.Allergens)
+	}
+
+	var errs []string
+	errs = append(errs, models.ValidateLabels("Diets", profile.Diets, models.Diets)...)
+	errs = append(errs, models.ValidateLabels("Allergens", profile.Allergens, models.Allergens)...)
+	if len(errs) > 0 {
+		return ValidationErrorType{Message: "Invalid profile data", Details: errs}
+	}
+	return nil
 }
 
 // GetUserProfileHandler handles fetching the profile of the authenticated user
@@ -38,8 +63,10 @@ func GetUserProfileHandler(c *gin.Context) {
 	var profile models.UserProfile
 	db := store.GetTxFromContext(c)
 
-	// Find profile
-	result := db.Where("user_id = ?", userID).First(&profile)
+	// Find profile, with the default address first
+	result := db.Preload("Addresses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("is_default DESC").Order("id ASC")
+	}).Where("user_id = ?", userID).First(&profile)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			// If no profile exists, return empty but valid response
@@ -58,8 +85,18 @@ func GetUserProfileHandler(c *gin.Context) {
 		return
 	}
 
+	driver, err := findDriverProfile(db, profile.UserID)
+	if err != nil {
+		HandleAppError(c, DatabaseErrorType{
+			Message: "Failed to fetch driver profile",
+			Details: err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, ProfileResponse{
-		Profile: profile,
+		Profile:       profile,
+		DriverProfile: driver,
 	})
 }
 
@@ -103,6 +140,10 @@ func CreateOrUpdateProfileHandler(c *gin.Context) {
 			}
 		}
 
+		if err := req.applyTo(&profile); err != nil {
+			return err
+		}
+
 		// Save profile
 		if isNew {
 			if err := tx.Create(&profile).Error; err != nil {
@@ -142,11 +183,89 @@ func CreateOrUpdateProfileHandler(c *gin.Context) {
 	})
 }
 
-// ListAddressesHandler removed - Address model no longer exists
+// DriverProfileRequest represents the request body for updating a driver
+// profile. Omitted fields keep their current value.
+type DriverProfileRequest struct {
+	IsAvailable      *bool                `json:"is_available"` // Shorthand for status available or offline
+	Status           *models.DriverStatus `json:"status"`
+	VehicleType      *models.VehicleType  `json:"vehicle_type"`
+	VehicleMake      *string              `json:"vehicle_make"`
+	VehicleModel     *string              `json:"vehicle_model"`
+	LicensePlate     *string              `json:"license_plate"`
+	LicenseNumber    *string              `json:"license_number"`
+	LicenseExpiresOn *string              `json:"license_expires_on"` // YYYY-MM-DD; empty clears it
+	HomeZone         *string              `json:"home_zone"`
+}
 
-// CreateAddressHandler removed - Address model no longer exists
+// applyTo copies the fields set in the request onto a driver profile
+func (r *DriverProfileRequest) applyTo(driver *models.DriverProfile) error {
+	if r.IsAvailable != nil {
+		driver.Status = models.DriverStatusOffline
+		if *r.IsAvailable {
+			driver.Status = models.DriverStatusAvailable
+		}
+	}
+	if r.Status != nil {
+		driver.Status = *r.Status
+	}
+	if r.VehicleType != nil {
+		driver.VehicleType = models.VehicleType(strings.ToLower(strings.TrimSpace(string(*r.VehicleType))))
+	}
+	if r.VehicleMake != nil {
+		driver.VehicleMake = strings.TrimSpace(*r.VehicleMake)
+	}
+	if r.VehicleModel != nil {
+		driver.VehicleModel = strings.TrimSpace(*r.VehicleModel)
+	}
+	if r.LicensePlate != nil {
+		driver.LicensePlate = strings.ToUpper(strings.TrimSpace(*r.LicensePlate))
+	}
+	if r.LicenseNumber != nil {
+		driver.LicenseNumber = strings.TrimSpace(*r.LicenseNumber)
+	}
+	if r.LicenseExpiresOn != nil {
+		driver.LicenseExpiresOn = nil
+		if *r.LicenseExpiresOn != "" {
+			expires, err := time.Parse("2006-01-02", *r.LicenseExpiresOn)
+			if err != nil {
+				return ValidationErrorType{
+					Message: "Invalid license expiry date, expected YYYY-MM-DD",
+					Details: map[string]interface{}{"license_expires_on": *r.LicenseExpiresOn},
+				}
+			}
+			driver.LicenseExpiresOn = &expires
+		}
+	}
+	if r.HomeZone != nil {
+		driver.HomeZone = strings.ToLower(strings.TrimSpace(*r.HomeZone))
+	}
+	return nil
+}
 
-// SetDriverProfileHandler handles updating driver-specific profile data
+// findDriverProfile loads a user's driver profile, returning nil if they have none
+func findDriverProfile(db *gorm.DB, userID uint) (*models.DriverProfile, error) {
+	var driver models.DriverProfile
+	err := db.Where("user_id = ?", userID).First(&driver).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &driver, nil
+}
+
+// SetDriverProfileHandler creates or updates the authenticated driver's
+// vehicle, license, availability and home zone.
+//
+// Drivers can only be available with a vehicle and, unless they ride a
+// bicycle, a license that has not expired.
+//
+// Route: PUT /profile/driver
+// Request body: JSON with any of is_available, status, vehicle_type, vehicle_make, vehicle_model,
+// license_plate, license_number, license_expires_on and home_zone
+// Response: 200 OK with the profile and driver profile
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not a driver or admin, 500 if database error
 func SetDriverProfileHandler(c *gin.Context) {
 	// Get user ID from context
 	userID, exists := c.Get("userID")
@@ -170,12 +289,7 @@ func SetDriverProfileHandler(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		IsAvailable   *bool   `json:"is_available"`
-		VehicleType   *string `json:"vehicle_type"`
-		LicenseNumber *string `json:"license_number"`
-	}
-
+	var req DriverProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		RespondWithError(c, ValidationError("Invalid request data", err.Error()))
 		return
@@ -205,8 +319,48 @@ func SetDriverProfileHandler(c *gin.Context) {
 			}
 		}
 
-		// Save profile
-		if err := tx.Save(&profile).Error; err != nil {
+		// Get or start the driver profile; new drivers are offline until they say otherwise
+		driver, err := findDriverProfile(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID.(uint))
+		if err != nil {
+			return DatabaseErrorType{
+				Message: "Failed to check for existing driver profile",
+				Details: err.Error(),
+			}
+		}
+		if driver == nil {
+			driver = &models.DriverProfile{
+				UserID: userID.(uint),
+				Status: models.DriverStatusOffline,
+			}
+		}
+
+		if err := req.applyTo(driver); err != nil {
+			return err
+		}
+
+		errs := driver.ValidateDriverProfile()
+		if driver.HomeZone != "" {
+			known, err := isDeliveryZone(tx, driver.HomeZone)
+			if err != nil {
+				return err
+			}
+			if !known {
+				errs = append(errs, "HomeZone is not a delivery zone")
+			}
+		}
+		if driver.Status == models.DriverStatusAvailable && driver.LicenseExpiresOn != nil &&
+			!driver.LicenseValidOn(time.Now()) {
+			errs = append(errs, "License has expired")
+		}
+		if len(errs) > 0 {
+			return ValidationErrorType{
+				Message: "Invalid driver profile data",
+				Details: errs,
+			}
+		}
+
+		// Save driver profile
+		if err := tx.Save(driver).Error; err != nil {
 			return DatabaseErrorType{
 				Message: "Failed to update driver profile",
 				Details: err.Error(),
@@ -230,7 +384,17 @@ func SetDriverProfileHandler(c *gin.Context) {
 		return
 	}
 
+	driver, err := findDriverProfile(store.DB, userID.(uint))
+	if err != nil {
+		HandleAppError(c, DatabaseErrorType{
+			Message: "Failed to fetch updated driver profile",
+			Details: err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, ProfileResponse{
-		Profile: updatedProfile,
+		Profile:       updatedProfile,
+		DriverProfile: driver,
 	})
 }
@@ -0,0 +1,490 @@
+package handlers
+
+import (
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PromoCodeRequest represents the request body for creating or updating a promo code
+type PromoCodeRequest struct {
+	Code           string              `json:"code" binding:"required"`
+	Description    string              `json:"description"`
+	DiscountType   models.DiscountType `json:"discount_type" binding:"required"`
+	PercentOff     int                 `json:"percent_off"`
+	AmountOff      models.Money        `json:"amount_off"`
+	MinOrderValue  models.Money        `json:"min_order_value"`
+	FirstOrderOnly bool                `json:"first_order_only"`
+	MaxUsesPerUser int                 `json:"max_uses_per_user"`
+	MaxUses        int                 `json:"max_uses"`
+	StartsAt       *time.Time          `json:"starts_at"`
+	EndsAt         *time.Time          `json:"ends_at"`
+	Active         *bool               `json:"active"` // Defaults to true
+	MealIDs        []uint              `json:"meal_ids"`
+	MenuIDs        []uint              `json:"menu_ids"`
+}
+
+// applyTo copies the request onto a promo code, normalizing the code and amounts
+func (r *PromoCodeRequest) applyTo(promo *models.PromoCode) {
+	promo.Code = models.NormalizePromoCode(r.Code)
+	promo.Description = r.Description
+	promo.DiscountType = r.DiscountType
+	promo.PercentOff = r.PercentOff
+	promo.AmountOff = models.NewMoney(r.AmountOff.Amount, r.AmountOff.Currency)
+	promo.MinOrderValue = models.NewMoney(r.MinOrderValue.Amount, r.MinOrderValue.Currency)
+	promo.FirstOrderOnly = r.FirstOrderOnly
+	promo.MaxUsesPerUser = r.MaxUsesPerUser
+	promo.MaxUses = r.MaxUses
+	promo.StartsAt = r.StartsAt
+	promo.EndsAt = r.EndsAt
+	promo.Active = r.Active == nil || *r.Active
+	promo.MealIDs = r.MealIDs
+	promo.MenuIDs = r.MenuIDs
+
+	if promo.DiscountType == models.DiscountTypePercentage {
+		promo.AmountOff = models.NewMoney(0, promo.AmountOff.Currency)
+	} else {
+		promo.PercentOff = 0
+	}
+}
+
+// checkPromoCodeReferences verifies that the meals and menus a code is
+// restricted to exist and that no other code uses the same name
+func checkPromoCodeReferences(tx *gorm.DB, promo *models.PromoCode) error {
+	// Deleted codes keep their name so past orders stay unambiguous
+	var taken int64
+	if err := tx.Unscoped().Model(&models.PromoCode{}).
+		Where("code = ? AND id <> ?", promo.Code, promo.ID).
+		Count(&taken).Error; err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ConflictErrorType{
+			Code:    ErrResourceExists,
+			Message: "Promo code " + promo.Code + " already exists",
+			Details: map[string]interface{}{"code": promo.Code},
+		}
+	}
+
+	references := []struct {
+		name  string
+		model interface{}
+		ids   []uint
+	}{
+		{"meal_ids", &models.Meal{}, promo.MealIDs},
+		{"menu_ids", &models.Menu{}, promo.MenuIDs},
+	}
+	for _, ref := range references {
+		if len(ref.ids) == 0 {
+			continue
+		}
+		var found int64
+		if err := tx.Model(ref.model).Where("id IN ?", ref.ids).Count(&found).Error; err != nil {
+			return err
+		}
+		if int(found) != len(uniqueIDs(ref.ids)) {
+			return RelationshipErrorType{
+				Message: "One or more " + ref.name + " do not exist",
+				Details: map[string]interface{}{ref.name: ref.ids},
+			}
+		}
+	}
+
+	return nil
+}
+
+// uniqueIDs returns ids without duplicates
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// lockPromoCode loads a promo code by its code with a row lock, so concurrent
+// checkouts are serialized on the code's usage count
+func lockPromoCode(tx *gorm.DB, code string) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("code = ?", models.NormalizePromoCode(code)).
+		First(&promo).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, promoCodeError(code, "Promo code does not exist")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+// promoCodeError reports why a promo code cannot be used on an order
+func promoCodeError(code, message string) ValidationErrorType {
+	return ValidationErrorType{
+		Message: message,
+		Details: map[string]interface{}{"promo_code": models.NormalizePromoCode(code)},
+	}
+}
+
+// checkPromoCodeUse verifies that the order's customer may redeem the code now
+func checkPromoCodeUse(tx *gorm.DB, promo *models.PromoCode, order *models.Order, now time.Time) error {
+	if !promo.IsAvailableAt(now) {
+		return promoCodeError(promo.Code, "Promo code is not available")
+	}
+
+	if promo.FirstOrderOnly {
+		var previous int64
+		if err := tx.Model(&models.Order{}).
+			Where("user_id = ? AND id <> ? AND status NOT IN ?", order.UserID, order.ID,
+				[]models.OrderStatus{models.OrderStatusDraft, models.OrderStatusCancelled}).
+			Count(&previous).Error; err != nil {
+			return err
+		}
+		if previous > 0 {
+			return promoCodeError(promo.Code, "Promo code is only valid on a first order")
+		}
+	}
+
+	if promo.MaxUsesPerUser > 0 {
+		var used int64
+		if err := tx.Model(&models.PromoRedemption{}).
+			Where("promo_code_id = ? AND user_id = ?", promo.ID, order.UserID).
+			Count(&used).Error; err != nil {
+			return err
+		}
+		if int(used) >= promo.MaxUsesPerUser {
+			return promoCodeError(promo.Code, "Promo code has already been used the maximum number of times")
+		}
+	}
+
+	return nil
+}
+
+// promoDiscount computes the code's discount on the order's priced items
+func promoDiscount(tx *gorm.DB, promo *models.PromoCode, order *models.Order) (models.Money, error) {
+	currency := order.Subtotal.Currency
+	if promo.DiscountType == models.DiscountTypeFixed && promo.AmountOff.Currency != currency {
+		return models.Money{}, promoCodeError(promo.Code, "Promo code is not valid for orders in "+currency)
+	}
+
+	if !promo.MinOrderValue.IsZero() {
+		if promo.MinOrderValue.Currency != currency {
+			return models.Money{}, promoCodeError(promo.Code, "Promo code is not valid for orders in "+currency)
+		}
+		if order.Subtotal.Amount < promo.MinOrderValue.Amount {
+			return models.Money{}, ValidationErrorType{
+				Message: "Order subtotal is below the promo code minimum of " + promo.MinOrderValue.String(),
+				Details: map[string]interface{}{
+					"promo_code":      promo.Code,
+					"min_order_value": promo.MinOrderValue,
+				},
+			}
+		}
+	}
+
+	// Lines are matched on the menu their menu meal belongs to
+	menuMealIDs := make([]uint, 0, len(order.Items))
+	for _, item := range order.Items {
+		menuMealIDs = append(menuMealIDs, item.MenuMealID)
+	}
+	var menuMeals []models.MenuMeal
+	if err := tx.Select("id", "menu_id").Where("id IN ?", menuMealIDs).Find(&menuMeals).Error; err != nil {
+		return models.Money{}, err
+	}
+	menuByMenuMeal := make(map[uint]uint, len(menuMeals))
+	for _, menuMeal := range menuMeals {
+		menuByMenuMeal[menuMeal.ID] = menuMeal.MenuID
+	}
+
+	eligible := models.NewMoney(0, currency)
+	for _, item := range order.Items {
+		if promo.AppliesTo(item.MealID, menuByMenuMeal[item.MenuMealID]) {
+			eligible = eligible.Add(item.LineTotal)
+		}
+	}
+
+	discount := promo.Discount(eligible)
+	if discount.IsZero() {
+		return models.Money{}, promoCodeError(promo.Code, "Promo code does not apply to any items in this order")
+	}
+	return discount, nil
+}
+
+// applyPromoCode validates order.PromoCode for a new order and discounts it.
+// The returned code is locked until tx ends and must be redeemed with
+// redeemPromoCode once the order has been created.
+func applyPromoCode(tx *gorm.DB, order *models.Order, now time.Time) (*models.PromoCode, error) {
+	promo, err := lockPromoCode(tx, order.PromoCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPromoCodeUse(tx, promo, order, now); err != nil {
+		return nil, err
+	}
+
+	discount, err := promoDiscount(tx, promo, order)
+	if err != nil {
+		return nil, err
+	}
+
+	order.PromoCodeID = &promo.ID
+	order.PromoCode = promo.Code
+	order.ApplyDiscount(discount)
+	if err := priceOrder(tx, order); err != nil {
+		return nil, err
+	}
+	return promo, nil
+}
+
+// redeemPromoCode records the use of a locked promo code by a created order
+func redeemPromoCode(tx *gorm.DB, promo *models.PromoCode, order *models.Order) error {
+	redemption := models.PromoRedemption{
+		PromoCodeID: promo.ID,
+		UserID:      order.UserID,
+		OrderID:     order.ID,
+		Discount:    order.Discount,
+	}
+	if err := tx.Create(&redemption).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(promo).Update("usage_count", gorm.Expr("usage_count + 1")).Error
+}
+
+// repricePromoDiscount recomputes the discount of an order whose items changed.
+// Usage limits were checked when the code was redeemed, but the edited order
+// must still meet the code's minimum and contain eligible items.
+func repricePromoDiscount(tx *gorm.DB, order *models.Order) error {
+	if order.PromoCodeID == nil {
+		return nil
+	}
+
+	// Codes deleted after they were redeemed still apply to their orders
+	var promo models.PromoCode
+	if err := tx.Unscoped().First(&promo, *order.PromoCodeID).Error; err != nil {
+		return err
+	}
+
+	discount, err := promoDiscount(tx, &promo, order)
+	if err != nil {
+		return err
+	}
+	order.ApplyDiscount(discount)
+	if err := priceOrder(tx, order); err != nil {
+		return err
+	}
+
+	return tx.Model(&models.PromoRedemption{}).Where("order_id = ?", order.ID).Updates(map[string]interface{}{
+		"discount_amount":   discount.Amount,
+		"discount_currency": discount.Currency,
+	}).Error
+}
+
+// releasePromoCode gives the promo code use of a cancelled order back
+func releasePromoCode(tx *gorm.DB, order *models.Order) error {
+	if order.PromoCodeID == nil {
+		return nil
+	}
+
+	result := tx.Where("order_id = ?", order.ID).Delete(&models.PromoRedemption{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	return tx.Model(&models.PromoCode{}).Unscoped().
+		Where("id = ? AND usage_count > 0", *order.PromoCodeID).
+		Update("usage_count", gorm.Expr("usage_count - 1")).Error
+}
+
+// parsePromoCodeID parses the :id path parameter or responds with 400
+func parsePromoCodeID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid promo code ID format"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// CreatePromoCodeHandler creates a promo code.
+//
+// Route: POST /admin/promo-codes
+// Request body: JSON with code, discount_type (percentage with percent_off, or fixed with amount_off)
+// and optional min_order_value, first_order_only, max_uses_per_user, max_uses, starts_at, ends_at,
+// active, meal_ids and menu_ids
+// Response: 201 Created with the PromoCode object
+// Error responses: 400 if invalid data or unknown meals/menus, 401 if unauthorized, 403 if not an admin,
+// 409 if the code already exists, 500 if database error
+func CreatePromoCodeHandler(c *gin.Context) {
+	var req PromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid promo code data", err.Error()))
+		return
+	}
+
+	var promo models.PromoCode
+	req.applyTo(&promo)
+	if errs := promo.ValidatePromoCode(); len(errs) > 0 {
+		RespondWithError(c, ValidationError("Invalid promo code data", errs))
+		return
+	}
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		if err := checkPromoCodeReferences(tx, &promo); err != nil {
+			return err
+		}
+		return tx.Create(&promo).Error
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, promo)
+}
+
+// GetPromoCodesHandler lists all promo codes, newest first.
+//
+// Route: GET /admin/promo-codes
+// Parameters: active (query, optional) - "true" or "false" to filter by the active flag
+// Response: 200 OK with array of PromoCode objects
+// Error responses: 401 if unauthorized, 403 if not an admin, 500 if database error
+func GetPromoCodesHandler(c *gin.Context) {
+	query := store.DB.Order("created_at DESC")
+	if active := c.Query("active"); active != "" {
+		value, err := strconv.ParseBool(active)
+		if err != nil {
+			RespondWithError(c, BadRequestError("Invalid active filter, expected true or false"))
+			return
+		}
+		query = query.Where("active = ?", value)
+	}
+
+	var promos []models.PromoCode
+	if err := query.Find(&promos).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve promo codes"))
+		return
+	}
+
+	c.JSON(http.StatusOK, promos)
+}
+
+// GetPromoCodeHandler retrieves a promo code by ID.
+//
+// Route: GET /admin/promo-codes/:id
+// Parameters: id (path) - The promo code ID
+// Response: 200 OK with the PromoCode object
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not an admin, 404 if not found,
+// 500 if database error
+func GetPromoCodeHandler(c *gin.Context) {
+	id, ok := parsePromoCodeID(c)
+	if !ok {
+		return
+	}
+
+	var promo models.PromoCode
+	if err := store.DB.First(&promo, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			RespondWithError(c, NotFoundError("Promo code"))
+		} else {
+			RespondWithError(c, DatabaseError("Failed to retrieve promo code"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
+
+// UpdatePromoCodeHandler replaces the settings of a promo code. The usage count
+// is kept, and orders that already redeemed the code are not affected.
+//
+// Route: PUT /admin/promo-codes/:id
+// Parameters: id (path) - The promo code ID
+// Request body: Same as POST /admin/promo-codes
+// Response: 200 OK with the updated PromoCode object
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not an admin, 404 if not found,
+// 409 if the new code already exists, 500 if database error
+func UpdatePromoCodeHandler(c *gin.Context) {
+	id, ok := parsePromoCodeID(c)
+	if !ok {
+		return
+	}
+
+	var req PromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid promo code data", err.Error()))
+		return
+	}
+
+	var promo models.PromoCode
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&promo, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return NotFoundErrorType{Resource: "Promo code"}
+			}
+			return err
+		}
+
+		req.applyTo(&promo)
+		if errs := promo.ValidatePromoCode(); len(errs) > 0 {
+			return ValidationErrorType{Message: "Invalid promo code data", Details: errs}
+		}
+
+		if err := checkPromoCodeReferences(tx, &promo); err != nil {
+			return err
+		}
+
+		// Save writes every field, including cleared restrictions and false flags
+		return tx.Save(&promo).Error
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
+
+// DeletePromoCodeHandler soft deletes a promo code so it can no longer be
+// redeemed. Orders that already used it keep their discount.
+//
+// Route: DELETE /admin/promo-codes/:id
+// Parameters: id (path) - The promo code ID
+// Response: 200 OK with success message
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not an admin, 404 if not found,
+// 500 if database error
+func DeletePromoCodeHandler(c *gin.Context) {
+	id, ok := parsePromoCodeID(c)
+	if !ok {
+		return
+	}
+
+	var rowsAffected int64
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		result := tx.Delete(&models.PromoCode{}, id)
+		rowsAffected = result.RowsAffected
+		return result.Error
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	if rowsAffected == 0 {
+		RespondWithError(c, NotFoundError("Promo code"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promo code successfully deleted"})
+}
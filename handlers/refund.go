@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"meals/models"
+	"meals/payments"
+	"meals/store"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RefundRequest represents the request body for refunding an order
+type RefundRequest struct {
+	Amount *models.Money       `json:"amount"` // Defaults to everything not refunded yet
+	Method models.RefundMethod `json:"method" binding:"required"`
+	Reason string              `json:"reason" binding:"required"`
+}
+
+// refundedAmount sums the refunds already issued on an order
+func refundedAmount(tx *gorm.DB, order *models.Order) (models.Money, error) {
+	var refunds []models.Refund
+	if err := tx.Where("order_id = ?", order.ID).Find(&refunds).Error; err != nil {
+		return models.Money{}, err
+	}
+
+	refunded := models.NewMoney(0, order.Total.Currency)
+	for _, refund := range refunds {
+		refunded = refunded.Add(refund.Amount)
+	}
+	return refunded, nil
+}
+
+// refundToPayment returns amount to the order's captured payment
+func refundToPayment(tx *gorm.DB, order *models.Order, amount models.Money) (*models.Payment, error) {
+	payment, err := lockOrderPayment(tx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	refundable := models.NewMoney(0, amount.Currency)
+	if payment != nil {
+		refundable = payment.Refundable()
+	}
+	if amount.Amount > refundable.Amount {
+		return nil, ValidationErrorType{
+			Message: "Amount exceeds what can be refunded to the original payment",
+			Details: map[string]interface{}{"refundable": refundable},
+		}
+	}
+
+	provider, err := paymentProvider(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := provider.Refund(tx.Statement.Context, payment.Reference, amount); err != nil {
+		if errors.Is(err, payments.ErrInvalidState) {
+			return nil, ConflictErrorType{
+				Message: "The payment provider cannot refund this payment",
+				Details: map[string]interface{}{"payment_id": payment.ID},
+			}
+		}
+		return nil, fmt.Errorf("refund payment: %w", err)
+	}
+
+	return payment, recordPaymentRefund(tx, payment, amount.Amount)
+}
+
+// CreateRefundHandler refunds all or part of a delivered order.
+//
+// Refunds go back to the order's payment or to the customer's store credit.
+// Together they never exceed the order total, and refunds to the payment
+// never exceed what was captured. Store credit refunds are appended to the
+// customer's ledger in the same transaction.
+//
+// Route: POST /admin/orders/:id/refunds
+// Parameters: id (path) - The order ID
+// Request body: JSON with method (original or credit), reason and optional amount
+// Response: 201 Created with the Refund object
+// Error responses: 400 if invalid data or the amount exceeds what is refundable, 401 if unauthorized,
+// 403 if not an admin, 404 if order not found, 409 if the order is not delivered, 500 if database error
+func CreateRefundHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid order ID format"))
+		return
+	}
+
+	var req RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid refund data", err.Error()))
+		return
+	}
+	if !req.Method.IsValid() {
+		RespondWithError(c, ValidationError("Invalid refund data", []string{"Method must be original or credit"}))
+		return
+	}
+
+	actor := actorFromContext(c)
+	var refund models.Refund
+
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		order, err := lockOrder(tx, uint(id))
+		if err != nil {
+			return err
+		}
+
+		if !order.IsRefundable() {
+			return ConflictErrorType{
+				Message: "Only " + string(models.OrderStatusDelivered) + " orders can be refunded; cancel the order instead",
+				Details: map[string]interface{}{"status": order.Status},
+			}
+		}
+
+		refunded, err := refundedAmount(tx, order)
+		if err != nil {
+			return err
+		}
+		remaining := order.Total.Sub(refunded)
+
+		amount := remaining
+		if req.Amount != nil {
+			amount = models.NewMoney(req.Amount.Amount, req.Amount.Currency)
+		}
+		if amount.Currency != order.Total.Currency || amount.Amount <= 0 || amount.Amount > remaining.Amount {
+			return ValidationErrorType{
+				Message: "Amount must be positive and at most the amount not refunded yet",
+				Details: map[string]interface{}{"refundable": remaining},
+			}
+		}
+
+		refund = models.Refund{
+			OrderID: order.ID,
+			Method:  req.Method,
+			Amount:  amount,
+			Reason:  req.Reason,
+			ActorID: actor.UserID,
+		}
+
+		if req.Method == models.RefundMethodOriginal {
+			payment, err := refundToPayment(tx, order, amount)
+			if err != nil {
+				return err
+			}
+			refund.PaymentID = &payment.ID
+		}
+
+		if err := tx.Create(&refund).Error; err != nil {
+			return err
+		}
+
+		if req.Method == models.RefundMethodCredit {
+			return appendLedgerEntry(tx, &models.LedgerEntry{
+				UserID:   order.UserID,
+				Kind:     models.LedgerEntryRefund,
+				Amount:   amount,
+				OrderID:  &order.ID,
+				RefundID: &refund.ID,
+				ActorID:  actor.UserID,
+				Note:     req.Reason,
+			})
+		}
+		return nil
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, refund)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"meals/config"
+	"meals/models"
+	"time"
+)
+
+// menuMealSchedule resolves a menu meal to its concrete delivery date and the
+// moment ordering for that date closes
+func menuMealSchedule(menuMeal *models.MenuMeal, menu *models.Menu) (deliveryDate time.Time, cutoff time.Time, err error) {
+	ordering := config.AppConfig.Ordering
+
+	deliveryDate, err = menu.DeliveryDateFor(menuMeal.DeliveryDay, ordering.Location())
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return deliveryDate, ordering.CutoffFor(deliveryDate), nil
+}
+
+// annotateMenuSchedule fills in the delivery date and ordering cutoff of every
+// meal on the menu. Meals whose delivery day cannot be resolved are left blank.
+func annotateMenuSchedule(menu *models.Menu) {
+	for i := range menu.MenuMeals {
+		deliveryDate, cutoff, err := menuMealSchedule(&menu.MenuMeals[i], menu)
+		if err != nil {
+			continue
+		}
+		menu.MenuMeals[i].DeliveryDate = &deliveryDate
+		menu.MenuMeals[i].OrderCutoff = &cutoff
+	}
+}
+
+// resolveOrderDeliveryDate resolves every menu meal (with its Menu preloaded) to
+// a delivery date, requiring a single shared date that is still open for ordering
+func resolveOrderDeliveryDate(menuMeals []models.MenuMeal, now time.Time) (time.Time, error) {
+	var deliveryDate time.Time
+
+	for i := range menuMeals {
+		date, cutoff, err := menuMealSchedule(&menuMeals[i], &menuMeals[i].Menu)
+		if err != nil {
+			return time.Time{}, ValidationErrorType{
+				Message: "Menu meal has no valid delivery date",
+				Details: map[string]interface{}{
+					"menu_meal_id": menuMeals[i].ID,
+					"error":        err.Error(),
+				},
+			}
+		}
+
+		if !now.Before(cutoff) {
+			return time.Time{}, ValidationErrorType{
+				Message: "Ordering for " + date.Format("Monday, January 2") + " has closed",
+				Details: map[string]interface{}{
+					"menu_meal_id":  menuMeals[i].ID,
+					"delivery_date": date.Format("2006-01-02"),
+					"cutoff":        cutoff,
+				},
+			}
+		}
+
+		if deliveryDate.IsZero() {
+			deliveryDate = date
+		} else if !deliveryDate.Equal(date) {
+			return time.Time{}, ValidationErrorType{
+				Message: "All items in an order must be delivered on the same day",
+				Details: map[string]interface{}{
+					"delivery_dates": []string{deliveryDate.Format("2006-01-02"), date.Format("2006-01-02")},
+				},
+			}
+		}
+	}
+
+	return models.CalendarDate(deliveryDate), nil
+}
+
+// orderCutoff returns the moment an existing order stops being editable
+func orderCutoff(order *models.Order) time.Time {
+	return config.AppConfig.Ordering.CutoffFor(order.DeliveryDate)
+}
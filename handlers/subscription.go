@@ -0,0 +1,509 @@
+package handlers
+
+import (
+	"meals/models"
+	"meals/store"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SubscriptionRequest represents the request body for creating or updating a subscription
+type SubscriptionRequest struct {
+	MealsPerWeek int                 `json:"meals_per_week" binding:"required,min=1"`
+	DeliveryDays []string            `json:"delivery_days" binding:"required,min=1"`
+	DietaryRules models.DietaryRules `json:"dietary_rules"`
+}
+
+// PauseSubscriptionRequest represents the request body for pausing a subscription
+type PauseSubscriptionRequest struct {
+	Until string `json:"until"` // Optional last paused day (YYYY-MM-DD)
+}
+
+// SkipWeekRequest represents the request body for skipping a week
+type SkipWeekRequest struct {
+	WeekStartDate string `json:"week_start_date" binding:"required"` // Any day in the week to skip (YYYY-MM-DD)
+}
+
+// parseDate parses a YYYY-MM-DD date from a request
+func parseDate(field, value string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, ValidationErrorType{
+			Message: "Invalid " + field + ", expected YYYY-MM-DD",
+			Details: map[string]interface{}{field: value},
+		}
+	}
+	return date, nil
+}
+
+// parseSubscriptionID parses the :id path parameter or responds with 400
+func parseSubscriptionID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid subscription ID format"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// lockSubscription loads a subscription owned by userID with a row lock and its skips preloaded
+func lockSubscription(tx *gorm.DB, id, userID uint) (*models.Subscription, error) {
+	var subscription models.Subscription
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		First(&subscription, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, NotFoundErrorType{Resource: "Subscription"}
+		}
+		return nil, err
+	}
+
+	if err := tx.Where("subscription_id = ?", subscription.ID).Find(&subscription.Skips).Error; err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// cancelSubscriptionDrafts cancels the subscription's draft orders delivered on
+// or after from and, if to is not zero, before to
+func cancelSubscriptionDrafts(tx *gorm.DB, subscriptionID uint, from, to time.Time, actor orderActor, note string) error {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("subscription_id = ? AND status = ? AND delivery_date >= ?",
+			subscriptionID, models.OrderStatusDraft, models.CalendarDate(from))
+	if !to.IsZero() {
+		query = query.Where("delivery_date < ?", models.CalendarDate(to))
+	}
+
+	var drafts []models.Order
+	if err := query.Find(&drafts).Error; err != nil {
+		return err
+	}
+
+	for i := range drafts {
+		if err := applyTransition(tx, &drafts[i], models.OrderStatusCancelled, actor, note); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// respondWithSubscription reloads a subscription with its skips and writes it to the response
+func respondWithSubscription(c *gin.Context, status int, id uint) {
+	var subscription models.Subscription
+	if err := store.DB.Preload("Skips").First(&subscription, id).Error; err != nil {
+		HandleAppError(c, err)
+		return
+	}
+	c.JSON(status, subscription)
+}
+
+// CreateSubscriptionHandler subscribes the authenticated customer to weekly meals.
+//
+// Draft orders are generated from every published menu from now on. Menus that
+// are already published and still open are backfilled immediately.
+//
+// Route: POST /subscriptions
+// Request body: JSON with meals_per_week, delivery_days and optional dietary_rules
+// Response: 201 Created with the Subscription object
+// Error responses: 400 if invalid data, 401 if unauthorized, 500 if database error
+func CreateSubscriptionHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid subscription data", err.Error()))
+		return
+	}
+
+	subscription := models.Subscription{
+		UserID:       userID,
+		Status:       models.SubscriptionStatusActive,
+		MealsPerWeek: req.MealsPerWeek,
+		DeliveryDays: req.DeliveryDays,
+		DietaryRules: req.DietaryRules,
+	}
+
+	if errs := subscription.ValidateSubscription(); len(errs) > 0 {
+		RespondWithError(c, ValidationError("Invalid subscription data", errs))
+		return
+	}
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		if err := tx.Create(&subscription).Error; err != nil {
+			return err
+		}
+		return backfillSubscriptionDrafts(tx, &subscription, time.Now())
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	respondWithSubscription(c, http.StatusCreated, subscription.ID)
+}
+
+// GetSubscriptionsHandler lists the authenticated customer's subscriptions.
+//
+// Route: GET /subscriptions
+// Response: 200 OK with array of Subscription objects
+// Error responses: 401 if unauthorized, 500 if database error
+func GetSubscriptionsHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var subscriptions []models.Subscription
+	if err := store.DB.Preload("Skips").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&subscriptions).Error; err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve subscriptions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// UpdateSubscriptionHandler changes the meal count, delivery days and dietary
+// rules of a subscription. Changes apply to menus published afterwards; drafts
+// that were already generated can be edited through PUT /orders/:id.
+//
+// Route: PUT /subscriptions/:id
+// Parameters: id (path) - The subscription ID
+// Request body: JSON with meals_per_week, delivery_days and optional dietary_rules
+// Response: 200 OK with the updated Subscription object
+// Error responses: 400 if invalid data, 401 if unauthorized, 404 if subscription not found,
+// 409 if the subscription is cancelled, 500 if database error
+func UpdateSubscriptionHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseSubscriptionID(c)
+	if !ok {
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid subscription data", err.Error()))
+		return
+	}
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		subscription, err := lockSubscription(tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		if subscription.Status == models.SubscriptionStatusCancelled {
+			return ConflictErrorType{Message: "Cancelled subscriptions cannot be changed"}
+		}
+
+		subscription.MealsPerWeek = req.MealsPerWeek
+		subscription.DeliveryDays = req.DeliveryDays
+		subscription.DietaryRules = req.DietaryRules
+
+		if errs := subscription.ValidateSubscription(); len(errs) > 0 {
+			return ValidationErrorType{
+				Message: "Invalid subscription data",
+				Details: errs,
+			}
+		}
+
+		return tx.Model(subscription).Select("meals_per_week", "delivery_days", "dietary_rules").Updates(subscription).Error
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	respondWithSubscription(c, http.StatusOK, id)
+}
+
+// PauseSubscriptionHandler pauses a subscription, optionally until a given
+// day. Pending draft orders in the paused period are cancelled; orders that
+// were already placed are kept.
+//
+// Route: POST /subscriptions/:id/pause
+// Parameters: id (path) - The subscription ID
+// Request body: optional JSON with until (YYYY-MM-DD, last paused day)
+// Response: 200 OK with the updated Subscription object
+// Error responses: 400 if invalid data, 401 if unauthorized, 404 if subscription not found,
+// 409 if the subscription is cancelled, 500 if database error
+func PauseSubscriptionHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseSubscriptionID(c)
+	if !ok {
+		return
+	}
+
+	var req PauseSubscriptionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondWithError(c, ValidationError("Invalid pause data", err.Error()))
+			return
+		}
+	}
+
+	var until *time.Time
+	var resumeOn time.Time
+	if req.Until != "" {
+		date, err := parseDate("until", req.Until)
+		if HandleAppError(c, err) {
+			return
+		}
+		until = &date
+		resumeOn = date.AddDate(0, 0, 1)
+	}
+
+	actor := actorFromContext(c)
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		subscription, err := lockSubscription(tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		if subscription.Status == models.SubscriptionStatusCancelled {
+			return ConflictErrorType{Message: "Cancelled subscriptions cannot be paused"}
+		}
+
+		if err := tx.Model(subscription).Updates(map[string]interface{}{
+			"status":       models.SubscriptionStatusPaused,
+			"paused_until": until,
+		}).Error; err != nil {
+			return err
+		}
+
+		return cancelSubscriptionDrafts(tx, subscription.ID, time.Now(), resumeOn, actor, "Subscription paused")
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	respondWithSubscription(c, http.StatusOK, id)
+}
+
+// ResumeSubscriptionHandler resumes a paused subscription. Drafts are
+// generated straight away for published menus that are still open.
+//
+// Route: POST /subscriptions/:id/resume
+// Parameters: id (path) - The subscription ID
+// Response: 200 OK with the updated Subscription object
+// Error responses: 400 if invalid ID, 401 if unauthorized, 404 if subscription not found,
+// 409 if the subscription is cancelled, 500 if database error
+func ResumeSubscriptionHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseSubscriptionID(c)
+	if !ok {
+		return
+	}
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		subscription, err := lockSubscription(tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		if subscription.Status == models.SubscriptionStatusCancelled {
+			return ConflictErrorType{Message: "Cancelled subscriptions cannot be resumed"}
+		}
+
+		subscription.Status = models.SubscriptionStatusActive
+		subscription.PausedUntil = nil
+		if err := tx.Model(subscription).Updates(map[string]interface{}{
+			"status":       subscription.Status,
+			"paused_until": nil,
+		}).Error; err != nil {
+			return err
+		}
+
+		return backfillSubscriptionDrafts(tx, subscription, time.Now())
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	respondWithSubscription(c, http.StatusOK, id)
+}
+
+// SkipSubscriptionWeekHandler skips a week of a subscription. No drafts are
+// generated for menus starting in that week, and pending drafts delivered in
+// it are cancelled. Skipping a week twice has no further effect.
+//
+// Route: POST /subscriptions/:id/skips
+// Parameters: id (path) - The subscription ID
+// Request body: JSON with week_start_date (any day in the week, YYYY-MM-DD)
+// Response: 200 OK with the updated Subscription object
+// Error responses: 400 if invalid data, 401 if unauthorized, 404 if subscription not found,
+// 409 if the subscription is cancelled, 500 if database error
+func SkipSubscriptionWeekHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseSubscriptionID(c)
+	if !ok {
+		return
+	}
+
+	var req SkipWeekRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid skip data", err.Error()))
+		return
+	}
+
+	date, err := parseDate("week_start_date", req.WeekStartDate)
+	if HandleAppError(c, err) {
+		return
+	}
+	weekStart := models.WeekStart(date)
+
+	actor := actorFromContext(c)
+
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		subscription, err := lockSubscription(tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		if subscription.Status == models.SubscriptionStatusCancelled {
+			return ConflictErrorType{Message: "Cancelled subscriptions cannot be changed"}
+		}
+
+		skip := models.SubscriptionSkip{SubscriptionID: subscription.ID, WeekStartDate: weekStart}
+		if err := tx.Where(&skip).FirstOrCreate(&skip).Error; err != nil {
+			return err
+		}
+
+		return cancelSubscriptionDrafts(tx, subscription.ID, weekStart, weekStart.AddDate(0, 0, 7), actor, "Week skipped")
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	respondWithSubscription(c, http.StatusOK, id)
+}
+
+// UnskipSubscriptionWeekHandler removes a skipped week. Drafts for that week
+// are generated again if its menu is published and still open.
+//
+// Route: DELETE /subscriptions/:id/skips/:week
+// Parameters: id (path) - The subscription ID, week (path) - Any day in the skipped week (YYYY-MM-DD)
+// Response: 200 OK with the updated Subscription object
+// Error responses: 400 if invalid data, 401 if unauthorized, 404 if subscription or skip not found, 500 if database error
+func UnskipSubscriptionWeekHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseSubscriptionID(c)
+	if !ok {
+		return
+	}
+
+	date, err := parseDate("week", c.Param("week"))
+	if HandleAppError(c, err) {
+		return
+	}
+	weekStart := models.WeekStart(date)
+
+	err = store.WithTransaction(c, func(tx *gorm.DB) error {
+		subscription, err := lockSubscription(tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		// Hard delete so the week can be skipped again later
+		result := tx.Unscoped().
+			Where("subscription_id = ? AND week_start_date = ?", subscription.ID, weekStart).
+			Delete(&models.SubscriptionSkip{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return NotFoundErrorType{Resource: "Skipped week"}
+		}
+
+		if subscription.Status == models.SubscriptionStatusCancelled {
+			return nil
+		}
+
+		if err := tx.Where("subscription_id = ?", subscription.ID).Find(&subscription.Skips).Error; err != nil {
+			return err
+		}
+		return backfillSubscriptionDrafts(tx, subscription, time.Now())
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	respondWithSubscription(c, http.StatusOK, id)
+}
+
+// CancelSubscriptionHandler ends a subscription and cancels its pending drafts.
+//
+// Route: DELETE /subscriptions/:id
+// Parameters: id (path) - The subscription ID
+// Response: 200 OK with the cancelled Subscription object
+// Error responses: 400 if invalid ID, 401 if unauthorized, 404 if subscription not found, 500 if database error
+func CancelSubscriptionHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, ok := parseSubscriptionID(c)
+	if !ok {
+		return
+	}
+
+	actor := actorFromContext(c)
+
+	err := store.WithTransaction(c, func(tx *gorm.DB) error {
+		subscription, err := lockSubscription(tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(subscription).Update("status", models.SubscriptionStatusCancelled).Error; err != nil {
+			return err
+		}
+
+		return cancelSubscriptionDrafts(tx, subscription.ID, time.Now(), time.Time{}, actor, "Subscription cancelled")
+	})
+
+	if HandleAppError(c, err) {
+		return
+	}
+
+	respondWithSubscription(c, http.StatusOK, id)
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"log"
+	"meals/config"
+	"meals/models"
+	"meals/store"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Names of the background jobs that keep subscription orders up to date
+const (
+	SubscriptionDraftsJob = "subscription-drafts"
+	DraftPlacementJob     = "draft-placement"
+)
+
+// subscriptionJobActor is recorded as the actor of order transitions made by
+// the subscription jobs
+var subscriptionJobActor = orderActor{RequestID: "job:subscriptions"}
+
+// GenerateSubscriptionDrafts creates draft orders for every active subscription
+// from each published menu that has not been processed yet.
+func GenerateSubscriptionDrafts() error {
+	now := time.Now()
+
+	var menus []models.Menu
+	if err := store.DB.
+		Where("published_at IS NOT NULL AND published_at <= ? AND drafts_generated_at IS NULL", now).
+		Find(&menus).Error; err != nil {
+		return err
+	}
+
+	for _, menu := range menus {
+		if err := generateMenuDrafts(store.DB, menu.ID, now); err != nil {
+			log.Printf("Failed to generate subscription drafts for menu %d: %v", menu.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// generateMenuDrafts creates the drafts for a single menu and marks it as
+// processed. The menu row is locked so concurrent runs cannot process it twice.
+func generateMenuDrafts(db *gorm.DB, menuID uint, now time.Time) error {
+	return store.Transaction(db, func(tx *gorm.DB) error {
+		var menu models.Menu
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&menu, menuID).Error; err != nil {
+			return err
+		}
+		if menu.DraftsGeneratedAt != nil {
+			return nil
+		}
+		if err := tx.Preload("Meal").Where("menu_id = ?", menu.ID).Find(&menu.MenuMeals).Error; err != nil {
+			return err
+		}
+
+		var subscriptions []models.Subscription
+		if err := tx.Preload("Skips").
+			Where("status IN ?", []models.SubscriptionStatus{models.SubscriptionStatusActive, models.SubscriptionStatusPaused}).
+			Find(&subscriptions).Error; err != nil {
+			return err
+		}
+
+		for i := range subscriptions {
+			if err := createSubscriptionDrafts(tx, &subscriptions[i], &menu, now); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&menu).Update("drafts_generated_at", now).Error
+	})
+}
+
+// backfillSubscriptionDrafts creates drafts for a subscription from menus that
+// were already processed and are still open, e.g. after subscribing or resuming
+// mid-week
+func backfillSubscriptionDrafts(tx *gorm.DB, subscription *models.Subscription, now time.Time) error {
+	var menus []models.Menu
+	if err := tx.Preload("MenuMeals.Meal").
+		Where("drafts_generated_at IS NOT NULL AND week_end_date >= ?", models.CalendarDate(now)).
+		Find(&menus).Error; err != nil {
+		return err
+	}
+
+	for i := range menus {
+		if err := createSubscriptionDrafts(tx, subscription, &menus[i], now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createSubscriptionDrafts plans the subscription's orders for the menu and
+// stores them as drafts. Delivery dates that already have a live order from the
+// subscription are left alone.
+func createSubscriptionDrafts(tx *gorm.DB, subscription *models.Subscription, menu *models.Menu, now time.Time) error {
+	if !subscription.IsActiveFor(menu.WeekStartDate) {
+		return nil
+	}
+
+	profile, err := findDietaryProfile(tx, subscription.UserID)
+	if err != nil {
+		return err
+	}
+
+	orders := planSubscriptionOrders(subscription, profile, menu, now)
+	for i := range orders {
+		order := &orders[i]
+
+		var existing int64
+		if err := tx.Model(&models.Order{}).
+			Where("subscription_id = ? AND delivery_date = ? AND status <> ?",
+				subscription.ID, order.DeliveryDate, models.OrderStatusCancelled).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			continue
+		}
+
+		// Drafts are delivered to the customer's default address, if they have one
+		if err := applyOrderAddress(tx, order, nil); err != nil {
+			return err
+		}
+
+		if err := prepareOrderItems(tx, order, now); err != nil {
+			if _, ok := err.(AppError); !ok {
+				return err
+			}
+			// The menu changed underneath the plan; skip the day rather than the whole run
+			log.Printf("Skipping subscription %d draft for %s: %v",
+				subscription.ID, order.DeliveryDate.Format("2006-01-02"), err)
+			continue
+		}
+
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+
+		if err := recordStatusEvent(tx, order.ID, "", order.Status, subscriptionJobActor, "Generated from subscription"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// planSubscriptionOrders picks meals from the menu for the subscription's
+// preferred delivery days that are still open for ordering. The weekly meal
+// count is spread as evenly as possible across those days, rotating through
+// each day's meals and honouring the dietary rules as well as the diets and
+// allergens on the customer's profile. Orders are returned unsaved, one per
+// delivery date.
+func planSubscriptionOrders(subscription *models.Subscription, profile *models.UserProfile, menu *models.Menu, now time.Time) []models.Order {
+	preferred := make(map[time.Weekday]bool)
+	for _, day := range subscription.DeliveryDays {
+		if weekday, ok := models.ParseWeekday(day); ok {
+			preferred[weekday] = true
+		}
+	}
+
+	// Group the eligible meals by delivery date
+	candidates := make(map[time.Time][]models.MenuMeal)
+	for i := range menu.MenuMeals {
+		menuMeal := menu.MenuMeals[i]
+
+		date, cutoff, err := menuMealSchedule(&menuMeal, menu)
+		if err != nil || !now.Before(cutoff) || !preferred[date.Weekday()] {
+			continue
+		}
+		if !menuMeal.CanReserve(1) || !subscription.DietaryRules.Allows(menuMeal.Meal) {
+			continue
+		}
+		if !profile.DietaryConflicts(menuMeal.Meal).IsEmpty() {
+			continue
+		}
+
+		date = models.CalendarDate(date)
+		candidates[date] = append(candidates[date], menuMeal)
+	}
+
+	dates := make([]time.Time, 0, len(candidates))
+	for date := range candidates {
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	var orders []models.Order
+	picked := make(map[uint]int) // Portions per meal across the week
+	maxRepeats := subscription.DietaryRules.MaxRepeats
+
+	for i, date := range dates {
+		count := subscription.MealsPerWeek / len(dates)
+		if i < subscription.MealsPerWeek%len(dates) {
+			count++
+		}
+
+		meals := candidates[date]
+		sort.Slice(meals, func(a, b int) bool { return meals[a].ID < meals[b].ID })
+
+		quantities := make(map[uint]int)
+		var menuMealIDs []uint
+		for n, next := 0, 0; n < count; n++ {
+			// Rotate to the next meal that has not hit its repeat limit
+			chosen := -1
+			for tries := 0; tries < len(meals); tries++ {
+				candidate := meals[(next+tries)%len(meals)]
+				if maxRepeats == 0 || picked[candidate.MealID] < maxRepeats {
+					chosen = (next + tries) % len(meals)
+					break
+				}
+			}
+			if chosen < 0 {
+				break
+			}
+
+			menuMeal := meals[chosen]
+			if quantities[menuMeal.ID] == 0 {
+				menuMealIDs = append(menuMealIDs, menuMeal.ID)
+			}
+			quantities[menuMeal.ID]++
+			picked[menuMeal.MealID]++
+			next = chosen + 1
+		}
+
+		if len(menuMealIDs) == 0 {
+			continue
+		}
+
+		draft := models.Order{
+			UserID:         subscription.UserID,
+			Status:         models.OrderStatusDraft,
+			SubscriptionID: &subscription.ID,
+			DeliveryDate:   date,
+		}
+		for _, menuMealID := range menuMealIDs {
+			draft.Items = append(draft.Items, models.OrderItem{
+				MenuMealID: menuMealID,
+				Quantity:   quantities[menuMealID],
+			})
+		}
+		orders = append(orders, draft)
+	}
+
+	return orders
+}
+
+// PlaceDueDraftOrders places draft orders whose ordering cutoff is less than
+// config.Subscriptions.AutoPlaceBefore away. Drafts that could not be placed
+// before the cutoff are cancelled.
+func PlaceDueDraftOrders() error {
+	now := time.Now()
+	lead := config.AppConfig.Subscriptions.AutoPlaceBefore
+
+	var drafts []models.Order
+	if err := store.DB.Where("status = ?", models.OrderStatusDraft).Find(&drafts).Error; err != nil {
+		return err
+	}
+
+	for _, draft := range drafts {
+		cutoff := orderCutoff(&draft)
+		if now.Before(cutoff.Add(-lead)) {
+			continue
+		}
+
+		err := store.Transaction(store.DB, func(tx *gorm.DB) error {
+			order, err := lockOrder(tx, draft.ID)
+			if err != nil {
+				return err
+			}
+			if order.Status != models.OrderStatusDraft {
+				return nil
+			}
+
+			if !now.Before(cutoff) {
+				return applyTransition(tx, order, models.OrderStatusCancelled, subscriptionJobActor,
+					"Not placed before the ordering cutoff")
+			}
+			return applyTransition(tx, order, models.OrderStatusPlaced, subscriptionJobActor,
+				"Placed automatically before the ordering cutoff")
+		})
+
+		// Failed placements (e.g. sold out meals) stay as drafts and are retried
+		// on the next run until the cutoff passes
+		if err != nil {
+			log.Printf("Failed to place draft order %d: %v", draft.ID, err)
+		}
+	}
+
+	return nil
+}
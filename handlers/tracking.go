@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"meals/config"
+	"meals/models"
+	"meals/routing"
+	"meals/store"
+	"meals/tracking"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DriverLocationRequest represents a driver's GPS ping
+type DriverLocationRequest struct {
+	Latitude   *float64   `json:"latitude" binding:"required"`
+	Longitude  *float64   `json:"longitude" binding:"required"`
+	Heading    *float64   `json:"heading"`
+	SpeedKmh   *float64   `json:"speed_kmh"`
+	RecordedAt *time.Time `json:"recorded_at"` // Defaults to when the ping is received
+}
+
+// TrackingEvent is sent to subscribers of an order's tracking stream
+type TrackingEvent struct {
+	Position         tracking.Position `json:"position"`
+	EstimatedArrival *time.Time        `json:"estimated_arrival"` // Nil if the order has no delivery location
+	StopsBefore      int               `json:"stops_before"`      // Stops the driver visits first
+}
+
+// trackers returns the tracker backed by store.RedisClient
+func trackers() *tracking.Tracker {
+	return tracking.NewTracker(store.RedisClient, config.AppConfig.Tracking.PositionTTL)
+}
+
+// PostDriverLocationHandler publishes the current driver's position to the
+// customers tracking their orders.
+//
+// Route: POST /driver/location
+// Request body: JSON with latitude, longitude and optional heading, speed_kmh and recorded_at
+// Response: 204 No Content
+// Error responses: 400 if invalid data, 401 if unauthorized, 403 if not a driver, 500 if Redis error
+func PostDriverLocationHandler(c *gin.Context) {
+	driverID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	var req DriverLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondWithError(c, ValidationError("Invalid location data", err.Error()))
+		return
+	}
+	if errs := models.ValidateCoordinates(req.Latitude, req.Longitude); len(errs) > 0 {
+		RespondWithError(c, ValidationError("Invalid location data", errs))
+		return
+	}
+
+	position := tracking.Position{
+		DriverID:   driverID,
+		Latitude:   *req.Latitude,
+		Longitude:  *req.Longitude,
+		Heading:    req.Heading,
+		SpeedKmh:   req.SpeedKmh,
+		RecordedAt: time.Now(),
+	}
+	if req.RecordedAt != nil {
+		position.RecordedAt = *req.RecordedAt
+	}
+
+	if err := trackers().Publish(position); err != nil {
+		RespondWithError(c, DatabaseError("Failed to publish location"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TrackOrderHandler streams the position of the driver delivering an order as
+// Server-Sent Events.
+//
+// A "position" event with a TrackingEvent is sent for the driver's last known
+// position and for every ping after it. The stream ends with a "status" event
+// once the order is no longer out for delivery. Pings reach the stream through
+// Redis pub/sub, so drivers and customers may be served by different instances.
+//
+// Route: GET /orders/:id/track
+// Parameters: id (path) - The order ID
+// Response: 200 OK with a text/event-stream
+// Error responses: 400 if invalid ID, 401 if unauthorized, 403 if not a customer or admin,
+// 404 if order not found, 409 if the order is not out for delivery on a run, 500 if database or Redis error
+func TrackOrderHandler(c *gin.Context) {
+	userID, ok := requireUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithError(c, BadRequestError("Invalid order ID format"))
+		return
+	}
+	orderID := uint(id)
+
+	var order models.Order
+	if err := store.DB.First(&order, orderID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			RespondWithError(c, NotFoundError("Order"))
+			return
+		}
+		RespondWithError(c, DatabaseError("Failed to retrieve order"))
+		return
+	}
+
+	if currentUserType(c) != models.UserTypeAdmin && !order.BelongsTo(userID) {
+		RespondWithError(c, NotFoundError("Order"))
+		return
+	}
+
+	run, err := trackedRun(store.DB, &order)
+	if HandleAppError(c, err) {
+		return
+	}
+
+	tracker := trackers()
+	subscription, err := tracker.Subscribe(*run.DriverID)
+	if err != nil {
+		RespondWithError(c, DatabaseError("Failed to subscribe to driver location"))
+		return
+	}
+	defer subscription.Close()
+
+	// Subscribe before reading the last position so no ping falls in between
+	last, err := tracker.Last(*run.DriverID)
+	if err != nil {
+		RespondWithError(c, DatabaseError("Failed to retrieve driver location"))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+	c.Status(http.StatusOK)
+
+	if last != nil {
+		c.SSEvent("position", trackingEvent(run, orderID, *last))
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(config.AppConfig.Tracking.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+
+		case position, ok := <-subscription.Positions():
+			if !ok {
+				return
+			}
+			c.SSEvent("position", trackingEvent(run, orderID, position))
+			c.Writer.Flush()
+
+		case <-heartbeat.C:
+			// Refresh the run so estimates skip stops delivered in the meantime
+			current, err := trackedRun(store.DB, &order)
+			if err != nil {
+				c.SSEvent("status", gin.H{"status": order.Status})
+				c.Writer.Flush()
+				return
+			}
+			run = current
+			// Comments keep idle connections open without waking clients
+			c.Writer.WriteString(": heartbeat\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// trackedRun reloads an order's status and returns the run delivering it with
+// its stops and their orders. The order must be out for delivery on a run
+// with a driver.
+func trackedRun(db *gorm.DB, order *models.Order) (*models.DeliveryRun, error) {
+	if err := db.Select("status").First(order, order.ID).Error; err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderStatusOutForDelivery {
+		return nil, ConflictErrorType{
+			Message: "Only orders out for delivery can be tracked",
+			Details: map[string]interface{}{"status": order.Status},
+		}
+	}
+
+	var stop models.DeliveryStop
+	err := db.Where("order_id = ?", order.ID).First(&stop).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	var run *models.DeliveryRun
+	if err == nil {
+		if run, err = loadDeliveryRun(db, stop.RunID); err != nil {
+			return nil, err
+		}
+	}
+	if run == nil || run.DriverID == nil {
+		return nil, ConflictErrorType{Message: "The order is not on a delivery run with a driver"}
+	}
+	return run, nil
+}
+
+// trackingEvent estimates when the driver at position reaches an order's stop.
+// The driver visits the remaining stops in sequence: the first from where
+// they are, the others along their planned legs, spending the configured
+// time at each stop before the order's.
+func trackingEvent(run *models.DeliveryRun, orderID uint, position tracking.Position) TrackingEvent {
+	event := TrackingEvent{Position: position}
+	cfg := config.AppConfig.Routing
+
+	now := time.Now()
+	at := now
+	from := &routing.Point{Latitude: position.Latitude, Longitude: position.Longitude}
+	for _, stop := range run.Stops {
+		if stop.Status.IsDone() {
+			continue
+		}
+		if stop.Order == nil || !stop.Order.HasDeliveryLocation() {
+			if stop.OrderID == orderID {
+				return event
+			}
+			continue
+		}
+
+		point := routing.Point{Latitude: *stop.Order.DeliveryLatitude, Longitude: *stop.Order.DeliveryLongitude}
+		switch {
+		case stop.Status == models.DeliveryStopStatusArrived:
+			// The driver is there already
+		case from != nil:
+			at = at.Add(routing.TravelTime(routing.Distance(*from, point), cfg.AverageSpeedKmh))
+		case stop.LegDurationSeconds != nil:
+			at = at.Add(time.Duration(*stop.LegDurationSeconds) * time.Second)
+		}
+		from = nil
+
+		if stop.OrderID == orderID {
+			event.EstimatedArrival = &at
+			return event
+		}
+		event.StopsBefore++
+		at = at.Add(cfg.StopDuration)
+	}
+	return event
+}
@@ -0,0 +1,100 @@
+// Package jobs runs background work on a fixed interval. Jobs can also be
+// triggered early, e.g. when a request changes data a job depends on.
+package jobs
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Func is the work performed by a job
+type Func func() error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      Func
+	trigger  chan struct{}
+}
+
+var (
+	mu       sync.Mutex
+	registry = make(map[string]*job)
+)
+
+// Register adds a job that runs every interval once Start is called.
+// Registering a name twice replaces the earlier job.
+func Register(name string, interval time.Duration, run Func) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if interval <= 0 {
+		log.Printf("Job %s has no valid interval, running it every minute", name)
+		interval = time.Minute
+	}
+
+	registry[name] = &job{
+		name:     name,
+		interval: interval,
+		run:      run,
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+// Start runs every registered job in its own goroutine. Each job runs once
+// immediately and then on its interval; runs of the same job never overlap.
+func Start() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, j := range registry {
+		go j.loop()
+	}
+}
+
+// Trigger asks a registered job to run as soon as possible. It never blocks;
+// a trigger that arrives while one is already pending is dropped.
+func Trigger(name string) {
+	mu.Lock()
+	j, exists := registry[name]
+	mu.Unlock()
+
+	if !exists {
+		return
+	}
+
+	select {
+	case j.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (j *job) loop() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.execute()
+
+		select {
+		case <-ticker.C:
+		case <-j.trigger:
+		}
+	}
+}
+
+// execute runs the job once, logging failures and recovering from panics so
+// a bad run does not stop the schedule
+func (j *job) execute() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v", j.name, r)
+		}
+	}()
+
+	start := time.Now()
+	if err := j.run(); err != nil {
+		log.Printf("Job %s failed after %v: %v", j.name, time.Since(start), err)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"log"
 	"meals/auth"
 	"meals/config"
+	"meals/handlers"
+	"meals/jobs"
+	"meals/payments"
 	"meals/routes"
 	"meals/store"
 )
@@ -21,6 +24,32 @@ func main() {
 	log.Println("Initializing OAuth2...")
 	auth.InitOAuth2()
 
+	// Register payment providers. The fake takes orders without charging
+	// anything, so it is only available outside production.
+	log.Println("Initializing payment providers...")
+	switch config.AppConfig.Server.Environment {
+	case "development", "test":
+		if config.AppConfig.Payments.FakeWebhookSecret == "" {
+			log.Fatal("payments.fakeWebhookSecret must be set to use the fake payment provider")
+		}
+		fake := payments.NewFake(config.AppConfig.Payments.FakeWebhookSecret)
+		fake.DeclineAmount = config.AppConfig.Payments.FakeDeclineAmount
+		payments.Register(fake)
+	}
+	if _, exists := payments.Get(config.AppConfig.Payments.Provider); !exists {
+		log.Fatalf("Payment provider %q is not registered in the %s environment",
+			config.AppConfig.Payments.Provider, config.AppConfig.Server.Environment)
+	}
+
+	// Start the background jobs that generate and place subscription orders
+	// and retry payment captures
+	log.Println("Starting background jobs...")
+	interval := config.AppConfig.Subscriptions.JobInterval
+	jobs.Register(handlers.SubscriptionDraftsJob, interval, handlers.GenerateSubscriptionDrafts)
+	jobs.Register(handlers.DraftPlacementJob, interval, handlers.PlaceDueDraftOrders)
+	jobs.Register(handlers.PaymentCaptureJob, interval, handlers.CapturePendingPayments)
+	jobs.Start()
+
 	// Initialize and start the router
 	log.Println("Starting web server...")
 	routes.InitRouter()
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+)
+
+const (
+	// IdempotencyKeyHeader is the header clients send to make a request safe to retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLength bounds the size of client supplied keys
+	maxIdempotencyKeyLength = 255
+	// idempotencyLockTTL is how long a key stays reserved while its first request
+	// runs, so a crashed request cannot block retries forever
+	idempotencyLockTTL = time.Minute
+	// idempotencyKeyPrefix namespaces stored responses in Redis
+	idempotencyKeyPrefix = "idempotency:"
+)
+
+// Error codes returned by the Idempotency middleware
+const (
+	ErrIdempotencyKeyReused = "IDEMPOTENCY_KEY_REUSED"
+	ErrIdempotencyKeyInUse  = "IDEMPOTENCY_KEY_IN_USE"
+)
+
+// idempotentResponse is what is stored in Redis for an idempotency key. While
+// the first request is running only the fingerprint is set.
+type idempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// responseRecorder copies everything written to the response so it can be stored
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency middleware makes retried requests safe for clients that send an
+// Idempotency-Key header. The first response for a key is stored in Redis for
+// ttl and replayed for every retry with the same key; a key reused with a
+// different method, path or body is rejected with 422. Keys are scoped to the
+// authenticated user, so it must run after the route's auth middleware; keys
+// sent without an authenticated user are rejected with 401, since clients
+// sharing an IP address could otherwise replay each other's responses.
+//
+// Server errors (5xx) are not stored, so the request can be retried. Requests
+// without the header pass through unchanged, and if Redis is unavailable the
+// request is processed without protection rather than rejected.
+func Idempotency(client *redis.Client, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithError(c, http.StatusBadRequest, "BAD_REQUEST",
+				fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength))
+			return
+		}
+		scope, ok := idempotencyScope(c)
+		if !ok {
+			abortWithError(c, http.StatusUnauthorized, "UNAUTHORIZED",
+				IdempotencyKeyHeader+" is only accepted on authenticated requests")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, "BAD_REQUEST", "Failed to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		redisKey := idempotencyKeyPrefix + scope + ":" + key
+		fingerprint := requestFingerprint(c.Request, body)
+
+		pending, _ := json.Marshal(idempotentResponse{Fingerprint: fingerprint})
+		reserved, err := client.SetNX(redisKey, pending, idempotencyLockTTL).Result()
+		if err != nil {
+			log.Printf("Idempotency check for key %q failed, processing request without it: %v", key, err)
+			c.Next()
+			return
+		}
+
+		if !reserved {
+			replayIdempotentResponse(c, client, redisKey, fingerprint)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		stored := false
+		defer func() {
+			// Release the key if the request panicked or failed so it can be retried
+			if !stored {
+				if err := client.Del(redisKey).Err(); err != nil {
+					log.Printf("Failed to release idempotency key %q: %v", key, err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		data, err := json.Marshal(idempotentResponse{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		})
+		if err == nil {
+			err = client.Set(redisKey, data, ttl).Err()
+		}
+		if err != nil {
+			log.Printf("Failed to store response for idempotency key %q: %v", key, err)
+			return
+		}
+		stored = true
+	}
+}
+
+// replayIdempotentResponse answers a request whose key is already taken
+func replayIdempotentResponse(c *gin.Context, client *redis.Client, redisKey, fingerprint string) {
+	data, err := client.Get(redisKey).Bytes()
+	if err == redis.Nil {
+		// The first request failed and released the key in the meantime
+		c.Header("Retry-After", "1")
+		abortWithError(c, http.StatusConflict, ErrIdempotencyKeyInUse,
+			"A request with this Idempotency-Key is still being processed")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load idempotent response: %v", err)
+		abortWithError(c, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", "An unexpected error occurred")
+		return
+	}
+
+	var previous idempotentResponse
+	if err := json.Unmarshal(data, &previous); err != nil {
+		log.Printf("Corrupt idempotent response at %s: %v", redisKey, err)
+		abortWithError(c, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", "An unexpected error occurred")
+		return
+	}
+
+	if previous.Fingerprint != fingerprint {
+		abortWithError(c, http.StatusUnprocessableEntity, ErrIdempotencyKeyReused,
+			"This Idempotency-Key was already used for a different request")
+		return
+	}
+
+	if !previous.Completed {
+		c.Header("Retry-After", "1")
+		abortWithError(c, http.StatusConflict, ErrIdempotencyKeyInUse,
+			"A request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(previous.Status, previous.ContentType, previous.Body)
+	c.Abort()
+}
+
+// idempotencyScope returns who an idempotency key belongs to, the user ID set
+// by auth.RequireRole, and false for anonymous requests
+func idempotencyScope(c *gin.Context) (string, bool) {
+	if value, exists := c.Get("userID"); exists {
+		if userID, ok := value.(uint); ok {
+			return "user:" + strconv.FormatUint(uint64(userID), 10), true
+		}
+	}
+	return "", false
+}
+
+// requestFingerprint identifies the request a key was first used for
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// abortWithError sends an error in the same shape as the handlers' error responses
+func abortWithError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error": gin.H{
+			"code":       code,
+			"message":    message,
+			"request_id": GetRequestID(c),
+		},
+	})
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Address is a delivery address in a customer's address book. Orders copy the
+// address when they are placed, so editing or deleting it never changes
+// where past orders were delivered.
+type Address struct {
+	gorm.Model
+	ProfileID uint        `json:"profile_id" gorm:"not null;index;uniqueIndex:idx_addresses_default,where:is_default AND deleted_at IS NULL"`
+	Profile   UserProfile `json:"-" gorm:"foreignKey:ProfileID;constraint:OnDelete:CASCADE;OnUpdate:CASCADE;"`
+
+	Label      string `json:"label" gorm:"type:varchar(50)"` // e.g. "Home" or "Work"
+	Line1      string `json:"line1" gorm:"not null"`
+	Line2      string `json:"line2"`
+	City       string `json:"city" gorm:"type:varchar(100);not null"`
+	Region     string `json:"region" gorm:"type:varchar(100)"` // State or province
+	PostalCode string `json:"postal_code" gorm:"type:varchar(20);not null"`
+
+	// Instructions tell the driver how to deliver, e.g. "Leave with the doorman"
+	Instructions string `json:"instructions"`
+
+	// Latitude and Longitude are the geocoded location, used to resolve the
+	// address's delivery zone and to plan delivery routes
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+
+	// Zone is the delivery zone containing the location, resolved when the
+	// address is saved; empty if it has no location or is outside every zone
+	Zone string `json:"zone" gorm:"type:varchar(32)"`
+
+	// IsDefault marks the one address used when an order names none
+	IsDefault bool `json:"is_default" gorm:"not null;default:false"`
+}
+
+// ValidateAddress validates the address data
+func (a *Address) ValidateAddress() []string {
+	var errors []string
+
+	if a.Line1 == "" {
+		errors = append(errors, "Line1 is required")
+	}
+	if a.City == "" {
+		errors = append(errors, "City is required")
+	}
+	if a.PostalCode == "" {
+		errors = append(errors, "PostalCode is required")
+	}
+	if len(a.Label) > 50 {
+		errors = append(errors, "Label must be at most 50 characters")
+	}
+
+	errors = append(errors, ValidateCoordinates(a.Latitude, a.Longitude)...)
+
+	return errors
+}
+
+// HasLocation checks if the address is geocoded
+func (a *Address) HasLocation() bool {
+	return a.Latitude != nil && a.Longitude != nil
+}
+
+// Format returns the address on one line, e.g. "12 Main St, Apt 4, Springfield, IL 62701"
+func (a *Address) Format() string {
+	parts := []string{a.Line1}
+	if a.Line2 != "" {
+		parts = append(parts, a.Line2)
+	}
+	parts = append(parts, a.City)
+
+	last := strings.TrimSpace(a.Region + " " + a.PostalCode)
+	if last != "" {
+		parts = append(parts, last)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,19 @@
+package models
+
+// ValidateCoordinates validates an optional geocoded location, which must
+// have both a latitude and a longitude or neither
+func ValidateCoordinates(latitude, longitude *float64) []string {
+	var errors []string
+
+	if (latitude == nil) != (longitude == nil) {
+		errors = append(errors, "Latitude and longitude must be given together")
+	}
+	if latitude != nil && (*latitude < -90 || *latitude > 90) {
+		errors = append(errors, "Latitude must be between -90 and 90")
+	}
+	if longitude != nil && (*longitude < -180 || *longitude > 180) {
+		errors = append(errors, "Longitude must be between -180 and 180")
+	}
+
+	return errors
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// BlobRef points at an uploaded file in the blob store. The key is never
+// exposed; files are served through short-lived signed URLs.
+type BlobRef struct {
+	Key         string `json:"-" gorm:"type:varchar(255)"`
+	ContentType string `json:"content_type" gorm:"type:varchar(100)"`
+	Size        int64  `json:"size"` // Bytes
+}
+
+// IsEmpty checks if no file was uploaded
+func (b BlobRef) IsEmpty() bool {
+	return b.Key == ""
+}
+
+// DeliveryProof is the evidence a driver collected when delivering an order
+type DeliveryProof struct {
+	gorm.Model
+	OrderID       uint    `json:"order_id" gorm:"not null;uniqueIndex"`
+	StopID        uint    `json:"stop_id" gorm:"not null;index"`
+	DriverID      uint    `json:"driver_id" gorm:"not null;index"`
+	RecipientName string  `json:"recipient_name,omitempty" gorm:"type:varchar(100)"`
+	Photo         BlobRef `json:"photo" gorm:"embedded;embeddedPrefix:photo_"`
+	Signature     BlobRef `json:"signature" gorm:"embedded;embeddedPrefix:signature_"` // Image of the recipient's signature
+}
+
+// Blobs returns the files attached to the proof
+func (p *DeliveryProof) Blobs() []BlobRef {
+	var blobs []BlobRef
+	for _, blob := range []BlobRef{p.Photo, p.Signature} {
+		if !blob.IsEmpty() {
+			blobs = append(blobs, blob)
+		}
+	}
+	return blobs
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeliveryRunStatus tracks a run from planning to its last stop
+type DeliveryRunStatus string
+
+const (
+	DeliveryRunStatusPlanned    DeliveryRunStatus = "planned"
+	DeliveryRunStatusInProgress DeliveryRunStatus = "in_progress"
+	DeliveryRunStatusCompleted  DeliveryRunStatus = "completed"
+)
+
+// DeliveryStopStatus tracks a single order's delivery within a run
+type DeliveryStopStatus string
+
+const (
+	DeliveryStopStatusPending   DeliveryStopStatus = "pending"
+	DeliveryStopStatusArrived   DeliveryStopStatus = "arrived"
+	DeliveryStopStatusDelivered DeliveryStopStatus = "delivered"
+	DeliveryStopStatusFailed    DeliveryStopStatus = "failed"
+)
+
+// DeliveryRun groups the orders of one delivery day that a single driver
+// delivers in sequence
+type DeliveryRun struct {
+	gorm.Model
+	DeliveryDate time.Time         `json:"delivery_date" gorm:"type:date;not null;index"`
+	Name         string            `json:"name" gorm:"type:varchar(100)"`
+	DriverID     *uint             `json:"driver_id" gorm:"index"` // Nil until a driver is assigned
+	Driver       *User             `json:"-" gorm:"foreignKey:DriverID;constraint:OnDelete:RESTRICT;OnUpdate:CASCADE;"`
+	Status       DeliveryRunStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	StartedAt    *time.Time        `json:"started_at"`
+	CompletedAt  *time.Time        `json:"completed_at"`
+	Stops        []DeliveryStop    `json:"stops" gorm:"foreignKey:RunID;constraint:OnDelete:CASCADE;OnUpdate:CASCADE;"`
+
+	// Route estimates from the kitchen through the located stops and back,
+	// including the time spent at each stop; nil until a stop has a location
+	RouteDistanceMeters  *int       `json:"route_distance_meters"`
+	RouteDurationSeconds *int       `json:"route_duration_seconds"`
+	OptimizedAt          *time.Time `json:"optimized_at"` // When the stops were last reordered by the route optimizer
+}
+
+// DeliveryStop is an order's place in a run. The partial unique index on
+// order_id keeps an order on at most one live stop.
+type DeliveryStop struct {
+	gorm.Model
+	RunID         uint               `json:"run_id" gorm:"not null;index"`
+	OrderID       uint               `json:"order_id" gorm:"not null;uniqueIndex:idx_delivery_stops_order_id,where:deleted_at IS NULL"`
+	Order         *Order             `json:"order,omitempty" gorm:"foreignKey:OrderID;constraint:OnDelete:RESTRICT;OnUpdate:CASCADE;"`
+	Sequence      int                `json:"sequence" gorm:"not null"` // 1-based position in the run
+	Status        DeliveryStopStatus `json:"status" gorm:"type:varchar(20);not null"`
+	ArrivedAt     *time.Time         `json:"arrived_at"`
+	CompletedAt   *time.Time         `json:"completed_at"` // When the stop was delivered or failed
+	FailureReason string             `json:"failure_reason,omitempty"`
+
+	// The drive to this stop from the kitchen or the previous located stop;
+	// nil for orders without a delivery location
+	LegDistanceMeters  *int `json:"leg_distance_meters"`
+	LegDurationSeconds *int `json:"leg_duration_seconds"`
+
+	// EstimatedArrival is computed for stops that are not done when a manifest is served
+	EstimatedArrival *time.Time `json:"estimated_arrival,omitempty" gorm:"-"`
+}
+
+// AfterDelete hook ensures that the stops of a run are soft deleted with it,
+// freeing their orders for other runs
+func (r *DeliveryRun) AfterDelete(tx *gorm.DB) error {
+	return tx.Model(&DeliveryStop{}).Where("run_id = ?", r.ID).Update("deleted_at", r.DeletedAt).Error
+}
+
+// IsAssignedTo checks if the run is assigned to the given driver
+func (r *DeliveryRun) IsAssignedTo(driverID uint) bool {
+	return r.DriverID != nil && *r.DriverID == driverID
+}
+
+// IsEditable checks if stops may still be added to or removed from the run
+func (r *DeliveryRun) IsEditable() bool {
+	return r.Status == DeliveryRunStatusPlanned
+}
+
+// IsDone checks if the stop has been delivered or failed
+func (s DeliveryStopStatus) IsDone() bool {
+	return s == DeliveryStopStatusDelivered || s == DeliveryStopStatusFailed
+}
+
+// CanMoveTo checks if a stop may move from s to next. Drivers may skip
+// marking a stop arrived, but a stop that is done stays done.
+func (s DeliveryStopStatus) CanMoveTo(next DeliveryStopStatus) bool {
+	switch next {
+	case DeliveryStopStatusArrived:
+		return s == DeliveryStopStatusPending
+	case DeliveryStopStatusDelivered, DeliveryStopStatusFailed:
+		return !s.IsDone()
+	}
+	return false
+}
+
+// IsComplete checks if every stop of the run is done
+func (r *DeliveryRun) IsComplete() bool {
+	for _, stop := range r.Stops {
+		if !stop.Status.IsDone() {
+			return false
+		}
+	}
+	return len(r.Stops) > 0
+}
+
+// EstimateArrivals sets the estimated arrival of every stop that is not done,
+// driving the remaining legs in sequence from the given time and spending
+// stopTime at each stop. Stops without leg estimates get no arrival.
+func (r *DeliveryRun) EstimateArrivals(from time.Time, stopTime time.Duration) {
+	at := from
+	for i := range r.Stops {
+		stop := &r.Stops[i]
+		if stop.Status.IsDone() || stop.LegDurationSeconds == nil {
+			continue
+		}
+		// Drivers already at a stop have finished the leg to it
+		if stop.Status != DeliveryStopStatusArrived {
+			at = at.Add(time.Duration(*stop.LegDurationSeconds) * time.Second)
+		}
+		arrival := at
+		stop.EstimatedArrival = &arrival
+		at = at.Add(stopTime)
+	}
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SlotTemplate is a weekly delivery window in a delivery zone. DeliverySlots
+// for concrete dates are generated from the active templates.
+type SlotTemplate struct {
+	gorm.Model
+	Zone      string `json:"zone" gorm:"type:varchar(32);not null;index"` // Pricing zone code, stored lower case
+	Weekday   string `json:"weekday" gorm:"type:varchar(10);not null"`    // Full weekday name, e.g. "Tuesday"
+	StartTime string `json:"start_time" gorm:"type:varchar(5);not null"`  // HH:MM in the kitchen's timezone
+	EndTime   string `json:"end_time" gorm:"type:varchar(5);not null"`    // HH:MM in the kitchen's timezone
+
+	// Capacity is the number of drivers times the orders each can deliver in the window
+	Drivers         int  `json:"drivers" gorm:"not null"`
+	OrdersPerDriver int  `json:"orders_per_driver" gorm:"not null"`
+	Active          bool `json:"active" gorm:"not null"`
+}
+
+// DeliverySlot is a bookable delivery window on a specific date. Each order
+// booked into the slot counts towards its capacity.
+type DeliverySlot struct {
+	gorm.Model
+	TemplateID   uint      `json:"template_id" gorm:"not null;uniqueIndex:idx_delivery_slots_template_date,where:deleted_at IS NULL"`
+	Zone         string    `json:"zone" gorm:"type:varchar(32);not null;index"`
+	DeliveryDate time.Time `json:"delivery_date" gorm:"type:date;not null;index;uniqueIndex:idx_delivery_slots_template_date,where:deleted_at IS NULL"`
+	StartsAt     time.Time `json:"starts_at" gorm:"not null"`
+	EndsAt       time.Time `json:"ends_at" gorm:"not null"`
+	Label        string    `json:"label" gorm:"type:varchar(32);not null"` // e.g. "Tue 17:00–19:00"
+	Capacity     int       `json:"capacity" gorm:"not null"`
+	Booked       int       `json:"booked" gorm:"not null;default:0"`
+}
+
+// ValidateSlotTemplate validates the slot template data
+func (t *SlotTemplate) ValidateSlotTemplate() []string {
+	var errors []string
+
+	if t.Zone == "" {
+		errors = append(errors, "Zone is required")
+	}
+
+	if _, ok := ParseWeekday(t.Weekday); !ok {
+		errors = append(errors, "Weekday must be a weekday name")
+	}
+
+	start, startErr := parseClock(t.StartTime)
+	if startErr != nil {
+		errors = append(errors, "StartTime must be in HH:MM format")
+	}
+	end, endErr := parseClock(t.EndTime)
+	if endErr != nil {
+		errors = append(errors, "EndTime must be in HH:MM format")
+	}
+	if startErr == nil && endErr == nil && end <= start {
+		errors = append(errors, "EndTime must be after StartTime")
+	}
+
+	if t.Drivers < 1 || t.OrdersPerDriver < 1 {
+		errors = append(errors, "Drivers and OrdersPerDriver must be positive")
+	}
+
+	return errors
+}
+
+// Capacity returns the number of orders a slot generated from the template can take
+func (t *SlotTemplate) Capacity() int {
+	return t.Drivers * t.OrdersPerDriver
+}
+
+// SlotOn returns the delivery slot of the template on a calendar date, with
+// its window in loc, and whether the template runs on that date's weekday
+func (t *SlotTemplate) SlotOn(date time.Time, loc *time.Location) (DeliverySlot, bool) {
+	weekday, ok := ParseWeekday(t.Weekday)
+	if !ok || date.Weekday() != weekday {
+		return DeliverySlot{}, false
+	}
+
+	start, err := parseClock(t.StartTime)
+	if err != nil {
+		return DeliverySlot{}, false
+	}
+	end, err := parseClock(t.EndTime)
+	if err != nil {
+		return DeliverySlot{}, false
+	}
+
+	// Build the times from the calendar date so they stay on the clock across DST changes
+	year, month, day := date.Date()
+	startsAt := time.Date(year, month, day, 0, int(start.Minutes()), 0, 0, loc)
+	endsAt := time.Date(year, month, day, 0, int(end.Minutes()), 0, 0, loc)
+	return DeliverySlot{
+		TemplateID:   t.ID,
+		Zone:         t.Zone,
+		DeliveryDate: CalendarDate(date),
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
+		Label:        fmt.Sprintf("%s %s–%s", date.Format("Mon"), startsAt.Format("15:04"), endsAt.Format("15:04")),
+		Capacity:     t.Capacity(),
+	}, true
+}
+
+// Remaining returns the number of orders the slot can still take
+func (s *DeliverySlot) Remaining() int {
+	if s.Booked >= s.Capacity {
+		return 0
+	}
+	return s.Capacity - s.Booked
+}
+
+// IsAvailableAt checks if an order can still be booked into the slot at the given time
+func (s *DeliverySlot) IsAvailableAt(now time.Time) bool {
+	return s.Remaining() > 0 && now.Before(s.StartsAt)
+}
+
+// parseClock parses an HH:MM time of day into the duration since midnight
+func parseClock(value string) (time.Duration, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
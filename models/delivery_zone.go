@@ -0,0 +1,76 @@
+package models
+
+import (
+	"meals/geofence"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeliveryZone is an area the kitchen delivers to, drawn as a GeoJSON
+// boundary. Orders, slot templates and drivers refer to zones by code, and a
+// zone's rules take precedence over a configured pricing zone with the same code.
+type DeliveryZone struct {
+	gorm.Model
+	Code     string            `json:"code" gorm:"type:varchar(32);not null;uniqueIndex:idx_delivery_zones_code,where:deleted_at IS NULL"` // Stored lower case
+	Name     string            `json:"name" gorm:"type:varchar(100)"`
+	Boundary geofence.Geometry `json:"boundary" gorm:"serializer:json;not null"`
+
+	// DeliveryFee and MinimumOrder are in minor units of the order's currency;
+	// MinimumOrder applies to the subtotal before discounts and zero means no minimum
+	DeliveryFee  int64 `json:"delivery_fee" gorm:"not null;default:0"`
+	MinimumOrder int64 `json:"minimum_order" gorm:"not null;default:0"`
+
+	// DeliveryDays lists the full weekday names the zone is delivered on; empty means every day
+	DeliveryDays []string `json:"delivery_days" gorm:"serializer:json"`
+
+	Kitchen      string `json:"kitchen" gorm:"type:varchar(64)"`      // Code of the kitchen that prepares and dispatches the zone's orders
+	Jurisdiction string `json:"jurisdiction" gorm:"type:varchar(32)"` // Tax jurisdiction; empty uses the configured one
+}
+
+// ValidateDeliveryZone validates the delivery zone data
+func (z *DeliveryZone) ValidateDeliveryZone() []string {
+	var errors []string
+
+	if z.Code == "" {
+		errors = append(errors, "Code is required")
+	} else if len(z.Code) > 32 || strings.ContainsAny(z.Code, " \t\n") {
+		errors = append(errors, "Code must be at most 32 characters without spaces")
+	}
+
+	if err := z.Boundary.Validate(); err != nil {
+		errors = append(errors, "Boundary must be a GeoJSON Polygon or MultiPolygon: "+strings.TrimPrefix(err.Error(), "geofence: "))
+	}
+
+	if z.DeliveryFee < 0 || z.MinimumOrder < 0 {
+		errors = append(errors, "DeliveryFee and MinimumOrder must not be negative")
+	}
+
+	for _, day := range z.DeliveryDays {
+		if _, ok := ParseWeekday(day); !ok {
+			errors = append(errors, "DeliveryDays must be weekday names")
+			break
+		}
+	}
+
+	return errors
+}
+
+// Contains checks if a geocoded location is inside the zone's boundary
+func (z *DeliveryZone) Contains(latitude, longitude float64) bool {
+	return z.Boundary.Contains(latitude, longitude)
+}
+
+// DeliversOn checks if the zone is delivered on the weekday of date
+func (z *DeliveryZone) DeliversOn(date time.Time) bool {
+	if len(z.DeliveryDays) == 0 {
+		return true
+	}
+	for _, day := range z.DeliveryDays {
+		if weekday, ok := ParseWeekday(day); ok && weekday == date.Weekday() {
+			return true
+		}
+	}
+	return false
+}
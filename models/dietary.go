@@ -0,0 +1,153 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// Diets customers can follow and meals can be labelled with
+const (
+	DietVegetarian  = "vegetarian"
+	DietVegan       = "vegan"
+	DietPescatarian = "pescatarian"
+	DietKeto        = "keto"
+	DietPaleo       = "paleo"
+	DietHalal       = "halal"
+	DietKosher      = "kosher"
+	DietGlutenFree  = "gluten_free"
+	DietDairyFree   = "dairy_free"
+)
+
+// Allergens customers can declare and meals can contain
+const (
+	AllergenPeanut    = "peanut"
+	AllergenTreeNut   = "tree_nut"
+	AllergenGluten    = "gluten"
+	AllergenDairy     = "dairy"
+	AllergenEgg       = "egg"
+	AllergenSoy       = "soy"
+	AllergenFish      = "fish"
+	AllergenShellfish = "shellfish"
+	AllergenSesame    = "sesame"
+	AllergenMustard   = "mustard"
+	AllergenCelery    = "celery"
+	AllergenSulphites = "sulphites"
+)
+
+// Diets lists every known diet
+var Diets = []string{
+	DietVegetarian, DietVegan, DietPescatarian, DietKeto, DietPaleo,
+	DietHalal, DietKosher, DietGlutenFree, DietDairyFree,
+}
+
+// Allergens lists every known allergen
+var Allergens = []string{
+	AllergenPeanut, AllergenTreeNut, AllergenGluten, AllergenDairy, AllergenEgg, AllergenSoy,
+	AllergenFish, AllergenShellfish, AllergenSesame, AllergenMustard, AllergenCelery, AllergenSulphites,
+}
+
+// dietImplies lists the diets that a meal labelled with a diet also suits
+var dietImplies = map[string][]string{
+	DietVegan:      {DietVegetarian, DietPescatarian, DietDairyFree},
+	DietVegetarian: {DietPescatarian},
+}
+
+// NormalizeLabels formats diet or allergen labels the way they are stored:
+// lower case with underscores, sorted and without duplicates
+func NormalizeLabels(labels []string) []string {
+	normalized := make([]string, 0, len(labels))
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		label = strings.NewReplacer(" ", "_", "-", "_").Replace(label)
+		if label == "" || seen[label] {
+			continue
+		}
+		seen[label] = true
+		normalized = append(normalized, label)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// ValidateLabels checks that every label is one of known, reporting them as field
+func ValidateLabels(field string, labels, known []string) []string {
+	for _, label := range labels {
+		if !containsLabel(known, label) {
+			return []string{field + " must be among " + strings.Join(known, ", ")}
+		}
+	}
+	return nil
+}
+
+// DietaryConflicts explains why a meal is unsuitable for a customer
+type DietaryConflicts struct {
+	Allergens []string `json:"allergens,omitempty"` // Declared allergens the meal contains
+	Diets     []string `json:"diets,omitempty"`     // Followed diets the meal is not labelled with
+}
+
+// IsEmpty checks if the meal is suitable after all
+func (c DietaryConflicts) IsEmpty() bool {
+	return len(c.Allergens) == 0 && len(c.Diets) == 0
+}
+
+// Suits checks if the meal is labelled with the diet, or with a stricter diet
+// that implies it
+func (m *Meal) Suits(diet string) bool {
+	for _, label := range m.Diets {
+		if label == diet || containsLabel(dietImplies[label], diet) {
+			return true
+		}
+	}
+	return false
+}
+
+// DietLabelsSuiting lists the labels a meal can carry to suit diet: the diet
+// itself followed by the diets that imply it, so queries can match meals the
+// way Suits does
+func DietLabelsSuiting(diet string) []string {
+	var implying []string
+	for label, implied := range dietImplies {
+		if containsLabel(implied, diet) {
+			implying = append(implying, label)
+		}
+	}
+	sort.Strings(implying)
+	return append([]string{diet}, implying...)
+}
+
+// ContainsAllergen checks if the meal contains the allergen
+func (m *Meal) ContainsAllergen(allergen string) bool {
+	return containsLabel(m.Allergens, allergen)
+}
+
+// HasDietaryPreferences checks if the profile declares any diet or allergen
+func (p *UserProfile) HasDietaryPreferences() bool {
+	return len(p.Diets) > 0 || len(p.Allergens) > 0
+}
+
+// DietaryConflicts checks a meal against the profile's diets and allergens
+func (p *UserProfile) DietaryConflicts(meal Meal) DietaryConflicts {
+	var conflicts DietaryConflicts
+	for _, allergen := range p.Allergens {
+		if meal.ContainsAllergen(allergen) {
+			conflicts.Allergens = append(conflicts.Allergens, allergen)
+		}
+	}
+	for _, diet := range p.Diets {
+		if !meal.Suits(diet) {
+			conflicts.Diets = append(conflicts.Diets, diet)
+		}
+	}
+	return conflicts
+}
+
+// containsLabel checks if labels contains label
+func containsLabel(labels []string, label string) bool {
+	for _, candidate := range labels {
+		if candidate == label {
+			return true
+		}
+	}
+	return false
+}
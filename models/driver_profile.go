@@ -0,0 +1,116 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DriverStatus is whether a driver can take deliveries
+type DriverStatus string
+
+const (
+	DriverStatusAvailable DriverStatus = "available" // Ready to be assigned deliveries
+	DriverStatusBusy      DriverStatus = "busy"      // On a delivery
+	DriverStatusOffline   DriverStatus = "offline"   // Not working
+)
+
+// VehicleType is what a driver delivers with
+type VehicleType string
+
+const (
+	VehicleTypeBicycle    VehicleType = "bicycle"
+	VehicleTypeScooter    VehicleType = "scooter"
+	VehicleTypeMotorcycle VehicleType = "motorcycle"
+	VehicleTypeCar        VehicleType = "car"
+	VehicleTypeVan        VehicleType = "van"
+)
+
+// DriverProfile holds the vehicle, license and availability of a driver
+type DriverProfile struct {
+	gorm.Model
+	UserID uint `json:"user_id" gorm:"not null;uniqueIndex"`
+	User   User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;OnUpdate:CASCADE;"`
+
+	VehicleType  VehicleType `json:"vehicle_type" gorm:"type:varchar(20)"`
+	VehicleMake  string      `json:"vehicle_make" gorm:"type:varchar(64)"`
+	VehicleModel string      `json:"vehicle_model" gorm:"type:varchar(64)"`
+	LicensePlate string      `json:"license_plate" gorm:"type:varchar(20)"`
+
+	// LicenseNumber and LicenseExpiresOn identify the driver's license; drivers
+	// with an expired license are never available
+	LicenseNumber    string     `json:"license_number" gorm:"type:varchar(64)"`
+	LicenseExpiresOn *time.Time `json:"license_expires_on" gorm:"type:date"`
+
+	Status   DriverStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	HomeZone string       `json:"home_zone" gorm:"type:varchar(32);index"` // Delivery zone code the driver starts from
+}
+
+// IsValid checks if the status is a known driver status
+func (s DriverStatus) IsValid() bool {
+	switch s {
+	case DriverStatusAvailable, DriverStatusBusy, DriverStatusOffline:
+		return true
+	}
+	return false
+}
+
+// IsValid checks if the vehicle type is a known vehicle type
+func (v VehicleType) IsValid() bool {
+	switch v {
+	case VehicleTypeBicycle, VehicleTypeScooter, VehicleTypeMotorcycle, VehicleTypeCar, VehicleTypeVan:
+		return true
+	}
+	return false
+}
+
+// RequiresLicense checks if driving the vehicle type needs a driver's license
+func (v VehicleType) RequiresLicense() bool {
+	return v != VehicleTypeBicycle
+}
+
+// ValidateDriverProfile validates the driver profile data
+func (d *DriverProfile) ValidateDriverProfile() []string {
+	var errors []string
+
+	if d.UserID == 0 {
+		errors = append(errors, "UserID is required")
+	}
+
+	if !d.Status.IsValid() {
+		errors = append(errors, "Status must be available, busy or offline")
+	}
+
+	if d.VehicleType != "" && !d.VehicleType.IsValid() {
+		errors = append(errors, "VehicleType must be bicycle, scooter, motorcycle, car or van")
+	}
+
+	// Drivers can only go on duty with a vehicle and, if it needs one, a license
+	if d.Status == DriverStatusAvailable {
+		if d.VehicleType == "" {
+			errors = append(errors, "VehicleType is required to be available")
+		} else if d.VehicleType.RequiresLicense() && (d.LicenseNumber == "" || d.LicenseExpiresOn == nil) {
+			errors = append(errors, "LicenseNumber and LicenseExpiresOn are required to drive a "+string(d.VehicleType))
+		}
+	}
+
+	return errors
+}
+
+// LicenseValidOn checks if the driver may drive their vehicle on the given
+// day. Licenses are valid through their expiry date.
+func (d *DriverProfile) LicenseValidOn(day time.Time) bool {
+	if !d.VehicleType.RequiresLicense() {
+		return true
+	}
+	if d.LicenseNumber == "" || d.LicenseExpiresOn == nil {
+		return false
+	}
+	expires := *d.LicenseExpiresOn
+	return day.Format("2006-01-02") <= expires.Format("2006-01-02")
+}
+
+// IsAvailableAt checks if the driver can be assigned deliveries at the given time
+func (d *DriverProfile) IsAvailableAt(now time.Time) bool {
+	return d.Status == DriverStatusAvailable && d.LicenseValidOn(now)
+}
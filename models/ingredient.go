@@ -0,0 +1,172 @@
+package models
+
+import (
+	"math"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Units an ingredient quantity can be measured in
+const (
+	UnitGram       = "g"
+	UnitKilogram   = "kg"
+	UnitMilliliter = "ml"
+	UnitLiter      = "l"
+	UnitPiece      = "piece"
+)
+
+// Units lists every known unit
+var Units = []string{UnitGram, UnitKilogram, UnitMilliliter, UnitLiter, UnitPiece}
+
+// NutritionFacts are the energy and macronutrients of 100g of an ingredient
+// or of one portion of a meal
+type NutritionFacts struct {
+	Calories      float64 `json:"calories" gorm:"not null;default:0"`      // kcal
+	Protein       float64 `json:"protein" gorm:"not null;default:0"`       // g
+	Carbohydrates float64 `json:"carbohydrates" gorm:"not null;default:0"` // g
+	Sugar         float64 `json:"sugar" gorm:"not null;default:0"`         // g, part of the carbohydrates
+	Fat           float64 `json:"fat" gorm:"not null;default:0"`           // g
+	SaturatedFat  float64 `json:"saturated_fat" gorm:"not null;default:0"` // g, part of the fat
+	Fiber         float64 `json:"fiber" gorm:"not null;default:0"`         // g
+	Sodium        float64 `json:"sodium" gorm:"not null;default:0"`        // mg
+}
+
+// Ingredient is an entry in the kitchen's ingredient catalog. Meals list their
+// ingredients with quantities, from which their allergens and nutrition facts
+// are derived.
+type Ingredient struct {
+	gorm.Model
+	Name      string   `json:"name" gorm:"type:varchar(100);not null;uniqueIndex:idx_ingredients_name,where:deleted_at IS NULL"`
+	Allergens []string `json:"allergens" gorm:"serializer:json"` // From the lists in dietary.go
+
+	// Nutrition is per 100g; volumes are weighed at 1g per ml
+	Nutrition NutritionFacts `json:"nutrition" gorm:"embedded;embeddedPrefix:nutrition_"`
+
+	// GramsPerPiece is the weight of one piece, for ingredients counted in
+	// pieces; pieces of ingredients without one add no nutrition
+	GramsPerPiece float64 `json:"grams_per_piece" gorm:"not null;default:0"`
+}
+
+// MealIngredient is the quantity of an ingredient in one portion of a meal
+type MealIngredient struct {
+	gorm.Model
+	MealID       uint       `json:"meal_id" gorm:"not null;uniqueIndex:idx_meal_ingredients_meal_ingredient,where:deleted_at IS NULL"`
+	IngredientID uint       `json:"ingredient_id" gorm:"not null;index;uniqueIndex:idx_meal_ingredients_meal_ingredient,where:deleted_at IS NULL"`
+	Ingredient   Ingredient `json:"ingredient" gorm:"foreignKey:IngredientID;constraint:OnDelete:RESTRICT;OnUpdate:CASCADE;"`
+	Quantity     float64    `json:"quantity" gorm:"not null"`
+	Unit         string     `json:"unit" gorm:"type:varchar(10);not null"`
+}
+
+// ValidateIngredient validates the ingredient data
+func (i *Ingredient) ValidateIngredient() []string {
+	var errors []string
+
+	if strings.TrimSpace(i.Name) == "" {
+		errors = append(errors, "Name is required")
+	} else if len(i.Name) > 100 {
+		errors = append(errors, "Name must be at most 100 characters")
+	}
+
+	errors = append(errors, ValidateLabels("Allergens", i.Allergens, Allergens)...)
+
+	if !i.Nutrition.IsValid() {
+		errors = append(errors, "Nutrition values must not be negative")
+	}
+	if i.GramsPerPiece < 0 {
+		errors = append(errors, "GramsPerPiece must not be negative")
+	}
+
+	return errors
+}
+
+// ValidateMealIngredient validates the quantity and unit of a meal ingredient
+func (mi *MealIngredient) ValidateMealIngredient() []string {
+	var errors []string
+
+	if mi.IngredientID == 0 {
+		errors = append(errors, "IngredientID is required")
+	}
+	if mi.Quantity <= 0 {
+		errors = append(errors, "Quantity must be positive")
+	}
+	if !containsLabel(Units, mi.Unit) {
+		errors = append(errors, "Unit must be among "+strings.Join(Units, ", "))
+	}
+
+	return errors
+}
+
+// Grams returns the weight of the quantity, or zero for pieces of an
+// ingredient without a weight per piece. The ingredient must be loaded.
+func (mi *MealIngredient) Grams() float64 {
+	switch mi.Unit {
+	case UnitGram, UnitMilliliter:
+		return mi.Quantity
+	case UnitKilogram, UnitLiter:
+		return mi.Quantity * 1000
+	case UnitPiece:
+		return mi.Quantity * mi.Ingredient.GramsPerPiece
+	}
+	return 0
+}
+
+// IsValid checks that no value is negative
+func (n NutritionFacts) IsValid() bool {
+	return n.Calories >= 0 && n.Protein >= 0 && n.Carbohydrates >= 0 && n.Sugar >= 0 &&
+		n.Fat >= 0 && n.SaturatedFat >= 0 && n.Fiber >= 0 && n.Sodium >= 0
+}
+
+// Add returns the sum of both facts, with the facts of per100g scaled to grams
+func (n NutritionFacts) Add(per100g NutritionFacts, grams float64) NutritionFacts {
+	factor := grams / 100
+	return NutritionFacts{
+		Calories:      n.Calories + per100g.Calories*factor,
+		Protein:       n.Protein + per100g.Protein*factor,
+		Carbohydrates: n.Carbohydrates + per100g.Carbohydrates*factor,
+		Sugar:         n.Sugar + per100g.Sugar*factor,
+		Fat:           n.Fat + per100g.Fat*factor,
+		SaturatedFat:  n.SaturatedFat + per100g.SaturatedFat*factor,
+		Fiber:         n.Fiber + per100g.Fiber*factor,
+		Sodium:        n.Sodium + per100g.Sodium*factor,
+	}
+}
+
+// Rounded returns the facts rounded to one decimal place
+func (n NutritionFacts) Rounded() NutritionFacts {
+	round := func(value float64) float64 { return math.Round(value*10) / 10 }
+	return NutritionFacts{
+		Calories:      round(n.Calories),
+		Protein:       round(n.Protein),
+		Carbohydrates: round(n.Carbohydrates),
+		Sugar:         round(n.Sugar),
+		Fat:           round(n.Fat),
+		SaturatedFat:  round(n.SaturatedFat),
+		Fiber:         round(n.Fiber),
+		Sodium:        round(n.Sodium),
+	}
+}
+
+// DeriveFromIngredients sets the meal's allergens to those of its ingredients,
+// its nutrition facts to their sum per portion and its ingredient names. Meals
+// without ingredients keep the allergens they were given. The ingredients must
+// be loaded.
+func (m *Meal) DeriveFromIngredients() {
+	if len(m.Ingredients) == 0 {
+		m.Nutrition = NutritionFacts{}
+		m.IngredientNames = ""
+		return
+	}
+
+	var allergens, names []string
+	var nutrition NutritionFacts
+	for _, line := range m.Ingredients {
+		allergens = append(allergens, line.Ingredient.Allergens...)
+		nutrition = nutrition.Add(line.Ingredient.Nutrition, line.Grams())
+		names = append(names, line.Ingredient.Name)
+	}
+
+	m.Allergens = NormalizeLabels(allergens)
+	m.Nutrition = nutrition.Rounded()
+	m.IngredientNames = strings.Join(names, " ")
+}
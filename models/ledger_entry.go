@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// LedgerEntryKind is why a customer's store credit changed
+type LedgerEntryKind string
+
+const (
+	LedgerEntryRefund      LedgerEntryKind = "refund"       // Refund issued as store credit
+	LedgerEntryOrderDebit  LedgerEntryKind = "order_debit"  // Credit spent on an order
+	LedgerEntryOrderCredit LedgerEntryKind = "order_credit" // Credit returned from a cancelled or reduced order
+	LedgerEntryAdjustment  LedgerEntryKind = "adjustment"   // Manual change by an admin
+)
+
+// ErrLedgerAppendOnly is returned when a ledger entry would be changed or removed
+var ErrLedgerAppendOnly = errors.New("ledger entries are append-only")
+
+// LedgerEntry is a credit (positive amount) or debit (negative amount) on a
+// user's store credit. Entries are append-only; corrections are made with a
+// new entry. Balance is the user's balance in the entry's currency after it
+// was applied, so the latest entry holds the current balance.
+type LedgerEntry struct {
+	gorm.Model
+	UserID  uint            `json:"user_id" gorm:"not null;index"`
+	Kind    LedgerEntryKind `json:"kind" gorm:"type:varchar(20);not null"`
+	Amount  Money           `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Balance Money           `json:"balance" gorm:"embedded;embeddedPrefix:balance_"`
+	OrderID *uint           `json:"order_id,omitempty" gorm:"index"`
+	// RefundID links credit issued by a Refund
+	RefundID *uint  `json:"refund_id,omitempty"`
+	ActorID  uint   `json:"actor_id"` // User who caused the entry
+	Note     string `json:"note"`
+}
+
+// BeforeUpdate keeps ledger entries from being modified
+func (e *LedgerEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrLedgerAppendOnly
+}
+
+// BeforeDelete keeps ledger entries from being removed
+func (e *LedgerEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrLedgerAppendOnly
+}
@@ -1,11 +1,63 @@
 package models
 
 import (
+	"strings"
+
 	"gorm.io/gorm"
 )
 
 type Meal struct {
 	gorm.Model
-	Name  string  `json:"name" gorm:"size:255;not null"`
-	Price float64 `json:"price" gorm:"not null"`
+	Name        string `json:"name" gorm:"size:255;not null"`
+	Description string `json:"description"`
+	Price       Money  `json:"price" gorm:"embedded;embeddedPrefix:price_"` // Stored as price_amount and price_currency
+	Category    string `json:"category" gorm:"type:varchar(50)"`            // Tax category, e.g. prepared or grocery
+
+	// Tags are free-form lower case labels such as spicy or family_size
+	Tags []string `json:"tags" gorm:"serializer:json"`
+
+	// Diets the meal suits and allergens it contains, from the lists in
+	// dietary.go; meals with ingredients derive their allergens from them
+	Diets     []string `json:"diets" gorm:"serializer:json"`
+	Allergens []string `json:"allergens" gorm:"serializer:json"`
+
+	// Ingredients of one portion, and the nutrition facts derived from them
+	Ingredients []MealIngredient `json:"ingredients,omitempty" gorm:"foreignKey:MealID;constraint:OnDelete:CASCADE;OnUpdate:CASCADE;"`
+	Nutrition   NutritionFacts   `json:"nutrition" gorm:"embedded;embeddedPrefix:nutrition_"`
+
+	// IngredientNames are the names of the ingredients, kept for the full-text
+	// search column, which cannot reference other tables
+	IngredientNames string `json:"-" gorm:"type:text;not null;default:''"`
+
+	// DietaryConflicts is set on meals listed with for_me that are unsuitable
+	// for the customer; not persisted
+	DietaryConflicts *DietaryConflicts `json:"dietary_conflicts,omitempty" gorm:"-"`
+}
+
+// NormalizeCategory formats a meal category the way it is stored and matched
+// against the configured tax rates
+func NormalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// ValidateMeal validates the meal data
+func (m *Meal) ValidateMeal() []string {
+	var errors []string
+
+	if m.Name == "" {
+		errors = append(errors, "Name is required")
+	}
+
+	errors = append(errors, m.Price.ValidateMoney("Price")...)
+
+	for _, tag := range m.Tags {
+		if len(tag) > 50 {
+			errors = append(errors, "Tags must be at most 50 characters")
+			break
+		}
+	}
+	errors = append(errors, ValidateLabels("Diets", m.Diets, Diets)...)
+	errors = append(errors, ValidateLabels("Allergens", m.Allergens, Allergens)...)
+
+	return errors
 }
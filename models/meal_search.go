@@ -0,0 +1,41 @@
+package models
+
+// PriceRangeBounds are the lower bounds of the price facet's ranges, in minor
+// units; the last range has no upper bound
+var PriceRangeBounds = []int64{0, 1000, 1500, 2000}
+
+// PriceRange is a range of the price facet, from Min up to but excluding Max
+type PriceRange struct {
+	Min   int64  `json:"min"`
+	Max   *int64 `json:"max"` // nil for the last range
+	Count int    `json:"count"`
+}
+
+// MealFacets counts meals per tag, price range and dietary label, so search
+// results can be narrowed down. Labels no meal has are left out; every price
+// range is listed.
+type MealFacets struct {
+	Tags        map[string]int `json:"tags"`
+	PriceRanges []PriceRange   `json:"price_ranges"`
+	Diets       map[string]int `json:"diets"` // Meals suiting each diet, including implied ones
+	Allergens   map[string]int `json:"allergens"`
+}
+
+// NewMealFacets returns facets with nothing counted yet and every price range
+// listed, for the counts of a search to be filled in
+func NewMealFacets() MealFacets {
+	facets := MealFacets{
+		Tags:        make(map[string]int),
+		PriceRanges: make([]PriceRange, len(PriceRangeBounds)),
+		Diets:       make(map[string]int),
+		Allergens:   make(map[string]int),
+	}
+	for i, min := range PriceRangeBounds {
+		facets.PriceRanges[i].Min = min
+		if i+1 < len(PriceRangeBounds) {
+			max := PriceRangeBounds[i+1]
+			facets.PriceRanges[i].Max = &max
+		}
+	}
+	return facets
+}
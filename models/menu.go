@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -13,6 +15,16 @@ type Menu struct {
 	WeekStartDate time.Time  `json:"week_start_date" gorm:"not null"`
 	WeekEndDate   time.Time  `json:"week_end_date" gorm:"not null"`
 	MenuMeals     []MenuMeal `json:"menu_meals" gorm:"foreignKey:MenuID;constraint:OnDelete:CASCADE;OnUpdate:CASCADE;"`
+
+	// PublishedAt is when the menu became visible to subscriptions; nil means unpublished
+	PublishedAt *time.Time `json:"published_at" gorm:"index"`
+	// DraftsGeneratedAt is when subscription draft orders were generated from the menu
+	DraftsGeneratedAt *time.Time `json:"drafts_generated_at"`
+}
+
+// IsPublished checks if the menu has been published as of now
+func (m *Menu) IsPublished(now time.Time) bool {
+	return m.PublishedAt != nil && !m.PublishedAt.After(now)
 }
 
 // AfterDelete hook ensures that MenuMeals are soft deleted when a Menu is soft deleted
@@ -22,3 +34,70 @@ func (m *Menu) AfterDelete(tx *gorm.DB) error {
 	// It also ensures the deleted_at timestamp matches the parent record.
 	return tx.Model(&MenuMeal{}).Where("menu_id = ?", m.ID).Update("deleted_at", m.DeletedAt).Error
 }
+
+// DeliveryDateFor resolves a MenuMeal delivery day to a concrete date within the
+// menu's week. The day may be a weekday name ("Monday", "mon") or an ISO date
+// ("2025-06-02"). The returned time is midnight of that date in loc.
+func (m *Menu) DeliveryDateFor(deliveryDay string, loc *time.Location) (time.Time, error) {
+	start := dateIn(m.WeekStartDate, loc)
+	end := dateIn(m.WeekEndDate, loc)
+
+	if date, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(deliveryDay), loc); err == nil {
+		if date.Before(start) || date.After(end) {
+			return time.Time{}, fmt.Errorf("delivery date %s is outside menu week %s to %s",
+				date.Format("2006-01-02"), start.Format("2006-01-02"), end.Format("2006-01-02"))
+		}
+		return date, nil
+	}
+
+	weekday, ok := ParseWeekday(deliveryDay)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid delivery day %q", deliveryDay)
+	}
+
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		if date.Weekday() == weekday {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("menu week %s to %s has no %s",
+		start.Format("2006-01-02"), end.Format("2006-01-02"), weekday)
+}
+
+// ParseWeekday parses a full or three-letter English weekday name, ignoring case
+func ParseWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) < 3 {
+		return 0, false
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// dateIn returns midnight in loc of the calendar date stored in t. Date
+// columns are read as midnight UTC, so converting them to loc first would
+// move them to the previous day west of UTC.
+func dateIn(t time.Time, loc *time.Location) time.Time {
+	year, month, day := CalendarDate(t).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// CalendarDate returns t's calendar date (as observed in t's own location) at
+// midnight UTC, which is how date columns are stored
+func CalendarDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// WeekStart returns the Monday of t's week as a calendar date
+func WeekStart(t time.Time) time.Time {
+	date := CalendarDate(t)
+	offset := (int(date.Weekday()) + 6) % 7
+	return date.AddDate(0, 0, -offset)
+}
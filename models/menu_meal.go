@@ -1,6 +1,9 @@
 package models
 
 import (
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -11,4 +14,51 @@ type MenuMeal struct {
 	MealID      uint   `json:"meal_id" gorm:"not null"`                                          // Foreign key to Meal
 	Menu        Menu   `gorm:"foreignKey:MenuID;constraint:OnDelete:CASCADE;OnUpdate:CASCADE;"`  // Reference to Menu
 	Meal        Meal   `gorm:"foreignKey:MealID;constraint:OnDelete:RESTRICT;OnUpdate:CASCADE;"` // Reference to Meal
+
+	// Capacity is the number of portions the kitchen can cook; nil means unlimited
+	Capacity *int `json:"capacity" gorm:"check:chk_menu_meals_capacity,capacity IS NULL OR capacity >= 0"`
+
+	// ReservedPortions is maintained by orders; values sent by clients are ignored
+	ReservedPortions int `json:"reserved_portions" gorm:"not null;default:0"`
+
+	// Schedule resolved from the menu week and ordering rules; not persisted
+	DeliveryDate *time.Time `json:"delivery_date,omitempty" gorm:"-"`
+	OrderCutoff  *time.Time `json:"order_cutoff,omitempty" gorm:"-"`
+
+	// RemainingPortions is Capacity minus ReservedPortions; nil means unlimited
+	RemainingPortions *int `json:"remaining_portions,omitempty" gorm:"-"`
+}
+
+// Remaining returns how many portions can still be reserved and whether the
+// meal has a capacity limit at all
+func (mm *MenuMeal) Remaining() (int, bool) {
+	if mm.Capacity == nil {
+		return 0, false
+	}
+	remaining := *mm.Capacity - mm.ReservedPortions
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, true
+}
+
+// CanReserve checks if the given number of portions is still available
+func (mm *MenuMeal) CanReserve(quantity int) bool {
+	remaining, limited := mm.Remaining()
+	return !limited || quantity <= remaining
+}
+
+// SameSlot checks if both menu meals offer the same meal on the same delivery
+// day, ignoring the case and spacing of the day
+func (mm *MenuMeal) SameSlot(other MenuMeal) bool {
+	return mm.MealID == other.MealID &&
+		strings.EqualFold(strings.TrimSpace(mm.DeliveryDay), strings.TrimSpace(other.DeliveryDay))
+}
+
+// AfterFind hook fills in RemainingPortions so API responses report stock
+func (mm *MenuMeal) AfterFind(tx *gorm.DB) error {
+	if remaining, limited := mm.Remaining(); limited {
+		mm.RemainingPortions = &remaining
+	}
+	return nil
 }
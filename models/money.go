@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultCurrency is the ISO 4217 currency used when none is specified
+const DefaultCurrency = "USD"
+
+// zeroDecimalCurrencies lists currencies whose minor unit is the major unit
+var zeroDecimalCurrencies = map[string]bool{
+	"JPY": true,
+	"KRW": true,
+	"VND": true,
+	"CLP": true,
+	"ISK": true,
+}
+
+// Money is an amount in minor units (e.g. cents) of an ISO 4217 currency.
+// Storing integers avoids the rounding drift of floating point prices.
+type Money struct {
+	Amount   int64  `json:"amount" gorm:"not null;default:0"`                    // Minor units, e.g. 1299 for 12.99
+	Currency string `json:"currency" gorm:"type:char(3);not null;default:'USD'"` // ISO 4217 code
+}
+
+// NewMoney creates a Money value, defaulting the currency if it is empty
+func NewMoney(amount int64, currency string) Money {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	return Money{Amount: amount, Currency: currency}
+}
+
+// MoneyFromDecimal converts a decimal major-unit amount (e.g. 12.99) to Money,
+// rounding half away from zero to the nearest minor unit
+func MoneyFromDecimal(value float64, currency string) Money {
+	m := NewMoney(0, currency)
+	m.Amount = int64(math.Round(value * math.Pow10(m.exponent())))
+	return m
+}
+
+// exponent returns the number of decimal places of the currency's minor unit
+func (m Money) exponent() int {
+	if zeroDecimalCurrencies[m.Currency] {
+		return 0
+	}
+	return 2
+}
+
+// SameCurrency checks if both amounts are in the same currency. A zero value
+// with no currency is compatible with any currency.
+func (m Money) SameCurrency(other Money) bool {
+	return m.Currency == other.Currency || m.Currency == "" || other.Currency == ""
+}
+
+// Add returns the sum of two amounts. Callers must ensure both amounts share a
+// currency; an empty currency adopts the other operand's currency.
+func (m Money) Add(other Money) Money {
+	currency := m.Currency
+	if currency == "" {
+		currency = other.Currency
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: currency}
+}
+
+// Sub returns the difference of two amounts in the same currency
+func (m Money) Sub(other Money) Money {
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Mul returns the amount multiplied by a quantity
+func (m Money) Mul(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// IsZero checks if the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative checks if the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// String formats the amount in major units followed by the currency code
+func (m Money) String() string {
+	exp := m.exponent()
+	if exp == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	unit := int64(math.Pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d %s", sign, amount/unit, exp, amount%unit, m.Currency)
+}
+
+// ValidateMoney validates a price-like amount: non-negative with an ISO currency code
+func (m Money) ValidateMoney(field string) []string {
+	var errors []string
+
+	if m.Amount < 0 {
+		errors = append(errors, field+" must not be negative")
+	}
+
+	if len(m.Currency) != 3 || strings.ToUpper(m.Currency) != m.Currency {
+		errors = append(errors, field+" currency must be a 3-letter ISO 4217 code")
+	}
+
+	return errors
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Order is a customer's purchase of one or more MenuMeals for delivery
+type Order struct {
+	gorm.Model
+	UserID uint        `json:"user_id" gorm:"not null;index"`                                             // Foreign key to User
+	User   User        `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:RESTRICT;OnUpdate:CASCADE;"` // Reference to User
+	Status OrderStatus `json:"status" gorm:"type:varchar(20);not null;default:'placed';index"`
+	Notes  string      `json:"notes"`
+	Items  []OrderItem `json:"items" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE;OnUpdate:CASCADE;"`
+
+	// DeliveryDate is the single date all items are delivered on, resolved from
+	// the items' menu week and delivery day
+	DeliveryDate time.Time `json:"delivery_date" gorm:"type:date;index"`
+
+	// DeliveryZone decides the delivery fee and tax jurisdiction of the order
+	DeliveryZone string `json:"delivery_zone" gorm:"type:varchar(32)"`
+
+	// DeliverySlot is the delivery window booked for the order, on its delivery date
+	DeliverySlotID *uint         `json:"delivery_slot_id,omitempty" gorm:"index"`
+	DeliverySlot   *DeliverySlot `json:"delivery_slot,omitempty" gorm:"foreignKey:DeliverySlotID;constraint:OnDelete:RESTRICT;OnUpdate:CASCADE;"`
+
+	// DeliveryAddress is where the order is delivered, with the geocoded
+	// location used to plan delivery routes
+	DeliveryAddress   string   `json:"delivery_address"`
+	DeliveryLatitude  *float64 `json:"delivery_latitude"`
+	DeliveryLongitude *float64 `json:"delivery_longitude"`
+
+	// AddressID is the address book entry the delivery fields were copied
+	// from; the copy is kept even if the entry is later edited or deleted
+	AddressID            *uint  `json:"address_id,omitempty" gorm:"index"`
+	DeliveryInstructions string `json:"delivery_instructions"`
+
+	// AcknowledgedAllergens are the declared allergens the customer agreed to
+	// order anyway, recorded when the order was placed or last edited
+	AcknowledgedAllergens []string `json:"acknowledged_allergens,omitempty" gorm:"serializer:json"`
+
+	// Totals computed from the item snapshots at placement time
+	Subtotal  Money          `json:"subtotal" gorm:"embedded;embeddedPrefix:subtotal_"`
+	Discount  Money          `json:"discount" gorm:"embedded;embeddedPrefix:discount_"`
+	Tax       Money          `json:"tax" gorm:"embedded;embeddedPrefix:tax_"`
+	Fees      Money          `json:"fees" gorm:"embedded;embeddedPrefix:fees_"`
+	Total     Money          `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	Breakdown PriceBreakdown `json:"breakdown" gorm:"serializer:json"` // Itemized totals
+
+	// PromoCode is the code redeemed on the order, kept as entered at checkout
+	PromoCodeID *uint  `json:"promo_code_id,omitempty" gorm:"index"`
+	PromoCode   string `json:"promo_code,omitempty" gorm:"type:varchar(64)"`
+
+	// CreditApplied is the store credit spent on the order; the payment covers the rest
+	CreditApplied Money `json:"credit_applied" gorm:"embedded;embeddedPrefix:credit_applied_"`
+
+	// Payment is created when the order is placed
+	Payment *Payment `json:"payment,omitempty" gorm:"foreignKey:OrderID;constraint:OnDelete:RESTRICT;OnUpdate:CASCADE;"`
+
+	// Refunds issued after delivery
+	Refunds []Refund `json:"refunds,omitempty" gorm:"foreignKey:OrderID;constraint:OnDelete:RESTRICT;OnUpdate:CASCADE;"`
+
+	// SubscriptionID is set on orders generated from a Subscription
+	SubscriptionID *uint `json:"subscription_id,omitempty" gorm:"index"`
+
+	StatusEvents []OrderStatusEvent `json:"status_events,omitempty" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE;OnUpdate:CASCADE;"`
+}
+
+// AfterDelete hook ensures that OrderItems are soft deleted when an Order is soft deleted
+func (o *Order) AfterDelete(tx *gorm.DB) error {
+	return tx.Model(&OrderItem{}).Where("order_id = ?", o.ID).Update("deleted_at", o.DeletedAt).Error
+}
+
+// ValidateOrder validates the order data
+func (o *Order) ValidateOrder() []string {
+	var errors []string
+
+	if o.UserID == 0 {
+		errors = append(errors, "UserID is required")
+	}
+
+	if o.Status != "" && !o.Status.IsValid() {
+		errors = append(errors, "Invalid order status")
+	}
+
+	if len(o.Items) == 0 {
+		errors = append(errors, "At least one item is required")
+	}
+
+	for _, item := range o.Items {
+		errors = append(errors, item.ValidateOrderItem()...)
+	}
+
+	errors = append(errors, ValidateCoordinates(o.DeliveryLatitude, o.DeliveryLongitude)...)
+
+	return errors
+}
+
+// HasDeliveryLocation checks if the order's delivery address is geocoded
+func (o *Order) HasDeliveryLocation() bool {
+	return o.DeliveryLatitude != nil && o.DeliveryLongitude != nil
+}
+
+// BelongsTo checks if the order was placed by the given user
+func (o *Order) BelongsTo(userID uint) bool {
+	return o.UserID == userID
+}
+
+// IsEditable checks if the order's items may still be changed by the customer
+func (o *Order) IsEditable() bool {
+	return o.Status == OrderStatusDraft || o.Status == OrderStatusPlaced
+}
+
+// Currency returns the currency shared by the order's items
+func (o *Order) Currency() string {
+	for _, item := range o.Items {
+		if item.UnitPrice.Currency != "" {
+			return item.UnitPrice.Currency
+		}
+	}
+	return DefaultCurrency
+}
+
+// ComputeTotals sums the item line totals into the order subtotal and adds
+// the taxes and fees to the discounted subtotal to get the total
+func (o *Order) ComputeTotals() {
+	subtotal := NewMoney(0, o.Currency())
+	tax := NewMoney(0, subtotal.Currency)
+	for _, item := range o.Items {
+		subtotal = subtotal.Add(item.LineTotal)
+		tax = tax.Add(item.Tax)
+	}
+	o.Subtotal = subtotal
+	o.Tax = tax
+	if o.Discount.IsZero() {
+		o.Discount = NewMoney(0, subtotal.Currency)
+	}
+	if o.Fees.IsZero() {
+		o.Fees = NewMoney(0, subtotal.Currency)
+	}
+	o.Total = subtotal.Sub(o.Discount).Add(o.Tax).Add(o.Fees)
+}
+
+// AmountDue returns the part of the total not covered by store credit, which
+// is what the payment is authorized for
+func (o *Order) AmountDue() Money {
+	return o.Total.Sub(o.CreditApplied)
+}
+
+// IsRefundable checks if money may be refunded on the order. Orders that are
+// not delivered are cancelled instead, which releases their payment.
+func (o *Order) IsRefundable() bool {
+	return o.Status == OrderStatusDelivered
+}
+
+// ApplyDiscount sets the order's discount and recomputes the total
+func (o *Order) ApplyDiscount(discount Money) {
+	o.Discount = discount
+	o.ComputeTotals()
+}
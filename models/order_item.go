@@ -0,0 +1,52 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// OrderItem is a single line of an Order referencing a MenuMeal
+type OrderItem struct {
+	gorm.Model
+	OrderID    uint     `json:"order_id" gorm:"not null;index"`                                                        // Foreign key to Order
+	MenuMealID uint     `json:"menu_meal_id" gorm:"not null;index"`                                                    // Foreign key to MenuMeal
+	Quantity   int      `json:"quantity" gorm:"not null"`                                                              // Number of portions
+	MenuMeal   MenuMeal `json:"menu_meal" gorm:"foreignKey:MenuMealID;constraint:OnDelete:RESTRICT;OnUpdate:CASCADE;"` // Reference to MenuMeal
+
+	// Snapshot of the meal at purchase time so later meal edits do not rewrite history
+	MealID    uint   `json:"meal_id" gorm:"index"`
+	MealName  string `json:"meal_name" gorm:"size:255"`
+	UnitPrice Money  `json:"unit_price" gorm:"embedded;embeddedPrefix:unit_price_"`
+	LineTotal Money  `json:"line_total" gorm:"embedded;embeddedPrefix:line_total_"`
+
+	// Tax on the line after its share of the order discount, at the rate for the meal's category
+	Category string `json:"category" gorm:"type:varchar(50)"`
+	TaxRate  int    `json:"tax_rate"` // Basis points, e.g. 888 for 8.88%
+	Tax      Money  `json:"tax" gorm:"embedded;embeddedPrefix:tax_"`
+}
+
+// ValidateOrderItem validates the order item data
+func (i *OrderItem) ValidateOrderItem() []string {
+	var errors []string
+
+	if i.MenuMealID == 0 {
+		errors = append(errors, "MenuMealID is required")
+	}
+
+	if i.Quantity <= 0 {
+		errors = append(errors, fmt.Sprintf("Quantity for menu meal %d must be positive", i.MenuMealID))
+	}
+
+	return errors
+}
+
+// SnapshotMeal copies the meal's name, category and current price onto the
+// item and computes the line total
+func (i *OrderItem) SnapshotMeal(meal Meal) {
+	i.MealID = meal.ID
+	i.MealName = meal.Name
+	i.Category = NormalizeCategory(meal.Category)
+	i.UnitPrice = meal.Price
+	i.LineTotal = meal.Price.Mul(i.Quantity)
+}
@@ -0,0 +1,105 @@
+package models
+
+// OrderStatus represents where an order is in its lifecycle
+type OrderStatus string
+
+const (
+	OrderStatusDraft          OrderStatus = "draft"
+	OrderStatusPlaced         OrderStatus = "placed"
+	OrderStatusConfirmed      OrderStatus = "confirmed"
+	OrderStatusPreparing      OrderStatus = "preparing"
+	OrderStatusOutForDelivery OrderStatus = "out_for_delivery"
+	OrderStatusDelivered      OrderStatus = "delivered"
+	OrderStatusCancelled      OrderStatus = "cancelled"
+	OrderStatusFailed         OrderStatus = "failed"
+)
+
+// orderTransitions lists, for every status, the statuses it may move to and
+// the roles allowed to perform each move
+var orderTransitions = map[OrderStatus]map[OrderStatus][]UserType{
+	OrderStatusDraft: {
+		OrderStatusPlaced:    {UserTypeCustomer, UserTypeAdmin},
+		OrderStatusCancelled: {UserTypeCustomer, UserTypeAdmin},
+	},
+	OrderStatusPlaced: {
+		OrderStatusConfirmed: {UserTypeAdmin},
+		OrderStatusCancelled: {UserTypeCustomer, UserTypeAdmin},
+	},
+	OrderStatusConfirmed: {
+		OrderStatusPreparing: {UserTypeAdmin},
+		OrderStatusCancelled: {UserTypeAdmin},
+	},
+	OrderStatusPreparing: {
+		OrderStatusOutForDelivery: {UserTypeAdmin, UserTypeDriver},
+		OrderStatusCancelled:      {UserTypeAdmin},
+	},
+	OrderStatusOutForDelivery: {
+		OrderStatusDelivered: {UserTypeDriver},
+		OrderStatusFailed:    {UserTypeDriver, UserTypeAdmin},
+	},
+}
+
+// IsValid checks if the status is one of the known order statuses
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusDraft, OrderStatusPlaced, OrderStatusConfirmed, OrderStatusPreparing,
+		OrderStatusOutForDelivery, OrderStatusDelivered, OrderStatusCancelled, OrderStatusFailed:
+		return true
+	}
+	return false
+}
+
+// IsTerminal checks if no further transitions are possible from the status
+func (s OrderStatus) IsTerminal() bool {
+	return len(orderTransitions[s]) == 0
+}
+
+// CanTransitionTo checks if the lifecycle allows moving from s to next at all,
+// regardless of who performs the move
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	_, ok := orderTransitions[s][next]
+	return ok
+}
+
+// CanBeTransitionedBy checks if a user of the given role may move an order
+// from s to next
+func (s OrderStatus) CanBeTransitionedBy(next OrderStatus, role UserType) bool {
+	for _, allowed := range orderTransitions[s][next] {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStatuses returns the statuses a user of the given role may move an order to from s
+func (s OrderStatus) NextStatuses(role UserType) []OrderStatus {
+	var next []OrderStatus
+	for _, status := range []OrderStatus{
+		OrderStatusPlaced, OrderStatusConfirmed, OrderStatusPreparing, OrderStatusOutForDelivery,
+		OrderStatusDelivered, OrderStatusCancelled, OrderStatusFailed,
+	} {
+		if s.CanBeTransitionedBy(status, role) {
+			next = append(next, status)
+		}
+	}
+	return next
+}
+
+// AwaitsDispatch checks if an order in this status still has to be sent out
+// for delivery, and so may be put on a delivery run
+func (s OrderStatus) AwaitsDispatch() bool {
+	return s == OrderStatusPlaced || s == OrderStatusConfirmed || s == OrderStatusPreparing
+}
+
+// ReservesStock checks if an order in this status holds reserved portions.
+// Drafts have not reserved anything yet and cancelled orders have released theirs.
+func (s OrderStatus) ReservesStock() bool {
+	return s != OrderStatusDraft && s != OrderStatusCancelled
+}
+
+// ProductionStatuses are the statuses of orders the kitchen cooks: confirmed
+// and not cancelled. Failed deliveries were cooked all the same.
+var ProductionStatuses = []OrderStatus{
+	OrderStatusConfirmed, OrderStatusPreparing, OrderStatusOutForDelivery, OrderStatusDelivered, OrderStatusFailed,
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrderStatusEvent records a single change of an Order's status. Events are
+// append-only and form the order's transition history.
+type OrderStatusEvent struct {
+	gorm.Model
+	OrderID    uint        `json:"order_id" gorm:"not null;index"`      // Foreign key to Order
+	FromStatus OrderStatus `json:"from_status" gorm:"type:varchar(20)"` // Empty for the initial placement
+	ToStatus   OrderStatus `json:"to_status" gorm:"type:varchar(20);not null"`
+	ActorID    uint        `json:"actor_id" gorm:"index"`                    // User who performed the transition
+	ActorType  UserType    `json:"actor_type" gorm:"type:varchar(20)"`       // Role of the actor at the time
+	RequestID  string      `json:"request_id" gorm:"type:varchar(64);index"` // Request that triggered the transition
+	Note       string      `json:"note"`
+	OccurredAt time.Time   `json:"occurred_at" gorm:"not null"`
+}
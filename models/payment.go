@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentStatus tracks an order's payment through the provider
+type PaymentStatus string
+
+const (
+	PaymentStatusAuthorized PaymentStatus = "authorized"
+	PaymentStatusCaptured   PaymentStatus = "captured"
+	PaymentStatusRefunded   PaymentStatus = "refunded"
+	PaymentStatusVoided     PaymentStatus = "voided"
+	PaymentStatusFailed     PaymentStatus = "failed"
+)
+
+// Payment is the provider-side payment for an order. Funds are authorized when
+// the order is placed and captured once it is delivered.
+type Payment struct {
+	gorm.Model
+	OrderID        uint          `json:"order_id" gorm:"not null;uniqueIndex"`
+	Provider       string        `json:"provider" gorm:"type:varchar(32);not null;uniqueIndex:idx_payments_provider_reference"`
+	Reference      string        `json:"reference" gorm:"type:varchar(128);not null;uniqueIndex:idx_payments_provider_reference"` // Provider's payment ID
+	Status         PaymentStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	Amount         Money         `json:"amount" gorm:"embedded;embeddedPrefix:amount_"` // Authorized amount
+	CapturedAmount Money         `json:"captured_amount" gorm:"embedded;embeddedPrefix:captured_"`
+	RefundedAmount Money         `json:"refunded_amount" gorm:"embedded;embeddedPrefix:refunded_"`
+	FailureReason  string        `json:"failure_reason,omitempty"` // Last provider error, e.g. a failed capture
+	AuthorizedAt   time.Time     `json:"authorized_at"`
+	CapturedAt     *time.Time    `json:"captured_at"`
+
+	// CaptureRequestedAt is set when the order is delivered; the provider is
+	// only asked to capture once that has been committed
+	CaptureRequestedAt *time.Time `json:"capture_requested_at,omitempty"`
+}
+
+// PaymentWebhookEvent records a webhook delivered by a payment provider. The
+// unique (provider, event_id) index makes replayed events no-ops.
+type PaymentWebhookEvent struct {
+	gorm.Model
+	Provider    string    `json:"provider" gorm:"type:varchar(32);not null;uniqueIndex:idx_payment_webhook_events_event"`
+	EventID     string    `json:"event_id" gorm:"type:varchar(128);not null;uniqueIndex:idx_payment_webhook_events_event"`
+	Type        string    `json:"type" gorm:"type:varchar(64);not null"`
+	PaymentID   *uint     `json:"payment_id" gorm:"index"` // Nil if the event references an unknown payment
+	Payload     string    `json:"payload" gorm:"type:text"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// CanCapture checks if the payment still holds funds that can be charged
+func (p *Payment) CanCapture() bool {
+	return p.Status == PaymentStatusAuthorized
+}
+
+// Refundable returns how much of the captured amount has not been refunded yet
+func (p *Payment) Refundable() Money {
+	if p.Status != PaymentStatusCaptured {
+		return NewMoney(0, p.Amount.Currency)
+	}
+	return p.CapturedAmount.Sub(p.RefundedAmount)
+}
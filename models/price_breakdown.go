@@ -0,0 +1,20 @@
+package models
+
+// PriceBreakdown itemizes how an order's total was computed. It is stored with
+// the order so later changes to tax or fee rules do not rewrite history.
+type PriceBreakdown struct {
+	Subtotal  Money             `json:"subtotal"`
+	Discounts []PriceAdjustment `json:"discounts"`
+	Taxes     []PriceAdjustment `json:"taxes"`
+	Fees      []PriceAdjustment `json:"fees"`
+	Total     Money             `json:"total"`
+}
+
+// PriceAdjustment is a single discount, tax or fee line of a PriceBreakdown
+type PriceAdjustment struct {
+	Code        string `json:"code"` // Promo code, tax category or fee type
+	Description string `json:"description"`
+	Rate        int    `json:"rate,omitempty"` // Tax rate in basis points
+	Base        *Money `json:"base,omitempty"` // Amount a tax was computed on
+	Amount      Money  `json:"amount"`
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ProductionPlan is what the kitchen cooks for one menu's delivery day: the
+// portions of every meal and the ingredients to buy for them
+type ProductionPlan struct {
+	MenuID       uint              `json:"menu_id"`
+	MenuName     string            `json:"menu_name"`
+	DeliveryDate time.Time         `json:"delivery_date"`
+	Meals        []ProductionMeal  `json:"meals"`
+	Ingredients  []IngredientTotal `json:"ingredients"`
+}
+
+// ProductionMeal is the number of portions of a meal to cook
+type ProductionMeal struct {
+	MealID   uint   `json:"meal_id"`
+	MealName string `json:"meal_name"`
+	Portions int    `json:"portions"`
+
+	// HasIngredients is false for meals without an ingredient list, whose
+	// ingredients are missing from the plan's totals
+	HasIngredients bool `json:"has_ingredients"`
+}
+
+// IngredientTotal is the quantity of an ingredient needed for a plan. An
+// ingredient used both by weight or volume and in pieces has one total per unit.
+type IngredientTotal struct {
+	IngredientID uint    `json:"ingredient_id"`
+	Name         string  `json:"name"`
+	Quantity     float64 `json:"quantity"`
+	Unit         string  `json:"unit"`
+}
+
+// baseUnits converts the larger units to the smaller one they are summed in
+var baseUnits = map[string]struct {
+	unit   string
+	factor float64
+}{
+	UnitGram:       {UnitGram, 1},
+	UnitKilogram:   {UnitGram, 1000},
+	UnitMilliliter: {UnitMilliliter, 1},
+	UnitLiter:      {UnitMilliliter, 1000},
+	UnitPiece:      {UnitPiece, 1},
+}
+
+// largeUnits are the units totals of 1000 base units or more are shown in
+var largeUnits = map[string]string{
+	UnitGram:       UnitKilogram,
+	UnitMilliliter: UnitLiter,
+}
+
+// PlanMeals fills in the plan's meals and ingredient totals from the meals to
+// cook, with their ingredients loaded, and the portions ordered per meal ID.
+// Meals are listed by name and ingredients by name and unit. Quantities are
+// summed in g, ml or pieces and shown in kg or l from 1000g or 1000ml up.
+func (p *ProductionPlan) PlanMeals(meals []Meal, portions map[uint]int) {
+	type totalKey struct {
+		ingredientID uint
+		unit         string
+	}
+	totals := make(map[totalKey]*IngredientTotal)
+
+	p.Meals = make([]ProductionMeal, 0, len(meals))
+	for _, meal := range meals {
+		count := portions[meal.ID]
+		p.Meals = append(p.Meals, ProductionMeal{
+			MealID:         meal.ID,
+			MealName:       meal.Name,
+			Portions:       count,
+			HasIngredients: len(meal.Ingredients) > 0,
+		})
+
+		for _, line := range meal.Ingredients {
+			base, ok := baseUnits[line.Unit]
+			if !ok || count == 0 {
+				continue
+			}
+			key := totalKey{line.IngredientID, base.unit}
+			total, ok := totals[key]
+			if !ok {
+				total = &IngredientTotal{IngredientID: line.IngredientID, Name: line.Ingredient.Name, Unit: base.unit}
+				totals[key] = total
+			}
+			total.Quantity += line.Quantity * base.factor * float64(count)
+		}
+	}
+
+	p.Ingredients = make([]IngredientTotal, 0, len(totals))
+	for _, total := range totals {
+		if large, ok := largeUnits[total.Unit]; ok && total.Quantity >= 1000 {
+			total.Quantity /= 1000
+			total.Unit = large
+		}
+		total.Quantity = math.Round(total.Quantity*1000) / 1000
+		p.Ingredients = append(p.Ingredients, *total)
+	}
+
+	sort.SliceStable(p.Meals, func(i, j int) bool {
+		if p.Meals[i].MealName != p.Meals[j].MealName {
+			return p.Meals[i].MealName < p.Meals[j].MealName
+		}
+		return p.Meals[i].MealID < p.Meals[j].MealID
+	})
+	sort.Slice(p.Ingredients, func(i, j int) bool {
+		a, b := p.Ingredients[i], p.Ingredients[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.IngredientID != b.IngredientID {
+			return a.IngredientID < b.IngredientID
+		}
+		return a.Unit < b.Unit
+	})
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DiscountType is how a promo code reduces an order
+type DiscountType string
+
+const (
+	DiscountTypePercentage DiscountType = "percentage"
+	DiscountTypeFixed      DiscountType = "fixed"
+)
+
+// PromoCode is a discount customers can apply when placing an order
+type PromoCode struct {
+	gorm.Model
+	Code         string       `json:"code" gorm:"type:varchar(64);not null;uniqueIndex"` // Stored upper case
+	Description  string       `json:"description"`
+	DiscountType DiscountType `json:"discount_type" gorm:"type:varchar(20);not null"`
+	PercentOff   int          `json:"percent_off"`                                           // Whole percent, for percentage discounts
+	AmountOff    Money        `json:"amount_off" gorm:"embedded;embeddedPrefix:amount_off_"` // For fixed discounts
+
+	// MinOrderValue is the order subtotal required to use the code; zero means no minimum
+	MinOrderValue  Money `json:"min_order_value" gorm:"embedded;embeddedPrefix:min_order_"`
+	FirstOrderOnly bool  `json:"first_order_only"`
+	MaxUsesPerUser int   `json:"max_uses_per_user"` // 0 means unlimited
+	MaxUses        int   `json:"max_uses"`          // Across all users; 0 means unlimited
+	UsageCount     int   `json:"usage_count" gorm:"not null;default:0"`
+
+	// StartsAt and EndsAt bound when the code can be used; nil means open-ended
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+	Active   bool       `json:"active" gorm:"not null"`
+
+	// MealIDs and MenuIDs restrict the discount to matching order lines; empty means any
+	MealIDs []uint `json:"meal_ids" gorm:"serializer:json"`
+	MenuIDs []uint `json:"menu_ids" gorm:"serializer:json"`
+}
+
+// PromoRedemption records a promo code used on an order. Redemptions of
+// cancelled orders are deleted so they no longer count towards usage limits.
+type PromoRedemption struct {
+	gorm.Model
+	PromoCodeID uint  `json:"promo_code_id" gorm:"not null;index"`
+	UserID      uint  `json:"user_id" gorm:"not null;index"`
+	OrderID     uint  `json:"order_id" gorm:"not null;uniqueIndex"`
+	Discount    Money `json:"discount" gorm:"embedded;embeddedPrefix:discount_"`
+}
+
+// NormalizePromoCode formats a code the way it is stored
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ValidatePromoCode validates the promo code data
+func (p *PromoCode) ValidatePromoCode() []string {
+	var errors []string
+
+	if p.Code == "" {
+		errors = append(errors, "Code is required")
+	} else if len(p.Code) > 64 || strings.ContainsAny(p.Code, " \t\n") {
+		errors = append(errors, "Code must be at most 64 characters without spaces")
+	}
+
+	switch p.DiscountType {
+	case DiscountTypePercentage:
+		if p.PercentOff < 1 || p.PercentOff > 100 {
+			errors = append(errors, "PercentOff must be between 1 and 100")
+		}
+	case DiscountTypeFixed:
+		errors = append(errors, p.AmountOff.ValidateMoney("AmountOff")...)
+		if p.AmountOff.Amount == 0 {
+			errors = append(errors, "AmountOff must be positive")
+		}
+	default:
+		errors = append(errors, "DiscountType must be percentage or fixed")
+	}
+
+	if !p.MinOrderValue.IsZero() {
+		errors = append(errors, p.MinOrderValue.ValidateMoney("MinOrderValue")...)
+	}
+
+	if p.MaxUsesPerUser < 0 || p.MaxUses < 0 {
+		errors = append(errors, "Usage limits must not be negative")
+	}
+
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		errors = append(errors, "EndsAt must be after StartsAt")
+	}
+
+	return errors
+}
+
+// IsAvailableAt checks if the code is enabled, within its date window and
+// not used up at the given time
+func (p *PromoCode) IsAvailableAt(now time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+	return p.MaxUses == 0 || p.UsageCount < p.MaxUses
+}
+
+// AppliesTo checks if an order line for the meal from the menu is discounted
+func (p *PromoCode) AppliesTo(mealID, menuID uint) bool {
+	return containsID(p.MealIDs, mealID) && containsID(p.MenuIDs, menuID)
+}
+
+// Discount returns the discount on the eligible part of an order. Percentages
+// round to the nearest minor unit and fixed amounts never exceed the eligible amount.
+func (p *PromoCode) Discount(eligible Money) Money {
+	discount := NewMoney(0, eligible.Currency)
+	if eligible.Amount <= 0 {
+		return discount
+	}
+
+	switch p.DiscountType {
+	case DiscountTypePercentage:
+		discount.Amount = (eligible.Amount*int64(p.PercentOff) + 50) / 100
+	case DiscountTypeFixed:
+		discount.Amount = p.AmountOff.Amount
+	}
+
+	if discount.Amount > eligible.Amount {
+		discount.Amount = eligible.Amount
+	}
+	return discount
+}
+
+// containsID checks if id is in ids; an empty list contains every ID
+func containsID(ids []uint, id uint) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package models
+
+import "gorm.io/gorm"
+
+// RefundMethod is where refunded money goes
+type RefundMethod string
+
+const (
+	RefundMethodOriginal RefundMethod = "original" // Back to the order's payment
+	RefundMethodCredit   RefundMethod = "credit"   // To the customer's store credit
+)
+
+// Refund records money returned to a customer for a delivered order, for
+// example for a missing item
+type Refund struct {
+	gorm.Model
+	OrderID   uint         `json:"order_id" gorm:"not null;index"`
+	PaymentID *uint        `json:"payment_id,omitempty"` // Set for refunds to the original payment
+	Method    RefundMethod `json:"method" gorm:"type:varchar(20);not null"`
+	Amount    Money        `json:"amount" gorm:"embedded;embeddedPrefix:amount_"`
+	Reason    string       `json:"reason" gorm:"not null"`
+	ActorID   uint         `json:"actor_id"` // Admin who issued the refund
+}
+
+// IsValid checks if the method is a known refund method
+func (m RefundMethod) IsValid() bool {
+	return m == RefundMethodOriginal || m == RefundMethodCredit
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SubscriptionStatus represents whether a subscription is generating orders
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusActive    SubscriptionStatus = "active"
+	SubscriptionStatusPaused    SubscriptionStatus = "paused"
+	SubscriptionStatusCancelled SubscriptionStatus = "cancelled"
+)
+
+// DietaryRules narrows which menu meals a subscription may pick
+type DietaryRules struct {
+	ExcludedMealIDs  []uint   `json:"excluded_meal_ids,omitempty"`
+	ExcludedKeywords []string `json:"excluded_keywords,omitempty"` // Matched case-insensitively against meal names
+	MaxRepeats       int      `json:"max_repeats,omitempty"`       // Max portions of the same meal per week; 0 means no limit
+}
+
+// Subscription is a customer's standing weekly order. Whenever a menu is
+// published, draft orders are generated from it for every active subscription.
+type Subscription struct {
+	gorm.Model
+	UserID       uint               `json:"user_id" gorm:"not null;index"`                                            // Foreign key to User
+	User         User               `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;OnUpdate:CASCADE;"` // Reference to User
+	Status       SubscriptionStatus `json:"status" gorm:"type:varchar(20);not null;default:'active';index"`
+	MealsPerWeek int                `json:"meals_per_week" gorm:"not null"`
+	DeliveryDays []string           `json:"delivery_days" gorm:"serializer:json"` // Preferred weekday names
+	DietaryRules DietaryRules       `json:"dietary_rules" gorm:"serializer:json"`
+	PausedUntil  *time.Time         `json:"paused_until" gorm:"type:date"` // Last paused day; nil while paused means until resumed
+	Skips        []SubscriptionSkip `json:"skips,omitempty" gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE;OnUpdate:CASCADE;"`
+}
+
+// SubscriptionSkip marks a week for which a subscription generates no orders.
+// WeekStartDate is always the Monday of the skipped week.
+type SubscriptionSkip struct {
+	gorm.Model
+	SubscriptionID uint      `json:"subscription_id" gorm:"not null;uniqueIndex:idx_subscription_skips_week"`
+	WeekStartDate  time.Time `json:"week_start_date" gorm:"type:date;not null;uniqueIndex:idx_subscription_skips_week"`
+}
+
+// ValidateSubscription validates the subscription data
+func (s *Subscription) ValidateSubscription() []string {
+	var errors []string
+
+	if s.MealsPerWeek <= 0 {
+		errors = append(errors, "MealsPerWeek must be positive")
+	}
+
+	if len(s.DeliveryDays) == 0 {
+		errors = append(errors, "At least one delivery day is required")
+	}
+
+	for _, day := range s.DeliveryDays {
+		if _, ok := ParseWeekday(day); !ok {
+			errors = append(errors, fmt.Sprintf("Invalid delivery day %q", day))
+		}
+	}
+
+	if s.DietaryRules.MaxRepeats < 0 {
+		errors = append(errors, "MaxRepeats must not be negative")
+	}
+
+	return errors
+}
+
+// IsActiveFor checks if the subscription should generate orders for the menu
+// week starting on weekStart. Skips match any menu starting in the skipped week.
+func (s *Subscription) IsActiveFor(weekStart time.Time) bool {
+	switch s.Status {
+	case SubscriptionStatusActive:
+	case SubscriptionStatusPaused:
+		// A pause with an end date lapses for weeks starting after it
+		if s.PausedUntil == nil || !CalendarDate(weekStart).After(CalendarDate(*s.PausedUntil)) {
+			return false
+		}
+	default:
+		return false
+	}
+
+	week := WeekStart(weekStart)
+	for _, skip := range s.Skips {
+		if WeekStart(skip.WeekStartDate).Equal(week) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Allows checks if the dietary rules permit the meal
+func (r DietaryRules) Allows(meal Meal) bool {
+	for _, id := range r.ExcludedMealIDs {
+		if id == meal.ID {
+			return false
+		}
+	}
+
+	name := strings.ToLower(meal.Name)
+	for _, keyword := range r.ExcludedKeywords {
+		if keyword != "" && strings.Contains(name, strings.ToLower(keyword)) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -4,9 +4,18 @@ import (
 	"gorm.io/gorm"
 )
 
-// UserProfile stores additional information about users, including delivery addresses
+// UserProfile stores additional information about users, including delivery
+// addresses and dietary preferences
 type UserProfile struct {
 	gorm.Model
 	UserID uint
 	User   User
+
+	// Addresses is the customer's address book, default address first
+	Addresses []Address `json:"addresses,omitempty" gorm:"foreignKey:ProfileID"`
+
+	// Diets the customer follows and allergens they declared, from the lists
+	// in dietary.go; menus listed with for_me and new orders are checked against them
+	Diets     []string `json:"diets" gorm:"serializer:json"`
+	Allergens []string `json:"allergens" gorm:"serializer:json"`
 }
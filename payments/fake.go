@@ -0,0 +1,183 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"meals/models"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FakeSignatureHeader carries the hex HMAC-SHA256 of a fake webhook payload
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// Fake is an in-process provider for development and tests. It keeps payments
+// in memory and signs webhooks with a shared secret.
+type Fake struct {
+	secret []byte
+
+	// DeclineAmount makes authorizations of exactly this many minor units fail;
+	// zero disables declines
+	DeclineAmount int64
+
+	mu        sync.Mutex
+	next      int
+	payments  map[string]*fakePayment
+	byIdemKey map[string]string
+}
+
+type fakePayment struct {
+	status     Status
+	authorized models.Money
+	captured   models.Money
+	refunded   models.Money
+}
+
+// NewFake creates a fake provider that signs webhooks with secret
+func NewFake(secret string) *Fake {
+	return &Fake{
+		secret:    []byte(secret),
+		payments:  make(map[string]*fakePayment),
+		byIdemKey: make(map[string]string),
+	}
+}
+
+// Name implements Provider
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+// Authorize implements Provider
+func (f *Fake) Authorize(ctx context.Context, req AuthorizeRequest) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if reference, exists := f.byIdemKey[req.IdempotencyKey]; exists && req.IdempotencyKey != "" {
+		payment := f.payments[reference]
+		return &Result{Reference: reference, Status: payment.status, Amount: payment.authorized}, nil
+	}
+
+	if f.DeclineAmount != 0 && req.Amount.Amount == f.DeclineAmount {
+		return nil, ErrDeclined
+	}
+
+	f.next++
+	reference := fmt.Sprintf("fake_pay_%d", f.next)
+	f.payments[reference] = &fakePayment{status: StatusAuthorized, authorized: req.Amount}
+	if req.IdempotencyKey != "" {
+		f.byIdemKey[req.IdempotencyKey] = reference
+	}
+
+	return &Result{Reference: reference, Status: StatusAuthorized, Amount: req.Amount}, nil
+}
+
+// Capture implements Provider. Capturing an already captured payment returns
+// the original capture without charging again.
+func (f *Fake) Capture(ctx context.Context, reference string, amount models.Money) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, exists := f.payments[reference]
+	if !exists {
+		return nil, ErrUnknownPayment
+	}
+
+	switch payment.status {
+	case StatusCaptured:
+		return &Result{Reference: reference, Status: StatusCaptured, Amount: payment.captured}, nil
+	case StatusAuthorized:
+	default:
+		return nil, ErrInvalidState
+	}
+
+	if amount.Amount > payment.authorized.Amount {
+		return nil, ErrInvalidState
+	}
+
+	payment.status = StatusCaptured
+	payment.captured = amount
+	return &Result{Reference: reference, Status: StatusCaptured, Amount: amount}, nil
+}
+
+// Refund implements Provider. Refunds may be partial; a payment becomes
+// refunded once its whole captured amount has been returned.
+func (f *Fake) Refund(ctx context.Context, reference string, amount models.Money) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payment, exists := f.payments[reference]
+	if !exists {
+		return nil, ErrUnknownPayment
+	}
+
+	switch payment.status {
+	case StatusAuthorized:
+		payment.status = StatusVoided
+		return &Result{Reference: reference, Status: StatusVoided, Amount: payment.authorized}, nil
+	case StatusCaptured:
+	default:
+		return nil, ErrInvalidState
+	}
+
+	if payment.refunded.Amount+amount.Amount > payment.captured.Amount {
+		return nil, ErrInvalidState
+	}
+
+	payment.refunded = payment.refunded.Add(amount)
+	status := StatusCaptured
+	if payment.refunded.Amount == payment.captured.Amount {
+		status = StatusRefunded
+		payment.status = StatusRefunded
+	}
+	return &Result{Reference: reference, Status: status, Amount: amount}, nil
+}
+
+// fakeWebhook is the JSON body of a fake webhook
+type fakeWebhook struct {
+	ID         string       `json:"id"`
+	Type       EventType    `json:"type"`
+	Reference  string       `json:"reference"`
+	Amount     models.Money `json:"amount"`
+	OccurredAt time.Time    `json:"occurred_at"`
+}
+
+// VerifyWebhook implements Provider
+func (f *Fake) VerifyWebhook(payload []byte, headers http.Header) (*WebhookEvent, error) {
+	signature, err := hex.DecodeString(headers.Get(FakeSignatureHeader))
+	if err != nil || !hmac.Equal(signature, f.sign(payload)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var body fakeWebhook
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, fmt.Errorf("payments: malformed webhook: %w", err)
+	}
+	if body.ID == "" || body.Reference == "" {
+		return nil, fmt.Errorf("payments: webhook is missing id or reference")
+	}
+
+	return &WebhookEvent{
+		ID:         body.ID,
+		Type:       body.Type,
+		Reference:  body.Reference,
+		Amount:     body.Amount,
+		OccurredAt: body.OccurredAt,
+	}, nil
+}
+
+// SignWebhook returns the signature header value for a payload, so tests and
+// local tooling can produce webhooks the fake accepts
+func (f *Fake) SignWebhook(payload []byte) string {
+	return hex.EncodeToString(f.sign(payload))
+}
+
+func (f *Fake) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
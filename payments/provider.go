@@ -0,0 +1,102 @@
+// Package payments abstracts payment processors behind the Provider interface.
+// Providers are registered by name at startup and looked up by the order flow.
+package payments
+
+import (
+	"context"
+	"errors"
+	"meals/models"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status is the state of a payment at the provider
+type Status string
+
+const (
+	StatusAuthorized Status = "authorized"
+	StatusCaptured   Status = "captured"
+	StatusRefunded   Status = "refunded"
+	StatusVoided     Status = "voided"
+	StatusDeclined   Status = "declined"
+)
+
+// EventType identifies what a webhook event reports
+type EventType string
+
+const (
+	EventCaptured EventType = "payment.captured"
+	EventRefunded EventType = "payment.refunded"
+	EventVoided   EventType = "payment.voided"
+	EventFailed   EventType = "payment.failed"
+)
+
+var (
+	// ErrDeclined is returned when the provider refuses an authorization
+	ErrDeclined = errors.New("payments: payment declined")
+	// ErrInvalidSignature is returned when a webhook cannot be authenticated
+	ErrInvalidSignature = errors.New("payments: invalid webhook signature")
+	// ErrUnknownPayment is returned for references the provider does not know
+	ErrUnknownPayment = errors.New("payments: unknown payment")
+	// ErrInvalidState is returned when an operation does not fit the payment's state
+	ErrInvalidState = errors.New("payments: operation not allowed in current state")
+)
+
+// AuthorizeRequest describes a payment to hold funds for
+type AuthorizeRequest struct {
+	Amount models.Money
+	// IdempotencyKey makes retried authorizations return the original result
+	IdempotencyKey string
+	Description    string
+}
+
+// Result is the outcome of a provider operation
+type Result struct {
+	Reference string // Provider's payment ID
+	Status    Status
+	Amount    models.Money // Amount affected by the operation
+}
+
+// WebhookEvent is a verified notification from the provider
+type WebhookEvent struct {
+	ID         string // Unique per event; replays carry the same ID
+	Type       EventType
+	Reference  string
+	Amount     models.Money // Amount captured, or for refunds the total refunded so far
+	OccurredAt time.Time
+}
+
+// Provider is a payment processor
+type Provider interface {
+	// Name is the provider's registry key, also used in webhook URLs
+	Name() string
+	// Authorize holds the amount without charging it
+	Authorize(ctx context.Context, req AuthorizeRequest) (*Result, error)
+	// Capture charges a previously authorized amount
+	Capture(ctx context.Context, reference string, amount models.Money) (*Result, error)
+	// Refund returns a captured amount, or voids an authorization that was never captured
+	Refund(ctx context.Context, reference string, amount models.Money) (*Result, error)
+	// VerifyWebhook authenticates and parses a webhook request
+	VerifyWebhook(payload []byte, headers http.Header) (*WebhookEvent, error)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = make(map[string]Provider)
+)
+
+// Register makes a provider available under its name
+func Register(provider Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[provider.Name()] = provider
+}
+
+// Get returns the provider registered under name
+func Get(name string) (Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	provider, exists := providers[name]
+	return provider, exists
+}
@@ -0,0 +1,228 @@
+// Package pricing computes the taxes, fees and totals of orders.
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"meals/config"
+	"meals/models"
+	"sort"
+	"strings"
+)
+
+// Fee codes used in price breakdowns
+const (
+	FeeDelivery   = "delivery"
+	FeeSmallOrder = "small_order"
+)
+
+// ErrUnknownZone is returned for orders to a delivery zone without pricing rules
+var ErrUnknownZone = errors.New("pricing: unknown delivery zone")
+
+// Calculator prices orders
+type Calculator interface {
+	// Price sets the line taxes, fees, total and breakdown of an order whose
+	// items are priced and whose discount is set
+	Price(order *models.Order) error
+}
+
+// ConfigCalculator prices orders with the zones and tax jurisdictions of a
+// config.PricingConfig
+type ConfigCalculator struct {
+	config config.PricingConfig
+}
+
+// NewCalculator creates a calculator for the given rules
+func NewCalculator(cfg config.PricingConfig) *ConfigCalculator {
+	return &ConfigCalculator{config: cfg}
+}
+
+// Price implements Calculator.
+//
+// The order discount is spread over the lines in proportion to their totals
+// and each line is taxed on what remains at the rate for its meal category.
+// Fees are added after tax and are not taxed themselves.
+func (c *ConfigCalculator) Price(order *models.Order) error {
+	// Zone codes are matched case-insensitively, like all configuration keys
+	order.DeliveryZone = strings.ToLower(strings.TrimSpace(order.DeliveryZone))
+	if order.DeliveryZone == "" {
+		order.DeliveryZone = strings.ToLower(c.config.DefaultZone)
+	}
+
+	zone, exists := c.config.Zones[order.DeliveryZone]
+	if !exists && len(c.config.Zones) > 0 {
+		return fmt.Errorf("%w %q", ErrUnknownZone, order.DeliveryZone)
+	}
+
+	jurisdiction := c.config.Jurisdictions[c.jurisdictionCode(zone)]
+
+	// Reset derived amounts so the subtotal is computed from the lines alone
+	currency := order.Currency()
+	for i := range order.Items {
+		order.Items[i].Tax = models.NewMoney(0, currency)
+	}
+	order.Fees = models.NewMoney(0, currency)
+	order.ComputeTotals()
+
+	discounts := allocate(order.Discount.Amount, order.Items)
+	taxes := make(map[taxKey]*models.PriceAdjustment)
+	for i := range order.Items {
+		item := &order.Items[i]
+		rate := jurisdiction.RateFor(item.Category)
+		taxable := item.LineTotal.Amount - discounts[i]
+
+		item.TaxRate = rate
+		item.Tax = models.NewMoney(applyRate(taxable, rate), currency)
+		if rate == 0 {
+			continue
+		}
+
+		key := taxKey{category: item.Category, rate: rate}
+		line, exists := taxes[key]
+		if !exists {
+			line = &models.PriceAdjustment{
+				Code:        taxCode(item.Category),
+				Description: taxDescription(jurisdiction, item.Category),
+				Rate:        rate,
+				Base:        &models.Money{Currency: currency},
+				Amount:      models.NewMoney(0, currency),
+			}
+			taxes[key] = line
+		}
+		line.Base.Amount += taxable
+		line.Amount = line.Amount.Add(item.Tax)
+	}
+
+	var fees []models.PriceAdjustment
+	if zone.DeliveryFee > 0 {
+		fees = append(fees, models.PriceAdjustment{
+			Code:        FeeDelivery,
+			Description: "Delivery to " + order.DeliveryZone,
+			Amount:      models.NewMoney(zone.DeliveryFee, currency),
+		})
+	}
+	discounted := order.Subtotal.Amount - order.Discount.Amount
+	if c.config.SmallOrderSurcharge > 0 && discounted < c.config.SmallOrderThreshold {
+		fees = append(fees, models.PriceAdjustment{
+			Code:        FeeSmallOrder,
+			Description: "Small order surcharge",
+			Amount:      models.NewMoney(c.config.SmallOrderSurcharge, currency),
+		})
+	}
+	for _, fee := range fees {
+		order.Fees = order.Fees.Add(fee.Amount)
+	}
+
+	order.ComputeTotals()
+	order.Breakdown = models.PriceBreakdown{
+		Subtotal:  order.Subtotal,
+		Discounts: []models.PriceAdjustment{},
+		Taxes:     sortedTaxes(taxes),
+		Fees:      fees,
+		Total:     order.Total,
+	}
+	if order.Breakdown.Fees == nil {
+		order.Breakdown.Fees = []models.PriceAdjustment{}
+	}
+	if !order.Discount.IsZero() {
+		order.Breakdown.Discounts = append(order.Breakdown.Discounts, models.PriceAdjustment{
+			Code:        order.PromoCode,
+			Description: "Promo code " + order.PromoCode,
+			Amount:      order.Discount,
+		})
+	}
+
+	return nil
+}
+
+// jurisdictionCode returns the tax jurisdiction of a zone
+func (c *ConfigCalculator) jurisdictionCode(zone config.PricingZone) string {
+	if zone.Jurisdiction != "" {
+		return zone.Jurisdiction
+	}
+	return c.config.DefaultJurisdiction
+}
+
+// taxKey groups the breakdown's tax lines
+type taxKey struct {
+	category string
+	rate     int
+}
+
+// allocate splits amount over the items in proportion to their line totals.
+// Remainders go to the lines with the largest fractional shares so the parts
+// always add up to amount.
+func allocate(amount int64, items []models.OrderItem) []int64 {
+	shares := make([]int64, len(items))
+	var total int64
+	for _, item := range items {
+		total += item.LineTotal.Amount
+	}
+	if amount == 0 || total <= 0 {
+		return shares
+	}
+
+	type remainder struct {
+		index int
+		value int64
+	}
+	remainders := make([]remainder, len(items))
+	var allocated int64
+	for i, item := range items {
+		product := amount * item.LineTotal.Amount
+		shares[i] = product / total
+		remainders[i] = remainder{index: i, value: product % total}
+		allocated += shares[i]
+	}
+
+	sort.SliceStable(remainders, func(a, b int) bool { return remainders[a].value > remainders[b].value })
+	for i := 0; allocated < amount; i++ {
+		shares[remainders[i%len(remainders)].index]++
+		allocated++
+	}
+
+	return shares
+}
+
+// taxCode names the tax line of a meal category
+func taxCode(category string) string {
+	if category == "" {
+		return "uncategorized"
+	}
+	return category
+}
+
+// taxDescription describes the tax line of a meal category
+func taxDescription(jurisdiction config.TaxJurisdiction, category string) string {
+	description := "Sales tax"
+	if jurisdiction.Name != "" {
+		description = jurisdiction.Name + " sales tax"
+	}
+	if category != "" {
+		description += " (" + category + ")"
+	}
+	return description
+}
+
+// applyRate computes a tax in basis points, rounding half up to the nearest minor unit
+func applyRate(amount int64, rate int) int64 {
+	if amount <= 0 || rate <= 0 {
+		return 0
+	}
+	return (amount*int64(rate) + 5000) / 10000
+}
+
+// sortedTaxes returns the tax lines ordered by category
+func sortedTaxes(taxes map[taxKey]*models.PriceAdjustment) []models.PriceAdjustment {
+	lines := make([]models.PriceAdjustment, 0, len(taxes))
+	for _, line := range taxes {
+		lines = append(lines, *line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Code != lines[j].Code {
+			return lines[i].Code < lines[j].Code
+		}
+		return lines[i].Rate < lines[j].Rate
+	})
+	return lines
+}
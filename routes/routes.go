@@ -6,6 +6,7 @@ import (
 	"meals/handlers"
 	"meals/middleware"
 	"meals/models"
+	"meals/store"
 
 	"net/http"
 
@@ -92,28 +93,98 @@ func RegisterRoutes(router *gin.Engine) {
 	router.GET("/auth/:provider/callback", handlers.GetAuthCallbackHandler)
 	router.GET("/logout", handlers.LogoutHandler)
 
-	// Meals
-	router.GET("/meals", handlers.GetMealsHandler)
-	router.POST("/meals", handlers.CreateMealHandler)
+	// Retried creates with the same Idempotency-Key replay the first response.
+	// Keys are scoped to the user, so it only goes on authenticated routes.
+	idempotent := middleware.Idempotency(store.RedisClient, config.AppConfig.Idempotency.TTL)
+
+	// Listings checked against the customer's dietary preferences need a session
+	forMe := auth.RequireRoleWhen(handlers.WantsForMe)
+
+	// Meals - admins create them; retried creates replay the first response
+	router.GET("/meals", forMe, handlers.GetMealsHandler)
+	router.GET("/meals/search", forMe, handlers.SearchMealsHandler)
+	router.POST("/meals", auth.RequireAdmin(), idempotent, handlers.CreateMealHandler)
 	router.GET("/meals/:id", handlers.GetMealHandler)
 	router.PUT("/meals/:id", handlers.UpdateMealHandler)
 	router.DELETE("/meals/:id", handlers.DeleteMealHandler)
 
 	// Menus
-	router.GET("/menus", handlers.GetMenusHandler)
-	router.POST("/menus", handlers.CreateMenuHandler)
+	router.GET("/menus", forMe, handlers.GetMenusHandler)
+	router.POST("/menus", auth.RequireAdmin(), idempotent, handlers.CreateMenuHandler)
 	router.PUT("/menus", handlers.UpdateMenuHandler)
 
+	// Delivery windows customers can book at checkout
+	router.GET("/delivery-slots", handlers.GetDeliverySlotsHandler)
+	router.GET("/delivery-zones/lookup", handlers.LookupDeliveryZoneHandler)
+
 	// Orders - all routes protected with role-based authentication
 	ordersGroup := router.Group("/orders")
 	{
 		// Customer & Admin can create orders
 		customerAdminRoutes := ordersGroup.Group("/")
 		customerAdminRoutes.Use(auth.RequireRole(models.UserTypeCustomer, models.UserTypeAdmin))
+		customerAdminRoutes.POST("", idempotent, handlers.CreateOrderHandler)
+		customerAdminRoutes.PUT("/:id", handlers.UpdateOrderHandler)
+		customerAdminRoutes.GET("/:id/track", handlers.TrackOrderHandler) // Server-Sent Events
+		customerAdminRoutes.GET("/:id/proof", handlers.GetOrderProofHandler)
 
 		// Any authenticated user can view orders (will be filtered by user ID in handler)
 		authenticatedRoutes := ordersGroup.Group("/")
 		authenticatedRoutes.Use(auth.RequireRole())
+		authenticatedRoutes.GET("", handlers.GetOrdersHandler)
+		authenticatedRoutes.GET("/mine", handlers.GetMyOrdersHandler)
+		authenticatedRoutes.GET("/:id", handlers.GetOrderHandler)
+		authenticatedRoutes.PUT("/:id/status", handlers.UpdateOrderStatusHandler)
+		authenticatedRoutes.POST("/:id/cancel", handlers.CancelOrderHandler)
+	}
+
+	// Store credit - any authenticated user can view their own balance and history
+	router.GET("/credit", auth.RequireRole(), handlers.GetMyCreditHandler)
+
+	// Uploaded files - links are authenticated by their signature and expire quickly
+	router.GET("/blobs/*key", handlers.ServeBlobHandler)
+
+	// Payments - provider callbacks are authenticated by their signature
+	router.POST("/payments/webhook/:provider", handlers.PaymentWebhookHandler)
+
+	// Cart - customers & admins build an order before checking out
+	cartGroup := router.Group("/cart")
+	cartGroup.Use(auth.RequireRole(models.UserTypeCustomer, models.UserTypeAdmin))
+	{
+		cartGroup.GET("", handlers.GetCartHandler)
+		cartGroup.DELETE("", handlers.ClearCartHandler)
+		cartGroup.POST("/items", handlers.AddCartItemHandler)
+		cartGroup.PUT("/items/:menu_meal_id", handlers.UpdateCartItemHandler)
+		cartGroup.DELETE("/items/:menu_meal_id", handlers.RemoveCartItemHandler)
+		cartGroup.POST("/checkout", idempotent, handlers.CheckoutCartHandler)
+	}
+
+	// Subscriptions - customers manage their own weekly subscriptions
+	subscriptionsGroup := router.Group("/subscriptions")
+	subscriptionsGroup.Use(auth.RequireRole(models.UserTypeCustomer))
+	{
+		subscriptionsGroup.POST("", handlers.CreateSubscriptionHandler)
+		subscriptionsGroup.GET("", handlers.GetSubscriptionsHandler)
+		subscriptionsGroup.PUT("/:id", handlers.UpdateSubscriptionHandler)
+		subscriptionsGroup.DELETE("/:id", handlers.CancelSubscriptionHandler)
+		subscriptionsGroup.POST("/:id/pause", handlers.PauseSubscriptionHandler)
+		subscriptionsGroup.POST("/:id/resume", handlers.ResumeSubscriptionHandler)
+		subscriptionsGroup.POST("/:id/skips", handlers.SkipSubscriptionWeekHandler)
+		subscriptionsGroup.DELETE("/:id/skips/:week", handlers.UnskipSubscriptionWeekHandler)
+	}
+
+	// Delivery runs - drivers can only view the runs assigned to them
+	router.GET("/delivery-runs/:id", auth.RequireAdminOrDriver(), handlers.GetDeliveryRunHandler)
+
+	// Drivers work through the stops of their runs
+	driverGroup := router.Group("/driver")
+	driverGroup.Use(auth.RequireDriver())
+	{
+		driverGroup.GET("/manifest", handlers.GetDriverManifestHandler)
+		driverGroup.POST("/location", handlers.PostDriverLocationHandler)
+		driverGroup.POST("/stops/:id/arrived", handlers.MarkStopArrivedHandler)
+		driverGroup.POST("/stops/:id/delivered", handlers.MarkStopDeliveredHandler)
+		driverGroup.POST("/stops/:id/failed", handlers.MarkStopFailedHandler)
 	}
 
 	// User Profiles
@@ -124,6 +195,11 @@ func RegisterRoutes(router *gin.Engine) {
 		authenticatedProfileRoutes.Use(auth.RequireRole())
 		authenticatedProfileRoutes.GET("", handlers.GetUserProfileHandler)
 		authenticatedProfileRoutes.PUT("", handlers.CreateOrUpdateProfileHandler)
+		authenticatedProfileRoutes.GET("/addresses", handlers.GetAddressesHandler)
+		authenticatedProfileRoutes.POST("/addresses", handlers.CreateAddressHandler)
+		authenticatedProfileRoutes.GET("/addresses/:id", handlers.GetAddressHandler)
+		authenticatedProfileRoutes.PUT("/addresses/:id", handlers.UpdateAddressHandler)
+		authenticatedProfileRoutes.DELETE("/addresses/:id", handlers.DeleteAddressHandler)
 
 		// Driver-specific profile management
 		driverAdminRoutes := profilesGroup.Group("/")
@@ -135,7 +211,45 @@ func RegisterRoutes(router *gin.Engine) {
 	adminGroup := router.Group("/admin")
 	adminGroup.Use(auth.RequireAdmin())
 	{
-		// Future admin routes will go here
+		adminGroup.POST("/menus/:id/publish", handlers.PublishMenuHandler)
+
+		adminGroup.GET("/promo-codes", handlers.GetPromoCodesHandler)
+		adminGroup.POST("/promo-codes", handlers.CreatePromoCodeHandler)
+		adminGroup.GET("/promo-codes/:id", handlers.GetPromoCodeHandler)
+		adminGroup.PUT("/promo-codes/:id", handlers.UpdatePromoCodeHandler)
+		adminGroup.DELETE("/promo-codes/:id", handlers.DeletePromoCodeHandler)
+
+		adminGroup.GET("/ingredients", handlers.GetIngredientsHandler)
+		adminGroup.POST("/ingredients", handlers.CreateIngredientHandler)
+		adminGroup.GET("/ingredients/:id", handlers.GetIngredientHandler)
+		adminGroup.PUT("/ingredients/:id", handlers.UpdateIngredientHandler)
+		adminGroup.DELETE("/ingredients/:id", handlers.DeleteIngredientHandler)
+		adminGroup.GET("/production", handlers.GetProductionPlanHandler)
+
+		adminGroup.GET("/drivers", handlers.GetDriversHandler)
+
+		adminGroup.GET("/delivery-zones", handlers.GetDeliveryZonesHandler)
+		adminGroup.POST("/delivery-zones", handlers.CreateDeliveryZoneHandler)
+		adminGroup.GET("/delivery-zones/:id", handlers.GetDeliveryZoneHandler)
+		adminGroup.PUT("/delivery-zones/:id", handlers.UpdateDeliveryZoneHandler)
+		adminGroup.DELETE("/delivery-zones/:id", handlers.DeleteDeliveryZoneHandler)
+
+		adminGroup.GET("/slot-templates", handlers.GetSlotTemplatesHandler)
+		adminGroup.POST("/slot-templates", handlers.CreateSlotTemplateHandler)
+		adminGroup.PUT("/slot-templates/:id", handlers.UpdateSlotTemplateHandler)
+		adminGroup.DELETE("/slot-templates/:id", handlers.DeleteSlotTemplateHandler)
+
+		adminGroup.GET("/delivery-runs", handlers.GetDeliveryRunsHandler)
+		adminGroup.POST("/delivery-runs", handlers.CreateDeliveryRunHandler)
+		adminGroup.DELETE("/delivery-runs/:id", handlers.DeleteDeliveryRunHandler)
+		adminGroup.PUT("/delivery-runs/:id/driver", handlers.AssignDeliveryRunDriverHandler)
+		adminGroup.POST("/delivery-runs/:id/optimize", handlers.OptimizeDeliveryRunHandler)
+		adminGroup.POST("/delivery-runs/:id/stops", handlers.AddDeliveryRunStopsHandler)
+		adminGroup.DELETE("/delivery-runs/:id/stops/:stop_id", handlers.RemoveDeliveryRunStopHandler)
+
+		adminGroup.POST("/orders/:id/refunds", handlers.CreateRefundHandler)
+		adminGroup.GET("/users/:id/credit", handlers.GetUserCreditHandler)
+		adminGroup.POST("/users/:id/credit", handlers.AdjustUserCreditHandler)
 	}
 }
 
@@ -0,0 +1,40 @@
+// Package routing plans the order in which a driver visits delivery stops.
+package routing
+
+import (
+	"math"
+	"time"
+)
+
+// earthRadius is the mean radius of the Earth in meters
+const earthRadius = 6371008.8
+
+// Point is a geocoded location in decimal degrees
+type Point struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Distance returns the great-circle distance between two points in meters
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLat := lat2 - lat1
+	dLng := radians(b.Longitude - a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// TravelTime estimates how long it takes to cover meters at kmh
+func TravelTime(meters, kmh float64) time.Duration {
+	if kmh <= 0 {
+		return 0
+	}
+	seconds := meters / (kmh * 1000 / 3600)
+	return time.Duration(math.Round(seconds)) * time.Second
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
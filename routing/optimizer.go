@@ -0,0 +1,152 @@
+package routing
+
+import "time"
+
+// Leg is one drive of a route, from the kitchen or the previous stop
+type Leg struct {
+	Distance float64       // Meters, as the crow flies
+	Duration time.Duration // Driving time at the route's average speed
+}
+
+// Route is a closed tour from the kitchen through every stop and back
+type Route struct {
+	// Order lists the indexes of the stops in visiting order
+	Order []int
+	// Legs holds the leg to each stop in Order, followed by the return to the kitchen
+	Legs []Leg
+}
+
+// Distance returns the total length of the route in meters
+func (r Route) Distance() float64 {
+	var total float64
+	for _, leg := range r.Legs {
+		total += leg.Distance
+	}
+	return total
+}
+
+// Duration returns the total driving time of the route
+func (r Route) Duration() time.Duration {
+	var total time.Duration
+	for _, leg := range r.Legs {
+		total += leg.Duration
+	}
+	return total
+}
+
+// Optimizer plans routes from a depot at an average driving speed
+type Optimizer struct {
+	Depot    Point
+	SpeedKmh float64
+}
+
+// Optimize returns a short closed tour from the depot through stops. A
+// nearest-neighbour tour is refined with 2-opt until no reversal of a
+// segment makes it shorter. The result is a good route, not necessarily the
+// shortest one.
+func (o Optimizer) Optimize(stops []Point) Route {
+	// Node 0 is the depot and node i+1 is stops[i]
+	nodes := append([]Point{o.Depot}, stops...)
+	dist := make([][]float64, len(nodes))
+	for i := range nodes {
+		dist[i] = make([]float64, len(nodes))
+		for j := range nodes {
+			dist[i][j] = Distance(nodes[i], nodes[j])
+		}
+	}
+
+	tour := nearestNeighbour(dist)
+	twoOpt(tour, dist)
+
+	route := Route{Order: make([]int, 0, len(stops))}
+	for i, node := range tour {
+		next := 0
+		if i+1 < len(tour) {
+			next = tour[i+1]
+		}
+		if node != 0 {
+			route.Order = append(route.Order, node-1)
+		}
+		if len(stops) > 0 {
+			route.Legs = append(route.Legs, o.leg(dist[node][next]))
+		}
+	}
+	return route
+}
+
+// Legs returns the legs of a route that visits stops in the given order
+// without reordering them
+func (o Optimizer) Legs(stops []Point) []Leg {
+	if len(stops) == 0 {
+		return nil
+	}
+	legs := make([]Leg, 0, len(stops)+1)
+	previous := o.Depot
+	for _, stop := range stops {
+		legs = append(legs, o.leg(Distance(previous, stop)))
+		previous = stop
+	}
+	return append(legs, o.leg(Distance(previous, o.Depot)))
+}
+
+func (o Optimizer) leg(meters float64) Leg {
+	return Leg{Distance: meters, Duration: TravelTime(meters, o.SpeedKmh)}
+}
+
+// nearestNeighbour builds a tour that starts at the depot and always drives
+// to the closest unvisited stop. Ties go to the stop listed first, so equal
+// inputs always give the same tour.
+func nearestNeighbour(dist [][]float64) []int {
+	visited := make([]bool, len(dist))
+	tour := make([]int, 1, len(dist))
+	visited[0] = true
+
+	for len(tour) < len(dist) {
+		current := tour[len(tour)-1]
+		next := -1
+		for candidate := range dist {
+			if visited[candidate] {
+				continue
+			}
+			if next == -1 || dist[current][candidate] < dist[current][next] {
+				next = candidate
+			}
+		}
+		visited[next] = true
+		tour = append(tour, next)
+	}
+	return tour
+}
+
+// twoOpt shortens a tour in place by reversing segments whose reversal
+// removes two crossing legs. The depot stays first; the tour implicitly
+// returns to it.
+func twoOpt(tour []int, dist [][]float64) {
+	n := len(tour)
+	if n < 4 {
+		return
+	}
+
+	// Improvements smaller than this are rounding noise and would stop the loop from ending
+	const epsilon = 1e-9
+	for improved := true; improved; {
+		improved = false
+		for i := 1; i < n-1; i++ {
+			for j := i + 1; j < n; j++ {
+				a, b := tour[i-1], tour[i]
+				c, d := tour[j], tour[(j+1)%n]
+				delta := dist[a][c] + dist[b][d] - dist[a][b] - dist[c][d]
+				if delta < -epsilon {
+					reverse(tour[i : j+1])
+					improved = true
+				}
+			}
+		}
+	}
+}
+
+func reverse(nodes []int) {
+	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+}
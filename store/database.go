@@ -33,12 +33,99 @@ func InitDB() {
 		&models.Session{},
 		&models.User{},
 		&models.UserProfile{},
+		&models.Address{},
+		&models.DriverProfile{},
+		&models.Ingredient{},
 		&models.Meal{},
+		&models.MealIngredient{},
 		&models.Menu{},
 		&models.MenuMeal{},
+		&models.DeliveryZone{},
+		&models.SlotTemplate{},
+		&models.DeliverySlot{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.OrderStatusEvent{},
+		&models.Subscription{},
+		&models.SubscriptionSkip{},
+		&models.Payment{},
+		&models.PaymentWebhookEvent{},
+		&models.PromoCode{},
+		&models.PromoRedemption{},
+		&models.Refund{},
+		&models.LedgerEntry{},
+		&models.DeliveryRun{},
+		&models.DeliveryStop{},
+		&models.DeliveryProof{},
 	); err != nil {
 		log.Fatalf("Failed to migrate models: %v", err)
 	}
 
+	if err := migrateMealPrices(DB); err != nil {
+		log.Fatalf("Failed to migrate meal prices: %v", err)
+	}
+
+	if err := migrateMealSearch(DB); err != nil {
+		log.Fatalf("Failed to migrate meal search: %v", err)
+	}
+
 	log.Println("Migrated PostgreSQL DB successfully")
 }
+
+// migrateMealPrices converts the legacy floating point meals.price column into
+// integer minor units. AutoMigrate has already added price_amount and
+// price_currency; existing prices are rounded to the nearest cent through
+// numeric (not float) arithmetic and the legacy column is dropped in the same
+// transaction, so a failure leaves the table untouched.
+func migrateMealPrices(db *gorm.DB) error {
+	if !db.Migrator().HasColumn("meals", "price") {
+		return nil
+	}
+
+	log.Println("Converting legacy meal prices to minor units...")
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE meals
+			SET price_amount = ROUND(price::numeric * 100)::bigint,
+				price_currency = ?
+			WHERE price IS NOT NULL`, models.DefaultCurrency).Error; err != nil {
+			return err
+		}
+		return tx.Exec("ALTER TABLE meals DROP COLUMN price").Error
+	})
+}
+
+// migrateMealSearch adds the meals.search_vector column searched by
+// GET /meals/search. It is generated by Postgres from the name, description
+// and ingredient names, weighted in that order, and indexed with GIN. GORM
+// cannot declare generated columns, so the column is not part of the model.
+// Ingredient names of existing meals are filled in first, in the same
+// transaction.
+func migrateMealSearch(db *gorm.DB) error {
+	if db.Migrator().HasColumn("meals", "search_vector") {
+		return nil
+	}
+
+	log.Println("Adding the meal search column...")
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE meals
+			SET ingredient_names = COALESCE((
+				SELECT string_agg(ingredients.name, ' ' ORDER BY meal_ingredients.id)
+				FROM meal_ingredients
+				JOIN ingredients ON ingredients.id = meal_ingredients.ingredient_id
+				WHERE meal_ingredients.meal_id = meals.id AND meal_ingredients.deleted_at IS NULL
+			), '')`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`ALTER TABLE meals ADD COLUMN search_vector tsvector
+			GENERATED ALWAYS AS (
+				setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+				setweight(to_tsvector('english', COALESCE(description, '')), 'B') ||
+				setweight(to_tsvector('english', COALESCE(ingredient_names, '')), 'C')
+			) STORED`).Error; err != nil {
+			return err
+		}
+		return tx.Exec("CREATE INDEX idx_meals_search_vector ON meals USING GIN (search_vector)").Error
+	})
+}
@@ -18,18 +18,130 @@ type TxFn func(tx TxHandle) error
 // TxFnWithResult represents a function that uses a transaction and returns a result
 type TxFnWithResult func(tx TxHandle) (interface{}, error)
 
+// txHooksKey is the context key under which a transaction carries its hooks
+type txHooksKey struct{}
+
+// txHook is work that depends on the outcome of a transaction, such as calls
+// to external services that cannot take part in it
+type txHook struct {
+	onCommit   func()
+	onRollback func()
+}
+
+// txHooks are the hooks registered during one transaction, in order
+type txHooks struct {
+	hooks []txHook
+}
+
+// committed runs the commit hooks in the order they were registered
+func (h *txHooks) committed() {
+	for _, hook := range h.hooks {
+		if hook.onCommit != nil {
+			hook.onCommit()
+		}
+	}
+	h.hooks = nil
+}
+
+// rolledBack runs the rollback hooks registered since mark, newest first, and
+// forgets those hooks so their commit hooks never run
+func (h *txHooks) rolledBack(mark int) {
+	if h == nil {
+		return
+	}
+	for i := len(h.hooks) - 1; i >= mark; i-- {
+		if h.hooks[i].onRollback != nil {
+			h.hooks[i].onRollback()
+		}
+	}
+	h.hooks = h.hooks[:mark]
+}
+
+// hooksOf returns the hooks of the transaction tx belongs to, or nil if tx was
+// not started by this package
+func hooksOf(tx *gorm.DB) *txHooks {
+	if tx.Statement.Context == nil {
+		return nil
+	}
+	hooks, _ := tx.Statement.Context.Value(txHooksKey{}).(*txHooks)
+	return hooks
+}
+
+// AfterCommit registers fn to run once the transaction tx belongs to has been
+// committed. Outside a transaction started by this package fn runs right away.
+func AfterCommit(tx *gorm.DB, fn func()) {
+	hooks := hooksOf(tx)
+	if hooks == nil {
+		fn()
+		return
+	}
+	hooks.hooks = append(hooks.hooks, txHook{onCommit: fn})
+}
+
+// AfterRollback registers fn to run if the transaction tx belongs to, or the
+// savepoint fn was registered in, is rolled back
+func AfterRollback(tx *gorm.DB, fn func()) {
+	if hooks := hooksOf(tx); hooks != nil {
+		hooks.hooks = append(hooks.hooks, txHook{onRollback: fn})
+	}
+}
+
+// begin starts a transaction that carries hooks for AfterCommit and AfterRollback
+func begin(db *gorm.DB) (*gorm.DB, *txHooks) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	hooks := &txHooks{}
+	return db.WithContext(context.WithValue(ctx, txHooksKey{}, hooks)).Begin(), hooks
+}
+
+// Transaction runs fn in a transaction of db outside a request, such as in
+// background jobs, with the same commit and rollback hooks as WithTransaction
+func Transaction(db *gorm.DB, fn TxFn) error {
+	tx, hooks := begin(db)
+	if tx.Error != nil {
+		return fmt.Errorf("failed to begin transaction: %w", tx.Error)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			hooks.rolledBack(0)
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		hooks.rolledBack(0)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		hooks.rolledBack(0)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	hooks.committed()
+	return nil
+}
+
 // WithTransactionResult executes a function within a database transaction.
 // If the function returns an error, the transaction is rolled back.
 // Otherwise, the transaction is committed.
 // This version supports returning a result along with an error.
 // It also supports nested transactions using savepoints.
+// Hooks registered with AfterCommit and AfterRollback run once the outcome of
+// the outermost transaction, or of a rolled back savepoint, is known.
 func WithTransactionResult(c *gin.Context, db *gorm.DB, fn TxFnWithResult) (interface{}, error) {
 	// Check if we're already in a transaction
 	tx, inTransaction := c.Request.Context().Value("tx").(*gorm.DB)
 
 	if !inTransaction {
 		// Start a new transaction if we're not already in one
-		tx = db.Begin()
+		var hooks *txHooks
+		tx, hooks = begin(db)
 		if tx.Error != nil {
 			return nil, fmt.Errorf("failed to begin transaction: %w", tx.Error)
 		}
@@ -44,6 +156,7 @@ func WithTransactionResult(c *gin.Context, db *gorm.DB, fn TxFnWithResult) (inte
 		defer func() {
 			if r := recover(); r != nil {
 				tx.Rollback()
+				hooks.rolledBack(0)
 				panic(r) // Re-throw panic after rollback
 			}
 		}()
@@ -52,14 +165,17 @@ func WithTransactionResult(c *gin.Context, db *gorm.DB, fn TxFnWithResult) (inte
 		result, err := fn(tx)
 		if err != nil {
 			tx.Rollback()
+			hooks.rolledBack(0)
 			return nil, err
 		}
 
 		// Commit the transaction if no error occurred
 		if err := tx.Commit().Error; err != nil {
+			hooks.rolledBack(0)
 			return nil, fmt.Errorf("failed to commit transaction: %w", err)
 		}
 
+		hooks.committed()
 		return result, nil
 	}
 
@@ -71,12 +187,18 @@ func WithTransactionResult(c *gin.Context, db *gorm.DB, fn TxFnWithResult) (inte
 	}
 
 	// Execute the function within the existing transaction
+	hooks := hooksOf(tx)
+	mark := 0
+	if hooks != nil {
+		mark = len(hooks.hooks)
+	}
 	result, err := fn(tx)
 	if err != nil {
 		// Rollback to the savepoint on error
 		if rbErr := tx.Exec("ROLLBACK TO SAVEPOINT " + savepointName).Error; rbErr != nil {
 			return nil, fmt.Errorf("failed to rollback to savepoint: %v (original error: %w)", rbErr, err)
 		}
+		hooks.rolledBack(mark)
 		return nil, err
 	}
 
@@ -0,0 +1,78 @@
+package blobstore_test
+
+import (
+	"context"
+	"io"
+	"meals/blobstore"
+	"meals/config"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := blobstore.NewLocal(t.TempDir())
+
+	assert.Nil(t, store.Put(ctx, "proofs/a.jpg", strings.NewReader("first")))
+	// Putting the same key replaces the file
+	assert.Nil(t, store.Put(ctx, "proofs/a.jpg", strings.NewReader("second")))
+
+	file, err := store.Open(ctx, "proofs/a.jpg")
+	assert.Nil(t, err)
+	data, _ := io.ReadAll(file)
+	file.Close()
+	assert.Equal(t, "second", string(data))
+
+	assert.Nil(t, store.Delete(ctx, "proofs/a.jpg"))
+	_, err = store.Open(ctx, "proofs/a.jpg")
+	assert.Equal(t, blobstore.ErrNotFound, err)
+
+	// Deleting twice is fine
+	assert.Nil(t, store.Delete(ctx, "proofs/a.jpg"))
+}
+
+func TestLocalStoreRejectsKeysOutsideTheStore(t *testing.T) {
+	ctx := context.Background()
+	store := blobstore.NewLocal(t.TempDir())
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "proofs/../../secret", "proofs//a.jpg", "./a.jpg", `proofs\a.jpg`} {
+		assert.Equal(t, blobstore.ErrInvalidKey, store.Put(ctx, key, strings.NewReader("x")), key)
+		_, err := store.Open(ctx, key)
+		assert.Equal(t, blobstore.ErrInvalidKey, err, key)
+	}
+}
+
+func TestNewSelectsDriver(t *testing.T) {
+	store, err := blobstore.New(config.BlobstoreConfig{Driver: "local", LocalPath: t.TempDir()})
+	assert.Nil(t, err)
+	assert.IsType(t, &blobstore.LocalStore{}, store)
+
+	_, err = blobstore.New(config.BlobstoreConfig{Driver: "s3"})
+	assert.Error(t, err)
+}
+
+func TestSignedURLs(t *testing.T) {
+	signer := blobstore.NewURLSigner("secret")
+	now := time.Unix(1700000000, 0)
+	expires := now.Add(5 * time.Minute)
+
+	link, err := url.Parse(signer.SignedURL("/blobs", "proofs/a.jpg", expires))
+	assert.Nil(t, err)
+	assert.Equal(t, "/blobs/proofs/a.jpg", link.Path)
+	query := link.Query()
+
+	assert.True(t, signer.Verify("proofs/a.jpg", query.Get("expires"), query.Get("signature"), now))
+
+	// Links stop working when they expire
+	assert.False(t, signer.Verify("proofs/a.jpg", query.Get("expires"), query.Get("signature"), expires))
+
+	// Signatures are bound to the key, the expiry and the secret
+	assert.False(t, signer.Verify("proofs/b.jpg", query.Get("expires"), query.Get("signature"), now))
+	assert.False(t, signer.Verify("proofs/a.jpg", "1800000000", query.Get("signature"), now))
+	assert.False(t, blobstore.NewURLSigner("other").Verify("proofs/a.jpg", query.Get("expires"), query.Get("signature"), now))
+	assert.False(t, signer.Verify("proofs/a.jpg", query.Get("expires"), "not-hex", now))
+}
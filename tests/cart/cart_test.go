@@ -0,0 +1,83 @@
+package cart_test
+
+import (
+	"meals/cart"
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCartItems(t *testing.T) {
+	now := time.Now()
+	c := cart.Cart{UserID: 1}
+
+	// Items are kept in the order they were added and the subtotal follows them
+	c.Set(cart.Item{MenuMealID: 2, Quantity: 1, UnitPrice: models.NewMoney(1000, "USD"), AddedAt: now.Add(time.Minute)})
+	c.Set(cart.Item{MenuMealID: 1, Quantity: 2, UnitPrice: models.NewMoney(450, "USD"), AddedAt: now})
+	assert.Equal(t, uint(1), c.Items[0].MenuMealID)
+	assert.Equal(t, models.NewMoney(1900, "USD"), c.Subtotal)
+
+	// Setting an existing menu meal replaces it
+	c.Set(cart.Item{MenuMealID: 2, Quantity: 3, UnitPrice: models.NewMoney(1000, "USD"), AddedAt: now.Add(time.Minute)})
+	assert.Equal(t, 2, len(c.Items))
+	assert.Equal(t, models.NewMoney(3900, "USD"), c.Subtotal)
+
+	// Checkout converts items into order lines
+	orderItems := c.OrderItems()
+	assert.Equal(t, []models.OrderItem{
+		{MenuMealID: 1, Quantity: 2},
+		{MenuMealID: 2, Quantity: 3},
+	}, orderItems)
+
+	assert.True(t, c.Remove(1))
+	assert.False(t, c.Remove(1))
+	assert.Nil(t, c.Find(1))
+	assert.Equal(t, models.NewMoney(3000, "USD"), c.Subtotal)
+}
+
+func TestCartServiceSlidingExpiry(t *testing.T) {
+	client := testutils.SetupTestRedis()
+	service := cart.NewService(client, time.Hour)
+	const userID = 900001
+	defer service.Clear(userID)
+
+	// A missing cart is empty
+	empty, err := service.Get(userID)
+	assert.Nil(t, err)
+	assert.True(t, empty.IsEmpty())
+	assert.Nil(t, empty.ExpiresAt)
+
+	// Changes are persisted and set the expiry
+	_, err = service.Update(userID, func(c *cart.Cart) error {
+		c.Set(cart.Item{MenuMealID: 7, Quantity: 2, UnitPrice: models.NewMoney(500, "USD"), AddedAt: time.Now()})
+		return nil
+	})
+	assert.Nil(t, err)
+
+	client.Expire("cart:900001", time.Minute)
+
+	// The next change slides the expiry forward again
+	updated, err := service.Update(userID, func(c *cart.Cart) error {
+		c.Find(7).Quantity = 3
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, updated.Items[0].Quantity)
+	assert.True(t, client.TTL("cart:900001").Val() > 59*time.Minute)
+
+	loaded, err := service.Get(userID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(loaded.Items))
+	assert.NotNil(t, loaded.ExpiresAt)
+
+	// Removing the last item deletes the cart
+	_, err = service.Update(userID, func(c *cart.Cart) error {
+		c.Remove(7)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), client.Exists("cart:900001").Val())
+}
@@ -0,0 +1,58 @@
+package config_test
+
+import (
+	"meals/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCutoffForDeliveryDate(t *testing.T) {
+	ordering := config.OrderingConfig{
+		CutoffLeadHours: 48,
+		CutoffTime:      "18:00",
+		KitchenTimezone: "America/New_York",
+	}
+	loc := ordering.Location()
+	assert.Equal(t, "America/New_York", loc.String())
+
+	// Monday delivery closes on Saturday at 18:00 kitchen time
+	monday := time.Date(2025, time.June, 2, 0, 0, 0, 0, time.UTC)
+	cutoff := ordering.CutoffFor(monday)
+	assert.Equal(t, time.Date(2025, time.May, 31, 18, 0, 0, 0, loc), cutoff)
+	assert.Equal(t, time.Saturday, cutoff.In(loc).Weekday())
+
+	// Only the calendar date of the delivery matters
+	lateMonday := time.Date(2025, time.June, 2, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, cutoff, ordering.CutoffFor(lateMonday))
+}
+
+func TestCutoffAcrossDaylightSaving(t *testing.T) {
+	ordering := config.OrderingConfig{
+		CutoffLeadHours: 48,
+		CutoffTime:      "18:00",
+		KitchenTimezone: "America/New_York",
+	}
+	loc := ordering.Location()
+
+	// Clocks go forward on Sunday 9 March 2025; Monday's delivery still closes
+	// at 18:00 on Saturday, not an hour earlier
+	monday := time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, time.March, 8, 18, 0, 0, 0, loc), ordering.CutoffFor(monday))
+
+	// Hours beyond whole days are taken off the local clock time
+	ordering.CutoffLeadHours = 50
+	assert.Equal(t, time.Date(2025, time.March, 8, 16, 0, 0, 0, loc), ordering.CutoffFor(monday))
+}
+
+func TestCutoffDefaults(t *testing.T) {
+	// Unknown time zones fall back to UTC
+	ordering := config.OrderingConfig{KitchenTimezone: "Mars/Olympus_Mons"}
+	assert.Equal(t, time.UTC, ordering.Location())
+
+	// With no lead time the cutoff is the cutoff time on the delivery day itself
+	ordering = config.OrderingConfig{CutoffTime: "09:30"}
+	delivery := time.Date(2025, time.June, 4, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, time.June, 4, 9, 30, 0, 0, time.UTC), ordering.CutoffFor(delivery))
+}
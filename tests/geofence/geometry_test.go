@@ -0,0 +1,86 @@
+package geofence_test
+
+import (
+	"encoding/json"
+	"errors"
+	"meals/geofence"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// square is a Polygon around lower Manhattan with a hole around Battery Park
+const square = `{
+	"type": "Polygon",
+	"coordinates": [
+		[[-74.02, 40.70], [-73.97, 40.70], [-73.97, 40.75], [-74.02, 40.75], [-74.02, 40.70]],
+		[[-74.02, 40.70], [-74.01, 40.70], [-74.01, 40.71], [-74.02, 40.71], [-74.02, 40.70]]
+	]
+}`
+
+func parse(t *testing.T, data string) geofence.Geometry {
+	var geometry geofence.Geometry
+	assert.NoError(t, json.Unmarshal([]byte(data), &geometry))
+	return geometry
+}
+
+func TestGeometryContains(t *testing.T) {
+	geometry := parse(t, square)
+	assert.NoError(t, geometry.Validate())
+
+	// Wall Street is inside, Battery Park in the hole and Downtown Brooklyn and Harlem outside
+	assert.True(t, geometry.Contains(40.7060, -74.0088))
+	assert.False(t, geometry.Contains(40.7033, -74.0170))
+	assert.False(t, geometry.Contains(40.6928, -73.9903))
+	assert.False(t, geometry.Contains(40.8116, -73.9465))
+}
+
+func TestGeometryMultiPolygonAndFeature(t *testing.T) {
+	feature := `{
+		"type": "Feature",
+		"properties": {"name": "two squares"},
+		"geometry": {
+			"type": "MultiPolygon",
+			"coordinates": [
+				[[[0, 0], [1, 0], [1, 1], [0, 1], [0, 0]]],
+				[[[2, 0], [3, 0], [3, 1], [2, 1], [2, 0]]]
+			]
+		}
+	}`
+	geometry := parse(t, feature)
+	assert.NoError(t, geometry.Validate())
+	assert.Equal(t, geofence.TypeMultiPolygon, geometry.Type)
+	assert.True(t, geometry.Contains(0.5, 2.5))
+	assert.False(t, geometry.Contains(0.5, 1.5))
+}
+
+func TestGeometryRoundTrip(t *testing.T) {
+	geometry := parse(t, square)
+	data, err := json.Marshal(geometry)
+	assert.NoError(t, err)
+
+	// Polygons are written back as Polygons, not MultiPolygons
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "Polygon", decoded["type"])
+	assert.Equal(t, geometry, parse(t, string(data)))
+}
+
+func TestGeometryValidate(t *testing.T) {
+	var geometry geofence.Geometry
+	err := json.Unmarshal([]byte(`{"type": "Point", "coordinates": [0, 0]}`), &geometry)
+	assert.True(t, errors.Is(err, geofence.ErrInvalidGeometry))
+
+	invalid := map[string]string{
+		"open ring":    `{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1]]]}`,
+		"short ring":   `{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [0, 0]]]}`,
+		"out of range": `{"type": "Polygon", "coordinates": [[[0, 0], [200, 0], [1, 1], [0, 0]]]}`,
+		"no rings":     `{"type": "Polygon", "coordinates": []}`,
+	}
+	for name, data := range invalid {
+		err := parse(t, data).Validate()
+		assert.True(t, errors.Is(err, geofence.ErrInvalidGeometry), name)
+	}
+
+	assert.Error(t, geofence.Geometry{}.Validate())
+}
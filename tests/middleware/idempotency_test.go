@@ -0,0 +1,133 @@
+package middleware_test
+
+import (
+	"io"
+	"meals/handlers"
+	"meals/middleware"
+	"meals/models"
+	"meals/tests/testutils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupIdempotentRouter returns a router whose POST /things echoes the request
+// body and counts how often the handler ran. Requests are made as user 1 when
+// authenticated is set, as auth.RequireRole would.
+func setupIdempotentRouter(calls *int, status int, authenticated bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	client := testutils.SetupTestRedis()
+
+	router := gin.New()
+	if authenticated {
+		router.Use(func(c *gin.Context) {
+			c.Set("userID", uint(1))
+		})
+	}
+	router.POST("/things", middleware.Idempotency(client, time.Hour), func(c *gin.Context) {
+		*calls++
+		body, _ := io.ReadAll(c.Request.Body)
+		c.JSON(status, gin.H{"call": *calls, "body": string(body)})
+	})
+	return router
+}
+
+func post(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	calls := 0
+	router := setupIdempotentRouter(&calls, http.StatusCreated, true)
+	key := uuid.New().String()
+
+	first := post(router, key, `{"name":"soup"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	// A retry gets the stored response without running the handler again
+	retry := post(router, key, `{"name":"soup"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, 1, calls)
+
+	// Reusing the key for a different body is rejected
+	reused := post(router, key, `{"name":"salad"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	assert.Contains(t, reused.Body.String(), middleware.ErrIdempotencyKeyReused)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyWithoutKey(t *testing.T) {
+	calls := 0
+	router := setupIdempotentRouter(&calls, http.StatusCreated, true)
+
+	post(router, "", `{"name":"soup"}`)
+	post(router, "", `{"name":"soup"}`)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	calls := 0
+	router := setupIdempotentRouter(&calls, http.StatusInternalServerError, true)
+	key := uuid.New().String()
+
+	post(router, key, `{"name":"soup"}`)
+	retry := post(router, key, `{"name":"soup"}`)
+	assert.Equal(t, http.StatusInternalServerError, retry.Code)
+	assert.Empty(t, retry.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyRequiresUser(t *testing.T) {
+	calls := 0
+	router := setupIdempotentRouter(&calls, http.StatusCreated, false)
+
+	// Anonymous requests with a key are rejected rather than scoped by IP
+	w := post(router, uuid.New().String(), `{"name":"soup"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, 0, calls)
+
+	// Without a key they pass through
+	w = post(router, "", `{"name":"soup"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotencyCreatesOneMeal(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+	client := testutils.SetupTestRedis()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userID", uint(1))
+	})
+	router.POST("/things", middleware.Idempotency(client, time.Hour), handlers.CreateMealHandler)
+
+	// A retried create with the same key, user and body stores one meal
+	key := uuid.New().String()
+	body := `{"name":"Lentil soup","price":{"amount":1299,"currency":"USD"}}`
+	first := post(router, key, body)
+	retry := post(router, key, body)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+
+	var count int64
+	db.Model(&models.Meal{}).Where("name = ?", "Lentil soup").Count(&count)
+	assert.Equal(t, int64(1), count)
+}
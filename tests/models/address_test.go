@@ -0,0 +1,90 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressCreation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+
+	// Arrange
+	testUser := models.User{
+		Provider:    "google",
+		Email:       "address-test@example.com",
+		Name:        "Address Test",
+		AccessToken: "address-token",
+		ExpiresAt:   testTime,
+		IDToken:     "address-id-token",
+		UserID:      "address123",
+		UserType:    models.UserTypeCustomer,
+	}
+	assert.Nil(t, db.Create(&testUser).Error)
+
+	profile := models.UserProfile{UserID: testUser.ID}
+	assert.Nil(t, db.Create(&profile).Error)
+
+	latitude, longitude := 40.75, -73.99
+	home := models.Address{
+		ProfileID:    profile.ID,
+		Label:        "Home",
+		Line1:        "12 Main St",
+		City:         "Springfield",
+		PostalCode:   "62701",
+		Instructions: "Ring twice",
+		Latitude:     &latitude,
+		Longitude:    &longitude,
+		IsDefault:    true,
+	}
+
+	// Act
+	result := db.Create(&home)
+
+	// Assert
+	assert.Nil(t, result.Error)
+
+	var retrieved models.UserProfile
+	assert.Nil(t, db.Preload("Addresses").First(&retrieved, profile.ID).Error)
+	assert.Len(t, retrieved.Addresses, 1)
+	assert.Equal(t, "Ring twice", retrieved.Addresses[0].Instructions)
+	assert.True(t, retrieved.Addresses[0].HasLocation())
+
+	// A profile has at most one live default address
+	work := models.Address{ProfileID: profile.ID, Line1: "1 Office Park", City: "Springfield", PostalCode: "62702", IsDefault: true}
+	assert.Error(t, db.Create(&work).Error)
+
+	work.ID = 0
+	work.IsDefault = false
+	assert.Nil(t, db.Create(&work).Error)
+
+	assert.Nil(t, db.Delete(&home).Error)
+	assert.Nil(t, db.Model(&work).Update("is_default", true).Error)
+}
+
+func TestAddressValidation(t *testing.T) {
+	valid := models.Address{Line1: "12 Main St", City: "Springfield", PostalCode: "62701"}
+	assert.Empty(t, valid.ValidateAddress())
+
+	latitude := 91.0
+	invalid := models.Address{Label: "A label that is much too long to fit in fifty characters", Latitude: &latitude}
+	assert.Equal(t, []string{
+		"Line1 is required",
+		"City is required",
+		"PostalCode is required",
+		"Label must be at most 50 characters",
+		"Latitude and longitude must be given together",
+		"Latitude must be between -90 and 90",
+	}, invalid.ValidateAddress())
+}
+
+func TestAddressFormat(t *testing.T) {
+	address := models.Address{Line1: "12 Main St", Line2: "Apt 4", City: "Springfield", Region: "IL", PostalCode: "62701"}
+	assert.Equal(t, "12 Main St, Apt 4, Springfield, IL 62701", address.Format())
+
+	address = models.Address{Line1: "12 Main St", City: "Springfield", PostalCode: "62701"}
+	assert.Equal(t, "12 Main St, Springfield, 62701", address.Format())
+}
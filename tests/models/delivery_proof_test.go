@@ -0,0 +1,47 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliveryProofCreation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+
+	// Arrange
+	user, menuMeal := createOrderFixtures(db, "delivery-proof")
+	order := models.Order{
+		UserID: user.ID,
+		Status: models.OrderStatusDelivered,
+		Items:  []models.OrderItem{{MenuMealID: menuMeal.ID, Quantity: 1}},
+	}
+	db.Create(&order)
+
+	proof := models.DeliveryProof{
+		OrderID:       order.ID,
+		StopID:        1,
+		DriverID:      2,
+		RecipientName: "Front desk",
+		Photo:         models.BlobRef{Key: "proofs/photo.jpg", ContentType: "image/jpeg", Size: 2048},
+	}
+
+	// Act
+	result := db.Create(&proof)
+
+	// Assert
+	assert.Nil(t, result.Error)
+
+	var retrieved models.DeliveryProof
+	assert.Nil(t, db.Where("order_id = ?", order.ID).First(&retrieved).Error)
+	assert.Equal(t, proof.Photo, retrieved.Photo)
+	assert.True(t, retrieved.Signature.IsEmpty())
+	assert.Equal(t, []models.BlobRef{proof.Photo}, retrieved.Blobs())
+
+	// An order has at most one proof
+	duplicate := models.DeliveryProof{OrderID: order.ID, StopID: 1, DriverID: 2}
+	assert.Error(t, db.Create(&duplicate).Error)
+}
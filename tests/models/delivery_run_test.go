@@ -0,0 +1,122 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeliveryRunCreation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+
+	// Arrange
+	user, menuMeal := createOrderFixtures(db, "delivery-run")
+	deliveryDate := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	order := models.Order{
+		UserID:       user.ID,
+		Status:       models.OrderStatusPreparing,
+		DeliveryDate: deliveryDate,
+		Items:        []models.OrderItem{{MenuMealID: menuMeal.ID, Quantity: 1}},
+	}
+	db.Create(&order)
+
+	run := models.DeliveryRun{
+		DeliveryDate: deliveryDate,
+		Name:         "North loop",
+		Status:       models.DeliveryRunStatusPlanned,
+		Stops: []models.DeliveryStop{
+			{OrderID: order.ID, Sequence: 1, Status: models.DeliveryStopStatusPending},
+		},
+	}
+
+	// Act
+	result := db.Create(&run)
+
+	// Assert
+	assert.Nil(t, result.Error)
+
+	var retrieved models.DeliveryRun
+	assert.Nil(t, db.Preload("Stops").First(&retrieved, run.ID).Error)
+	assert.Len(t, retrieved.Stops, 1)
+	assert.Equal(t, order.ID, retrieved.Stops[0].OrderID)
+
+	// An order is on at most one live stop
+	other := models.DeliveryRun{DeliveryDate: deliveryDate, Status: models.DeliveryRunStatusPlanned}
+	db.Create(&other)
+	duplicate := models.DeliveryStop{RunID: other.ID, OrderID: order.ID, Sequence: 1, Status: models.DeliveryStopStatusPending}
+	assert.Error(t, db.Create(&duplicate).Error)
+
+	// Deleting a run frees its orders
+	assert.Nil(t, db.Delete(&run).Error)
+	duplicate.ID = 0
+	assert.Nil(t, db.Create(&duplicate).Error)
+}
+
+func TestDeliveryStopTransitions(t *testing.T) {
+	pending := models.DeliveryStopStatusPending
+	assert.True(t, pending.CanMoveTo(models.DeliveryStopStatusArrived))
+	assert.True(t, pending.CanMoveTo(models.DeliveryStopStatusDelivered))
+	assert.True(t, pending.CanMoveTo(models.DeliveryStopStatusFailed))
+	assert.False(t, pending.CanMoveTo(models.DeliveryStopStatusPending))
+
+	arrived := models.DeliveryStopStatusArrived
+	assert.False(t, arrived.CanMoveTo(models.DeliveryStopStatusArrived))
+	assert.True(t, arrived.CanMoveTo(models.DeliveryStopStatusDelivered))
+
+	// Done stops stay done
+	for _, done := range []models.DeliveryStopStatus{models.DeliveryStopStatusDelivered, models.DeliveryStopStatusFailed} {
+		assert.True(t, done.IsDone())
+		assert.False(t, done.CanMoveTo(models.DeliveryStopStatusArrived))
+		assert.False(t, done.CanMoveTo(models.DeliveryStopStatusDelivered))
+		assert.False(t, done.CanMoveTo(models.DeliveryStopStatusFailed))
+	}
+}
+
+func TestDeliveryRunProgress(t *testing.T) {
+	driverID := uint(7)
+	run := models.DeliveryRun{DriverID: &driverID, Status: models.DeliveryRunStatusPlanned}
+	assert.True(t, run.IsAssignedTo(7))
+	assert.False(t, run.IsAssignedTo(8))
+	assert.False(t, (&models.DeliveryRun{}).IsAssignedTo(7))
+
+	// An empty run is never complete
+	assert.True(t, run.IsEditable())
+	assert.False(t, run.IsComplete())
+
+	run.Stops = []models.DeliveryStop{
+		{Status: models.DeliveryStopStatusDelivered},
+		{Status: models.DeliveryStopStatusArrived},
+	}
+	assert.False(t, run.IsComplete())
+
+	run.Stops[1].Status = models.DeliveryStopStatusFailed
+	assert.True(t, run.IsComplete())
+
+	run.Status = models.DeliveryRunStatusInProgress
+	assert.False(t, run.IsEditable())
+}
+
+func TestDeliveryRunEstimateArrivals(t *testing.T) {
+	leg := func(seconds int) *int { return &seconds }
+	run := models.DeliveryRun{Stops: []models.DeliveryStop{
+		{Status: models.DeliveryStopStatusDelivered, LegDurationSeconds: leg(600)},
+		{Status: models.DeliveryStopStatusArrived, LegDurationSeconds: leg(300)},
+		{Status: models.DeliveryStopStatusPending, LegDurationSeconds: leg(420)},
+		{Status: models.DeliveryStopStatusPending}, // No location
+		{Status: models.DeliveryStopStatusPending, LegDurationSeconds: leg(60)},
+	}}
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	run.EstimateArrivals(now, 3*time.Minute)
+
+	assert.Nil(t, run.Stops[0].EstimatedArrival)
+	// The driver is already at the arrived stop
+	assert.Equal(t, now, *run.Stops[1].EstimatedArrival)
+	assert.Equal(t, now.Add(10*time.Minute), *run.Stops[2].EstimatedArrival)
+	assert.Nil(t, run.Stops[3].EstimatedArrival)
+	assert.Equal(t, now.Add(14*time.Minute), *run.Stops[4].EstimatedArrival)
+}
@@ -0,0 +1,108 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/clause"
+)
+
+func TestDeliverySlotCreation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+
+	// Arrange
+	template := models.SlotTemplate{
+		Zone:            "local",
+		Weekday:         "Tuesday",
+		StartTime:       "17:00",
+		EndTime:         "19:00",
+		Drivers:         2,
+		OrdersPerDriver: 10,
+		Active:          true,
+	}
+	assert.Nil(t, db.Create(&template).Error)
+
+	slot, ok := template.SlotOn(time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC), time.UTC)
+	assert.True(t, ok)
+
+	// Act
+	result := db.Create(&slot)
+
+	// Assert
+	assert.Nil(t, result.Error)
+
+	var retrieved models.DeliverySlot
+	assert.Nil(t, db.First(&retrieved, slot.ID).Error)
+	assert.Equal(t, 20, retrieved.Capacity)
+	assert.Equal(t, 0, retrieved.Booked)
+
+	// A template has one slot per date, so generating it again is a no-op
+	again, _ := template.SlotOn(time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC), time.UTC)
+	result = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&again)
+	assert.Nil(t, result.Error)
+	assert.Equal(t, int64(0), result.RowsAffected)
+}
+
+func TestSlotTemplateValidation(t *testing.T) {
+	valid := models.SlotTemplate{Zone: "local", Weekday: "tue", StartTime: "17:00", EndTime: "19:00", Drivers: 1, OrdersPerDriver: 8}
+	assert.Empty(t, valid.ValidateSlotTemplate())
+
+	invalid := models.SlotTemplate{Weekday: "someday", StartTime: "19:00", EndTime: "17:00"}
+	assert.Equal(t, []string{
+		"Zone is required",
+		"Weekday must be a weekday name",
+		"EndTime must be after StartTime",
+		"Drivers and OrdersPerDriver must be positive",
+	}, invalid.ValidateSlotTemplate())
+
+	malformed := models.SlotTemplate{Zone: "local", Weekday: "Tuesday", StartTime: "5pm", EndTime: "25:00", Drivers: 1, OrdersPerDriver: 1}
+	assert.Equal(t, []string{
+		"StartTime must be in HH:MM format",
+		"EndTime must be in HH:MM format",
+	}, malformed.ValidateSlotTemplate())
+}
+
+func TestSlotTemplateSlotOn(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+	template := models.SlotTemplate{Zone: "local", Weekday: "Tuesday", StartTime: "17:00", EndTime: "19:30", Drivers: 3, OrdersPerDriver: 6}
+
+	// Monday is not one of the template's days
+	_, ok := template.SlotOn(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), loc)
+	assert.False(t, ok)
+
+	// The window is on the kitchen's clock, on both sides of a DST change
+	for _, date := range []time.Time{
+		time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC),
+	} {
+		slot, ok := template.SlotOn(date, loc)
+		assert.True(t, ok)
+		assert.Equal(t, date, slot.DeliveryDate)
+		assert.Equal(t, "17:00", slot.StartsAt.In(loc).Format("15:04"))
+		assert.Equal(t, "19:30", slot.EndsAt.In(loc).Format("15:04"))
+		assert.Equal(t, "Tue 17:00–19:30", slot.Label)
+		assert.Equal(t, 18, slot.Capacity)
+	}
+}
+
+func TestDeliverySlotAvailability(t *testing.T) {
+	startsAt := time.Date(2025, 3, 11, 17, 0, 0, 0, time.UTC)
+	slot := models.DeliverySlot{StartsAt: startsAt, Capacity: 2, Booked: 1}
+
+	assert.Equal(t, 1, slot.Remaining())
+	assert.True(t, slot.IsAvailableAt(startsAt.Add(-time.Hour)))
+	assert.False(t, slot.IsAvailableAt(startsAt))
+
+	slot.Booked = 2
+	assert.Equal(t, 0, slot.Remaining())
+	assert.False(t, slot.IsAvailableAt(startsAt.Add(-time.Hour)))
+
+	// Closing a slot may leave it with more bookings than capacity
+	slot.Capacity = 1
+	assert.Equal(t, 0, slot.Remaining())
+}
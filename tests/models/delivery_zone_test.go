@@ -0,0 +1,80 @@
+package models_test
+
+import (
+	"encoding/json"
+	"meals/geofence"
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unitSquare returns a boundary from 0,0 to 1,1
+func unitSquare(t *testing.T) geofence.Geometry {
+	var boundary geofence.Geometry
+	err := json.Unmarshal([]byte(`{"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 1], [0, 0]]]}`), &boundary)
+	assert.NoError(t, err)
+	return boundary
+}
+
+func TestDeliveryZoneCreation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+
+	// Arrange
+	zone := models.DeliveryZone{
+		Code:         "midtown",
+		Name:         "Midtown",
+		Boundary:     unitSquare(t),
+		DeliveryFee:  399,
+		MinimumOrder: 2000,
+		DeliveryDays: []string{"Monday", "Thursday"},
+		Kitchen:      "chelsea",
+	}
+
+	// Act
+	result := db.Create(&zone)
+
+	// Assert
+	assert.Nil(t, result.Error)
+
+	var retrieved models.DeliveryZone
+	assert.Nil(t, db.First(&retrieved, zone.ID).Error)
+	assert.Equal(t, zone.Boundary, retrieved.Boundary)
+	assert.Equal(t, []string{"Monday", "Thursday"}, retrieved.DeliveryDays)
+	assert.True(t, retrieved.Contains(0.5, 0.5))
+
+	// Codes are unique among live zones
+	duplicate := models.DeliveryZone{Code: "midtown", Boundary: unitSquare(t)}
+	assert.Error(t, db.Create(&duplicate).Error)
+
+	assert.Nil(t, db.Delete(&zone).Error)
+	assert.Nil(t, db.Create(&duplicate).Error)
+}
+
+func TestDeliveryZoneValidation(t *testing.T) {
+	valid := models.DeliveryZone{Code: "midtown", Boundary: unitSquare(t), DeliveryDays: []string{"mon"}}
+	assert.Empty(t, valid.ValidateDeliveryZone())
+
+	invalid := models.DeliveryZone{Code: "mid town", DeliveryFee: -1, DeliveryDays: []string{"someday"}}
+	assert.Equal(t, []string{
+		"Code must be at most 32 characters without spaces",
+		"Boundary must be a GeoJSON Polygon or MultiPolygon: invalid geometry: no polygons",
+		"DeliveryFee and MinimumOrder must not be negative",
+		"DeliveryDays must be weekday names",
+	}, invalid.ValidateDeliveryZone())
+}
+
+func TestDeliveryZoneDeliversOn(t *testing.T) {
+	monday := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	zone := models.DeliveryZone{DeliveryDays: []string{"Monday", "Thursday"}}
+	assert.True(t, zone.DeliversOn(monday))
+	assert.False(t, zone.DeliversOn(monday.AddDate(0, 0, 1)))
+	assert.True(t, zone.DeliversOn(monday.AddDate(0, 0, 3)))
+
+	// Zones without delivery days are delivered every day
+	assert.True(t, (&models.DeliveryZone{}).DeliversOn(monday.AddDate(0, 0, 1)))
+}
@@ -0,0 +1,90 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDietaryPreferencesPersisted(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+
+	// Arrange
+	testUser := models.User{
+		Provider:    "google",
+		Email:       "dietary-test@example.com",
+		Name:        "Dietary Test",
+		AccessToken: "dietary-token",
+		ExpiresAt:   testTime,
+		IDToken:     "dietary-id-token",
+		UserID:      "dietary123",
+		UserType:    models.UserTypeCustomer,
+	}
+	assert.Nil(t, db.Create(&testUser).Error)
+
+	profile := models.UserProfile{UserID: testUser.ID, Diets: []string{"vegan"}, Allergens: []string{"peanut"}}
+	meal := models.Meal{Name: "Satay tofu", Price: models.NewMoney(1299, "USD"), Diets: []string{"vegan"}, Allergens: []string{"peanut", "soy"}}
+
+	// Act
+	assert.Nil(t, db.Create(&profile).Error)
+	assert.Nil(t, db.Create(&meal).Error)
+
+	// Assert
+	var retrievedProfile models.UserProfile
+	assert.Nil(t, db.First(&retrievedProfile, profile.ID).Error)
+	assert.Equal(t, []string{"vegan"}, retrievedProfile.Diets)
+	assert.Equal(t, []string{"peanut"}, retrievedProfile.Allergens)
+
+	var retrievedMeal models.Meal
+	assert.Nil(t, db.First(&retrievedMeal, meal.ID).Error)
+	assert.Equal(t, []string{"peanut", "soy"}, retrievedMeal.Allergens)
+	assert.Equal(t, []string{"peanut"}, retrievedProfile.DietaryConflicts(retrievedMeal).Allergens)
+}
+
+func TestNormalizeLabels(t *testing.T) {
+	assert.Equal(t, []string{"gluten_free", "vegan"}, models.NormalizeLabels([]string{" Vegan", "gluten-free", "vegan", ""}))
+	assert.Equal(t, []string{"tree_nut"}, models.NormalizeLabels([]string{"Tree nut"}))
+	assert.Empty(t, models.NormalizeLabels(nil))
+}
+
+func TestValidateLabels(t *testing.T) {
+	assert.Empty(t, models.ValidateLabels("Diets", []string{"vegan", "halal"}, models.Diets))
+	assert.Len(t, models.ValidateLabels("Allergens", []string{"peanut", "kryptonite"}, models.Allergens), 1)
+
+	meal := models.Meal{Name: "Curry", Price: models.NewMoney(999, "USD"), Diets: []string{"carnivore"}}
+	assert.Contains(t, meal.ValidateMeal()[0], "Diets must be among")
+}
+
+func TestMealSuits(t *testing.T) {
+	vegan := models.Meal{Diets: []string{models.DietVegan}}
+	assert.True(t, vegan.Suits(models.DietVegan))
+	assert.True(t, vegan.Suits(models.DietVegetarian))
+	assert.True(t, vegan.Suits(models.DietDairyFree))
+	assert.False(t, vegan.Suits(models.DietHalal))
+
+	vegetarian := models.Meal{Diets: []string{models.DietVegetarian}}
+	assert.False(t, vegetarian.Suits(models.DietVegan))
+	assert.True(t, vegetarian.Suits(models.DietPescatarian))
+}
+
+func TestDietaryConflicts(t *testing.T) {
+	profile := models.UserProfile{
+		Diets:     []string{models.DietHalal},
+		Allergens: []string{models.AllergenShellfish, models.AllergenPeanut},
+	}
+
+	prawns := models.Meal{Allergens: []string{models.AllergenShellfish}}
+	assert.Equal(t, models.DietaryConflicts{
+		Allergens: []string{models.AllergenShellfish},
+		Diets:     []string{models.DietHalal},
+	}, profile.DietaryConflicts(prawns))
+
+	chicken := models.Meal{Diets: []string{models.DietHalal}}
+	assert.True(t, profile.DietaryConflicts(chicken).IsEmpty())
+
+	// Profiles without preferences suit every meal
+	assert.True(t, (&models.UserProfile{}).DietaryConflicts(prawns).IsEmpty())
+}
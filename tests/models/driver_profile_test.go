@@ -0,0 +1,98 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDriverProfileCreation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+
+	// Arrange
+	driver := models.User{
+		Provider:    "google",
+		Email:       "driver-test@example.com",
+		Name:        "Driver Test",
+		AccessToken: "driver-token",
+		ExpiresAt:   testTime,
+		IDToken:     "driver-id-token",
+		UserID:      "driver123",
+		UserType:    models.UserTypeDriver,
+	}
+	db.Create(&driver)
+
+	expires := time.Date(2030, 6, 30, 0, 0, 0, 0, time.UTC)
+	profile := models.DriverProfile{
+		UserID:           driver.ID,
+		VehicleType:      models.VehicleTypeCar,
+		LicensePlate:     "ABC1234",
+		LicenseNumber:    "D1234567",
+		LicenseExpiresOn: &expires,
+		Status:           models.DriverStatusAvailable,
+		HomeZone:         "central",
+	}
+
+	// Act
+	result := db.Create(&profile)
+
+	// Assert
+	assert.Nil(t, result.Error)
+
+	var retrieved models.DriverProfile
+	assert.Nil(t, db.Where("user_id = ?", driver.ID).First(&retrieved).Error)
+	assert.Equal(t, models.DriverStatusAvailable, retrieved.Status)
+	assert.Equal(t, "2030-06-30", retrieved.LicenseExpiresOn.Format("2006-01-02"))
+
+	// A user has at most one driver profile
+	duplicate := models.DriverProfile{UserID: driver.ID, Status: models.DriverStatusOffline}
+	assert.Error(t, db.Create(&duplicate).Error)
+}
+
+func TestDriverProfileValidation(t *testing.T) {
+	offline := models.DriverProfile{UserID: 1, Status: models.DriverStatusOffline}
+	assert.Empty(t, offline.ValidateDriverProfile())
+
+	// Going on duty needs a vehicle, and a license for anything but a bicycle
+	available := models.DriverProfile{UserID: 1, Status: models.DriverStatusAvailable}
+	assert.Equal(t, []string{"VehicleType is required to be available"}, available.ValidateDriverProfile())
+
+	available.VehicleType = models.VehicleTypeScooter
+	assert.Equal(t, []string{"LicenseNumber and LicenseExpiresOn are required to drive a scooter"},
+		available.ValidateDriverProfile())
+
+	available.VehicleType = models.VehicleTypeBicycle
+	assert.Empty(t, available.ValidateDriverProfile())
+
+	invalid := models.DriverProfile{Status: "sleeping", VehicleType: "boat"}
+	assert.Equal(t, []string{
+		"UserID is required",
+		"Status must be available, busy or offline",
+		"VehicleType must be bicycle, scooter, motorcycle, car or van",
+	}, invalid.ValidateDriverProfile())
+}
+
+func TestDriverProfileAvailability(t *testing.T) {
+	expires := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	driver := models.DriverProfile{
+		Status:           models.DriverStatusAvailable,
+		VehicleType:      models.VehicleTypeCar,
+		LicenseNumber:    "D1234567",
+		LicenseExpiresOn: &expires,
+	}
+
+	// Licenses are valid through their expiry date
+	assert.True(t, driver.IsAvailableAt(time.Date(2025, 3, 31, 22, 0, 0, 0, time.UTC)))
+	assert.False(t, driver.IsAvailableAt(time.Date(2025, 4, 1, 8, 0, 0, 0, time.UTC)))
+
+	driver.Status = models.DriverStatusBusy
+	assert.False(t, driver.IsAvailableAt(time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)))
+
+	// Cyclists need no license
+	cyclist := models.DriverProfile{Status: models.DriverStatusAvailable, VehicleType: models.VehicleTypeBicycle}
+	assert.True(t, cyclist.IsAvailableAt(time.Date(2025, 4, 1, 8, 0, 0, 0, time.UTC)))
+}
@@ -0,0 +1,113 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngredientCreation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+
+	// Arrange
+	peanuts := models.Ingredient{
+		Name:      "Peanuts",
+		Allergens: []string{models.AllergenPeanut},
+		Nutrition: models.NutritionFacts{Calories: 567, Protein: 25.8, Fat: 49.2},
+	}
+	meal := models.Meal{Name: "Satay noodles", Price: models.NewMoney(1299, "USD")}
+
+	// Act
+	assert.Nil(t, db.Create(&peanuts).Error)
+	assert.Nil(t, db.Create(&meal).Error)
+
+	line := models.MealIngredient{MealID: meal.ID, IngredientID: peanuts.ID, Quantity: 30, Unit: models.UnitGram}
+	assert.Nil(t, db.Create(&line).Error)
+
+	// Assert
+	var retrieved models.Meal
+	assert.Nil(t, db.Preload("Ingredients.Ingredient").First(&retrieved, meal.ID).Error)
+	assert.Len(t, retrieved.Ingredients, 1)
+	assert.Equal(t, []string{models.AllergenPeanut}, retrieved.Ingredients[0].Ingredient.Allergens)
+	assert.Equal(t, 567.0, retrieved.Ingredients[0].Ingredient.Nutrition.Calories)
+
+	// An ingredient is listed once per meal
+	duplicate := models.MealIngredient{MealID: meal.ID, IngredientID: peanuts.ID, Quantity: 10, Unit: models.UnitGram}
+	assert.Error(t, db.Create(&duplicate).Error)
+
+	// Names are unique among live ingredients
+	assert.Error(t, db.Create(&models.Ingredient{Name: "Peanuts"}).Error)
+}
+
+func TestIngredientValidation(t *testing.T) {
+	valid := models.Ingredient{Name: "Egg", Allergens: []string{"egg"}, GramsPerPiece: 50}
+	assert.Empty(t, valid.ValidateIngredient())
+
+	invalid := models.Ingredient{Allergens: []string{"nightshade"}, Nutrition: models.NutritionFacts{Fat: -1}, GramsPerPiece: -1}
+	assert.Equal(t, []string{
+		"Name is required",
+		"Allergens must be among peanut, tree_nut, gluten, dairy, egg, soy, fish, shellfish, sesame, mustard, celery, sulphites",
+		"Nutrition values must not be negative",
+		"GramsPerPiece must not be negative",
+	}, invalid.ValidateIngredient())
+
+	line := models.MealIngredient{IngredientID: 1, Quantity: 0, Unit: "cup"}
+	assert.Equal(t, []string{
+		"Quantity must be positive",
+		"Unit must be among g, kg, ml, l, piece",
+	}, line.ValidateMealIngredient())
+}
+
+func TestMealIngredientGrams(t *testing.T) {
+	egg := models.Ingredient{GramsPerPiece: 50}
+
+	assert.Equal(t, 250.0, (&models.MealIngredient{Quantity: 250, Unit: models.UnitGram}).Grams())
+	assert.Equal(t, 1500.0, (&models.MealIngredient{Quantity: 1.5, Unit: models.UnitKilogram}).Grams())
+	assert.Equal(t, 200.0, (&models.MealIngredient{Quantity: 0.2, Unit: models.UnitLiter}).Grams())
+	assert.Equal(t, 100.0, (&models.MealIngredient{Quantity: 2, Unit: models.UnitPiece, Ingredient: egg}).Grams())
+
+	// Pieces without a weight add nothing
+	assert.Zero(t, (&models.MealIngredient{Quantity: 2, Unit: models.UnitPiece}).Grams())
+}
+
+func TestMealDeriveFromIngredients(t *testing.T) {
+	noodles := models.Ingredient{
+		Name:      "Egg noodles",
+		Allergens: []string{models.AllergenGluten, models.AllergenEgg},
+		Nutrition: models.NutritionFacts{Calories: 138, Carbohydrates: 25, Sodium: 5},
+	}
+	peanuts := models.Ingredient{
+		Name:      "Peanuts",
+		Allergens: []string{models.AllergenPeanut},
+		Nutrition: models.NutritionFacts{Calories: 567, Protein: 25.8, Fat: 49.2},
+	}
+
+	meal := models.Meal{
+		Allergens: []string{models.AllergenShellfish},
+		Ingredients: []models.MealIngredient{
+			{Quantity: 200, Unit: models.UnitGram, Ingredient: noodles},
+			{Quantity: 30, Unit: models.UnitGram, Ingredient: peanuts},
+		},
+	}
+	meal.DeriveFromIngredients()
+
+	// Ingredients replace the allergens the meal was given
+	assert.Equal(t, []string{models.AllergenEgg, models.AllergenGluten, models.AllergenPeanut}, meal.Allergens)
+	assert.Equal(t, models.NutritionFacts{
+		Calories:      446.1,
+		Protein:       7.7,
+		Carbohydrates: 50,
+		Fat:           14.8,
+		Sodium:        10,
+	}, meal.Nutrition)
+	assert.Equal(t, "Egg noodles Peanuts", meal.IngredientNames)
+
+	// Meals without ingredients keep their allergens and have no nutrition facts
+	plain := models.Meal{Allergens: []string{models.AllergenFish}, Nutrition: models.NutritionFacts{Calories: 100}}
+	plain.DeriveFromIngredients()
+	assert.Equal(t, []string{models.AllergenFish}, plain.Allergens)
+	assert.Equal(t, models.NutritionFacts{}, plain.Nutrition)
+}
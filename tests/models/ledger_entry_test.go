@@ -0,0 +1,58 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedgerEntryIsAppendOnly(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+	user, _ := createOrderFixtures(db, "ledger")
+
+	// Arrange
+	entry := models.LedgerEntry{
+		UserID:  user.ID,
+		Kind:    models.LedgerEntryRefund,
+		Amount:  models.NewMoney(500, "USD"),
+		Balance: models.NewMoney(500, "USD"),
+		Note:    "Missing side salad",
+	}
+
+	// Act
+	result := db.Create(&entry)
+
+	// Assert
+	assert.Nil(t, result.Error)
+	assert.NotZero(t, entry.ID)
+
+	// Entries can neither be changed nor removed
+	assert.ErrorIs(t, db.Model(&entry).Update("note", "Changed").Error, models.ErrLedgerAppendOnly)
+	assert.ErrorIs(t, db.Delete(&entry).Error, models.ErrLedgerAppendOnly)
+
+	var retrieved models.LedgerEntry
+	assert.Nil(t, db.First(&retrieved, entry.ID).Error)
+	assert.Equal(t, "Missing side salad", retrieved.Note)
+	assert.Equal(t, models.NewMoney(500, "USD"), retrieved.Balance)
+}
+
+func TestOrderAmountDueAndRefunds(t *testing.T) {
+	order := models.Order{Total: models.NewMoney(3200, "USD")}
+	assert.Equal(t, models.NewMoney(3200, "USD"), order.AmountDue())
+
+	// Store credit covers part of the total
+	order.CreditApplied = models.NewMoney(1200, "USD")
+	assert.Equal(t, models.NewMoney(2000, "USD"), order.AmountDue())
+
+	// Only delivered orders are refunded; others are cancelled instead
+	order.Status = models.OrderStatusPlaced
+	assert.False(t, order.IsRefundable())
+	order.Status = models.OrderStatusDelivered
+	assert.True(t, order.IsRefundable())
+
+	assert.True(t, models.RefundMethodCredit.IsValid())
+	assert.False(t, models.RefundMethod("cash").IsValid())
+}
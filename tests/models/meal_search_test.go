@@ -0,0 +1,105 @@
+package models_test
+
+import (
+	"encoding/json"
+	"meals/handlers"
+	"meals/models"
+	"meals/tests/testutils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMealFacets(t *testing.T) {
+	facets := models.NewMealFacets()
+
+	assert.Empty(t, facets.Tags)
+	assert.Empty(t, facets.Diets)
+	assert.Empty(t, facets.Allergens)
+
+	// Ranges include their lower bound; the last one is open ended
+	assert.Len(t, facets.PriceRanges, len(models.PriceRangeBounds))
+	assert.Equal(t, int64(0), facets.PriceRanges[0].Min)
+	assert.Equal(t, int64(1000), *facets.PriceRanges[0].Max)
+	assert.Equal(t, int64(1000), facets.PriceRanges[1].Min)
+	assert.Equal(t, int64(2000), facets.PriceRanges[3].Min)
+	assert.Nil(t, facets.PriceRanges[3].Max)
+	for _, priceRange := range facets.PriceRanges {
+		assert.Zero(t, priceRange.Count)
+	}
+}
+
+func TestDietLabelsSuiting(t *testing.T) {
+	// Vegan and vegetarian meals also suit pescatarians
+	assert.Equal(t, []string{models.DietPescatarian, models.DietVegan, models.DietVegetarian},
+		models.DietLabelsSuiting(models.DietPescatarian))
+	assert.Equal(t, []string{models.DietDairyFree, models.DietVegan}, models.DietLabelsSuiting(models.DietDairyFree))
+
+	// Diets nothing implies only match their own label
+	assert.Equal(t, []string{models.DietKeto}, models.DietLabelsSuiting(models.DietKeto))
+
+	// Labels matched this way agree with Suits
+	for _, diet := range models.Diets {
+		for _, label := range models.DietLabelsSuiting(diet) {
+			meal := models.Meal{Diets: []string{label}}
+			assert.True(t, meal.Suits(diet), "%s meal should suit %s", label, diet)
+		}
+	}
+}
+
+func TestSearchMealFacets(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+
+	meals := []models.Meal{
+		{Name: "Tomato soup", Price: models.NewMoney(899, "USD"), Tags: []string{"spicy"}, Diets: []string{models.DietVegan}},
+		{Name: "Peanut soup", Price: models.NewMoney(1000, "USD"), Tags: []string{"spicy", "family_size"},
+			Allergens: []string{models.AllergenPeanut}},
+		{Name: "Cheese soup", Price: models.NewMoney(2450, "USD"), Diets: []string{models.DietVegetarian},
+			Allergens: []string{models.AllergenDairy}},
+		{Name: "Chicken salad", Price: models.NewMoney(1200, "USD"), Tags: []string{"spicy"}},
+		{Name: "Fish soup", Price: models.NewMoney(1600, "USD"), Allergens: []string{models.AllergenFish}},
+	}
+	assert.Nil(t, db.Create(&meals).Error)
+	// Deleted meals are never counted
+	assert.Nil(t, db.Delete(&meals[4]).Error)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/meals/search", handlers.SearchMealsHandler)
+
+	// One meal is returned, but the total and facets cover every match
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/meals/search?q=soup&limit=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Total  int64             `json:"total"`
+		Meals  []models.Meal     `json:"meals"`
+		Facets models.MealFacets `json:"facets"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(3), response.Total)
+	assert.Len(t, response.Meals, 1)
+
+	facets := response.Facets
+	assert.Equal(t, map[string]int{"spicy": 2, "family_size": 1}, facets.Tags)
+	assert.Equal(t, map[string]int{
+		models.DietVegan:       1,
+		models.DietVegetarian:  2,
+		models.DietPescatarian: 2,
+		models.DietDairyFree:   1,
+	}, facets.Diets)
+	assert.Equal(t, map[string]int{models.AllergenPeanut: 1, models.AllergenDairy: 1}, facets.Allergens)
+
+	// Ranges include their lower bound; the last one is open ended
+	assert.Len(t, facets.PriceRanges, len(models.PriceRangeBounds))
+	counts := make([]int, 0, len(facets.PriceRanges))
+	for _, priceRange := range facets.PriceRanges {
+		counts = append(counts, priceRange.Count)
+	}
+	assert.Equal(t, []int{1, 1, 0, 1}, counts)
+}
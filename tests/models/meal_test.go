@@ -19,7 +19,7 @@ func TestMealCreation(t *testing.T) {
 	// Arrange
 	testMeal := models.Meal{
 		Name:  "Test Meal",
-		Price: 12.99,
+		Price: models.NewMoney(1299, "USD"),
 	}
 
 	// Act
@@ -44,13 +44,13 @@ func TestMealUpdate(t *testing.T) {
 	// Create a meal
 	meal := models.Meal{
 		Name:  "Original Meal",
-		Price: 9.99,
+		Price: models.NewMoney(999, "USD"),
 	}
 	db.Create(&meal)
 
 	// Update the meal
 	meal.Name = "Updated Meal"
-	meal.Price = 14.99
+	meal.Price = models.NewMoney(1499, "USD")
 	db.Save(&meal)
 
 	// Verify updates
@@ -58,7 +58,7 @@ func TestMealUpdate(t *testing.T) {
 	db.First(&updatedMeal, meal.ID)
 
 	assert.Equal(t, "Updated Meal", updatedMeal.Name)
-	assert.Equal(t, 14.99, updatedMeal.Price)
+	assert.Equal(t, models.NewMoney(1499, "USD"), updatedMeal.Price)
 }
 
 func TestMealDelete(t *testing.T) {
@@ -67,7 +67,7 @@ func TestMealDelete(t *testing.T) {
 	// Create a meal
 	meal := models.Meal{
 		Name:  "Meal to Delete",
-		Price: 19.99,
+		Price: models.NewMoney(1999, "USD"),
 	}
 	db.Create(&meal)
 
@@ -91,9 +91,9 @@ func TestMealQuery(t *testing.T) {
 	defer testutils.CleanupTestDB(db)
 	// Create multiple meals
 	meals := []models.Meal{
-		{Name: "Meal 1", Price: 9.99},
-		{Name: "Meal 2", Price: 14.99},
-		{Name: "Meal 3", Price: 19.99},
+		{Name: "Meal 1", Price: models.NewMoney(999, "USD")},
+		{Name: "Meal 2", Price: models.NewMoney(1499, "USD")},
+		{Name: "Meal 3", Price: models.NewMoney(1999, "USD")},
 	}
 	for _, meal := range meals {
 		db.Create(&meal)
@@ -110,5 +110,5 @@ func TestMealQuery(t *testing.T) {
 	result = db.Where("name = ?", "Meal 1").First(&meal1)
 	assert.Nil(t, result.Error)
 	assert.Equal(t, "Meal 1", meal1.Name)
-	assert.Equal(t, 9.99, meal1.Price)
+	assert.Equal(t, models.NewMoney(999, "USD"), meal1.Price)
 }
@@ -15,7 +15,7 @@ func TestMenuMealCreation(t *testing.T) {
 	// Create a meal first
 	testMeal := models.Meal{
 		Name:  "Menu-Meal Test Item",
-		Price: 15.99,
+		Price: models.NewMoney(1599, "USD"),
 	}
 	db.Create(&testMeal)
 
@@ -60,9 +60,9 @@ func TestMenuMealRelations(t *testing.T) {
 	defer testutils.CleanupTestDB(db)
 	// Create multiple meals
 	meals := []models.Meal{
-		{Name: "Breakfast", Price: 8.99},
-		{Name: "Lunch", Price: 12.99},
-		{Name: "Dinner", Price: 15.99},
+		{Name: "Breakfast", Price: models.NewMoney(899, "USD")},
+		{Name: "Lunch", Price: models.NewMoney(1299, "USD")},
+		{Name: "Dinner", Price: models.NewMoney(1599, "USD")},
 	}
 	for i := range meals {
 		db.Create(&meals[i])
@@ -123,7 +123,7 @@ func TestMenuMealCascadeDelete(t *testing.T) {
 	// Create a meal
 	meal := models.Meal{
 		Name:  "Cascade Test Meal",
-		Price: 9.99,
+		Price: models.NewMoney(999, "USD"),
 	}
 	db.Create(&meal)
 
@@ -173,12 +173,12 @@ func TestMenuWithMultipleMeals(t *testing.T) {
 
 	// 1. Create meals
 	meals := []models.Meal{
-		{Name: "Oatmeal", Price: 5.99},
-		{Name: "Sandwich", Price: 7.99},
-		{Name: "Salad", Price: 8.99},
-		{Name: "Pasta", Price: 10.99},
-		{Name: "Steak", Price: 18.99},
-		{Name: "Soup", Price: 6.99},
+		{Name: "Oatmeal", Price: models.NewMoney(599, "USD")},
+		{Name: "Sandwich", Price: models.NewMoney(799, "USD")},
+		{Name: "Salad", Price: models.NewMoney(899, "USD")},
+		{Name: "Pasta", Price: models.NewMoney(1099, "USD")},
+		{Name: "Steak", Price: models.NewMoney(1899, "USD")},
+		{Name: "Soup", Price: models.NewMoney(699, "USD")},
 	}
 	for i := range meals {
 		db.Create(&meals[i])
@@ -232,3 +232,67 @@ func TestMenuWithMultipleMeals(t *testing.T) {
 	assert.Equal(t, 3, days["Tuesday"])
 	assert.Equal(t, 3, days["Wednesday"])
 }
+
+func TestMenuMealCapacity(t *testing.T) {
+	// Without a capacity a menu meal is unlimited
+	unlimited := models.MenuMeal{ReservedPortions: 500}
+	_, limited := unlimited.Remaining()
+	assert.False(t, limited)
+	assert.True(t, unlimited.CanReserve(1000))
+
+	// With a capacity, reservations are bounded by what is left
+	capacity := 10
+	limitedMeal := models.MenuMeal{Capacity: &capacity, ReservedPortions: 7}
+	remaining, limited := limitedMeal.Remaining()
+	assert.True(t, limited)
+	assert.Equal(t, 3, remaining)
+	assert.True(t, limitedMeal.CanReserve(3))
+	assert.False(t, limitedMeal.CanReserve(4))
+}
+
+func TestMenuMealRemainingPortionsLoaded(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+	meal := models.Meal{
+		Name:  "Limited Meal",
+		Price: models.NewMoney(1299, "USD"),
+	}
+	db.Create(&meal)
+
+	weekStart := time.Now()
+	menu := models.Menu{
+		Name:          "Capacity Test Menu",
+		WeekStartDate: weekStart,
+		WeekEndDate:   weekStart.AddDate(0, 0, 7),
+	}
+	db.Create(&menu)
+
+	capacity := 20
+	menuMeal := models.MenuMeal{
+		MenuID:           menu.ID,
+		MealID:           meal.ID,
+		DeliveryDay:      "Thursday",
+		Capacity:         &capacity,
+		ReservedPortions: 5,
+	}
+	assert.Nil(t, db.Create(&menuMeal).Error)
+
+	// Remaining portions are computed when the row is loaded
+	var loaded models.MenuMeal
+	assert.Nil(t, db.First(&loaded, menuMeal.ID).Error)
+	assert.NotNil(t, loaded.RemainingPortions)
+	assert.Equal(t, 15, *loaded.RemainingPortions)
+
+	// Negative capacities are rejected by the database
+	negative := -1
+	invalid := models.MenuMeal{MenuID: menu.ID, MealID: meal.ID, DeliveryDay: "Friday", Capacity: &negative}
+	assert.Error(t, db.Create(&invalid).Error)
+}
+
+func TestMenuMealSameSlot(t *testing.T) {
+	monday := models.MenuMeal{MealID: 1, DeliveryDay: "Monday"}
+
+	assert.True(t, monday.SameSlot(models.MenuMeal{MealID: 1, DeliveryDay: " monday"}))
+	assert.False(t, monday.SameSlot(models.MenuMeal{MealID: 1, DeliveryDay: "Tuesday"}))
+	assert.False(t, monday.SameSlot(models.MenuMeal{MealID: 2, DeliveryDay: "Monday"}))
+}
@@ -146,3 +146,45 @@ func TestMenuQuery(t *testing.T) {
 	assert.Nil(t, result.Error)
 	assert.Equal(t, "Week 1 Menu", week1Menu.Name)
 }
+
+func TestMenuDeliveryDateFor(t *testing.T) {
+	// A menu running Monday 2 June to Sunday 8 June 2025
+	menu := models.Menu{
+		WeekStartDate: time.Date(2025, time.June, 2, 0, 0, 0, 0, time.UTC),
+		WeekEndDate:   time.Date(2025, time.June, 8, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Weekday names resolve to the matching date in the menu week
+	date, err := menu.DeliveryDateFor("Wednesday", time.UTC)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2025, time.June, 4, 0, 0, 0, 0, time.UTC), date)
+
+	// Short and lowercase names are accepted
+	date, err = menu.DeliveryDateFor("sun", time.UTC)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, date.Day())
+
+	// ISO dates inside the week are accepted as-is
+	date, err = menu.DeliveryDateFor("2025-06-06", time.UTC)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Friday, date.Weekday())
+
+	// Dates outside the week and unknown days are rejected
+	_, err = menu.DeliveryDateFor("2025-06-10", time.UTC)
+	assert.Error(t, err)
+	_, err = menu.DeliveryDateFor("Someday", time.UTC)
+	assert.Error(t, err)
+
+	// West of UTC the week keeps its stored dates: Monday is still 2 June,
+	// at midnight kitchen time
+	loc, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+	date, err = menu.DeliveryDateFor("Monday", loc)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2025, time.June, 2, 0, 0, 0, 0, loc), date)
+	date, err = menu.DeliveryDateFor("2025-06-08", loc)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Sunday, date.Weekday())
+	_, err = menu.DeliveryDateFor("2025-06-01", loc)
+	assert.Error(t, err)
+}
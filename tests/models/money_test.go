@@ -0,0 +1,67 @@
+package models_test
+
+import (
+	"meals/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoneyArithmetic(t *testing.T) {
+	price := models.NewMoney(1299, "usd")
+	assert.Equal(t, "USD", price.Currency)
+
+	// Arithmetic stays in integer minor units
+	assert.Equal(t, models.NewMoney(3897, "USD"), price.Mul(3))
+	assert.Equal(t, models.NewMoney(1399, "USD"), price.Add(models.NewMoney(100, "USD")))
+	assert.Equal(t, models.NewMoney(1199, "USD"), price.Sub(models.NewMoney(100, "USD")))
+
+	// A zero value adopts the other operand's currency
+	var total models.Money
+	total = total.Add(price)
+	assert.Equal(t, price, total)
+
+	// Formatting uses the currency's minor unit
+	assert.Equal(t, "12.99 USD", price.String())
+	assert.Equal(t, "-0.05 USD", models.NewMoney(-5, "USD").String())
+	assert.Equal(t, "1500 JPY", models.NewMoney(1500, "JPY").String())
+}
+
+func TestMoneyFromDecimal(t *testing.T) {
+	// Float prices round to the nearest minor unit without drift
+	assert.Equal(t, int64(1299), models.MoneyFromDecimal(12.99, "USD").Amount)
+	assert.Equal(t, int64(29), models.MoneyFromDecimal(0.29, "USD").Amount)
+	assert.Equal(t, int64(1005), models.MoneyFromDecimal(10.045, "EUR").Amount)
+	assert.Equal(t, int64(1500), models.MoneyFromDecimal(1500, "JPY").Amount)
+	assert.Equal(t, models.DefaultCurrency, models.MoneyFromDecimal(1, "").Currency)
+}
+
+func TestMoneyValidation(t *testing.T) {
+	assert.Empty(t, models.NewMoney(0, "USD").ValidateMoney("Price"))
+	assert.Contains(t, models.NewMoney(-1, "USD").ValidateMoney("Price"), "Price must not be negative")
+	assert.Equal(t, 1, len(models.Money{Amount: 100, Currency: "dollars"}.ValidateMoney("Price")))
+}
+
+func TestOrderItemPriceSnapshot(t *testing.T) {
+	meal := models.Meal{Name: "Snapshot Meal", Price: models.NewMoney(1150, "USD")}
+	meal.ID = 7
+
+	order := models.Order{
+		Items: []models.OrderItem{
+			{MenuMealID: 1, Quantity: 2},
+		},
+	}
+	order.Items[0].SnapshotMeal(meal)
+	order.ComputeTotals()
+
+	// Later price changes do not affect the snapshot
+	meal.Price = models.NewMoney(9999, "USD")
+	meal.Name = "Renamed Meal"
+
+	assert.Equal(t, uint(7), order.Items[0].MealID)
+	assert.Equal(t, "Snapshot Meal", order.Items[0].MealName)
+	assert.Equal(t, models.NewMoney(1150, "USD"), order.Items[0].UnitPrice)
+	assert.Equal(t, models.NewMoney(2300, "USD"), order.Items[0].LineTotal)
+	assert.Equal(t, models.NewMoney(2300, "USD"), order.Subtotal)
+	assert.Equal(t, models.NewMoney(2300, "USD"), order.Total)
+}
@@ -0,0 +1,86 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderStatusTransitions(t *testing.T) {
+	// Admins confirm placed orders, customers cannot
+	assert.True(t, models.OrderStatusPlaced.CanTransitionTo(models.OrderStatusConfirmed))
+	assert.True(t, models.OrderStatusPlaced.CanBeTransitionedBy(models.OrderStatusConfirmed, models.UserTypeAdmin))
+	assert.False(t, models.OrderStatusPlaced.CanBeTransitionedBy(models.OrderStatusConfirmed, models.UserTypeCustomer))
+
+	// Drivers mark orders delivered, admins cannot
+	assert.True(t, models.OrderStatusOutForDelivery.CanBeTransitionedBy(models.OrderStatusDelivered, models.UserTypeDriver))
+	assert.False(t, models.OrderStatusOutForDelivery.CanBeTransitionedBy(models.OrderStatusDelivered, models.UserTypeAdmin))
+
+	// Skipping steps is not part of the lifecycle
+	assert.False(t, models.OrderStatusPlaced.CanTransitionTo(models.OrderStatusDelivered))
+	assert.False(t, models.OrderStatusConfirmed.CanTransitionTo(models.OrderStatusOutForDelivery))
+
+	// Terminal statuses have no way out
+	assert.True(t, models.OrderStatusDelivered.IsTerminal())
+	assert.True(t, models.OrderStatusCancelled.IsTerminal())
+	assert.True(t, models.OrderStatusFailed.IsTerminal())
+	assert.False(t, models.OrderStatusPlaced.IsTerminal())
+	assert.False(t, models.OrderStatusDelivered.CanTransitionTo(models.OrderStatusCancelled))
+
+	// Unknown statuses are rejected
+	assert.False(t, models.OrderStatus("shipped").IsValid())
+	assert.True(t, models.OrderStatusOutForDelivery.IsValid())
+
+	// Drafts are submitted or discarded by customers, and only submitted drafts reserve stock
+	assert.True(t, models.OrderStatusDraft.CanBeTransitionedBy(models.OrderStatusPlaced, models.UserTypeCustomer))
+	assert.False(t, models.OrderStatusDraft.CanTransitionTo(models.OrderStatusConfirmed))
+	assert.False(t, models.OrderStatusDraft.ReservesStock())
+	assert.False(t, models.OrderStatusCancelled.ReservesStock())
+	assert.True(t, models.OrderStatusPlaced.ReservesStock())
+
+	// Next statuses are filtered by role
+	assert.Equal(t, []models.OrderStatus{models.OrderStatusCancelled}, models.OrderStatusPlaced.NextStatuses(models.UserTypeCustomer))
+	assert.Equal(t,
+		[]models.OrderStatus{models.OrderStatusConfirmed, models.OrderStatusCancelled},
+		models.OrderStatusPlaced.NextStatuses(models.UserTypeAdmin))
+}
+
+func TestOrderStatusEventHistory(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+	user, menuMeal := createOrderFixtures(db, "status-history")
+
+	order := models.Order{
+		UserID: user.ID,
+		Items: []models.OrderItem{
+			{MenuMealID: menuMeal.ID, Quantity: 1},
+		},
+	}
+	db.Create(&order)
+
+	// New orders default to placed
+	var created models.Order
+	db.First(&created, order.ID)
+	assert.Equal(t, models.OrderStatusPlaced, created.Status)
+
+	// Record a couple of transitions
+	now := time.Now()
+	events := []models.OrderStatusEvent{
+		{OrderID: order.ID, ToStatus: models.OrderStatusPlaced, ActorID: user.ID, ActorType: models.UserTypeCustomer, RequestID: "req-1", OccurredAt: now},
+		{OrderID: order.ID, FromStatus: models.OrderStatusPlaced, ToStatus: models.OrderStatusConfirmed, ActorType: models.UserTypeAdmin, RequestID: "req-2", OccurredAt: now.Add(time.Minute)},
+	}
+	for i := range events {
+		assert.Nil(t, db.Create(&events[i]).Error)
+	}
+
+	// History is preloaded in order
+	var withHistory models.Order
+	result := db.Preload("StatusEvents", "order_id = ?", order.ID).First(&withHistory, order.ID)
+	assert.Nil(t, result.Error)
+	assert.Equal(t, 2, len(withHistory.StatusEvents))
+	assert.Equal(t, "req-2", withHistory.StatusEvents[1].RequestID)
+	assert.Equal(t, models.OrderStatusConfirmed, withHistory.StatusEvents[1].ToStatus)
+}
@@ -0,0 +1,141 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// createOrderFixtures creates a customer and a menu meal that orders can reference
+func createOrderFixtures(db *gorm.DB, suffix string) (models.User, models.MenuMeal) {
+	user := models.User{
+		Provider:    "google",
+		Email:       "order-" + suffix + "@example.com",
+		Name:        "Order Test",
+		AccessToken: "order-token",
+		ExpiresAt:   testTime,
+		IDToken:     "order-id-token",
+		UserID:      "order-" + suffix,
+		UserType:    models.UserTypeCustomer,
+	}
+	db.Create(&user)
+
+	meal := models.Meal{
+		Name:  "Order Test Meal",
+		Price: models.NewMoney(1150, "USD"),
+	}
+	db.Create(&meal)
+
+	weekStart := time.Now()
+	menu := models.Menu{
+		Name:          "Order Test Menu",
+		Description:   "For testing orders",
+		WeekStartDate: weekStart,
+		WeekEndDate:   weekStart.AddDate(0, 0, 7),
+	}
+	db.Create(&menu)
+
+	menuMeal := models.MenuMeal{
+		MenuID:      menu.ID,
+		MealID:      meal.ID,
+		DeliveryDay: "Monday",
+	}
+	db.Create(&menuMeal)
+
+	return user, menuMeal
+}
+
+func TestOrderCreation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+	user, menuMeal := createOrderFixtures(db, "create")
+
+	// Arrange
+	order := models.Order{
+		UserID: user.ID,
+		Notes:  "Leave at the door",
+		Items: []models.OrderItem{
+			{MenuMealID: menuMeal.ID, Quantity: 2},
+		},
+	}
+
+	// Act
+	result := db.Create(&order)
+
+	// Assert
+	assert.Nil(t, result.Error)
+	assert.NotZero(t, order.ID)
+	assert.NotZero(t, order.Items[0].ID)
+
+	// Verify order and nested relations can be preloaded
+	var retrieved models.Order
+	result = db.Preload("Items.MenuMeal.Meal").First(&retrieved, order.ID)
+	assert.Nil(t, result.Error)
+	assert.Equal(t, user.ID, retrieved.UserID)
+	assert.Equal(t, "Leave at the door", retrieved.Notes)
+	assert.Equal(t, 1, len(retrieved.Items))
+	assert.Equal(t, 2, retrieved.Items[0].Quantity)
+	assert.Equal(t, "Order Test Meal", retrieved.Items[0].MenuMeal.Meal.Name)
+}
+
+func TestOrderCascadeDelete(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+	user, menuMeal := createOrderFixtures(db, "cascade")
+
+	order := models.Order{
+		UserID: user.ID,
+		Items: []models.OrderItem{
+			{MenuMealID: menuMeal.ID, Quantity: 1},
+		},
+	}
+	db.Create(&order)
+
+	// Delete the order (should cascade to order items)
+	db.Delete(&order)
+
+	var item models.OrderItem
+	result := db.First(&item, order.Items[0].ID)
+	assert.Error(t, result.Error) // Should not find it
+
+	// The menu meal is unaffected
+	var menuMealCheck models.MenuMeal
+	result = db.First(&menuMealCheck, menuMeal.ID)
+	assert.Nil(t, result.Error)
+}
+
+func TestOrderValidation(t *testing.T) {
+	// An empty order is rejected
+	var empty models.Order
+	errs := empty.ValidateOrder()
+	assert.Contains(t, errs, "UserID is required")
+	assert.Contains(t, errs, "At least one item is required")
+
+	// Non-positive quantities are rejected
+	order := models.Order{
+		UserID: 1,
+		Items: []models.OrderItem{
+			{MenuMealID: 1, Quantity: 0},
+		},
+	}
+	assert.Equal(t, 1, len(order.ValidateOrder()))
+
+	order.Items[0].Quantity = 3
+	assert.Empty(t, order.ValidateOrder())
+
+	// Delivery locations need both coordinates within range
+	latitude, longitude := 40.74, -200.0
+	order.DeliveryLatitude = &latitude
+	assert.Equal(t, []string{"Latitude and longitude must be given together"}, order.ValidateOrder())
+	order.DeliveryLongitude = &longitude
+	assert.Equal(t, []string{"Longitude must be between -180 and 180"}, order.ValidateOrder())
+	longitude = -73.99
+	assert.Empty(t, order.ValidateOrder())
+	assert.True(t, order.HasDeliveryLocation())
+	assert.True(t, order.BelongsTo(1))
+	assert.False(t, order.BelongsTo(2))
+}
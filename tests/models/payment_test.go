@@ -0,0 +1,81 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentCreation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+	user, menuMeal := createOrderFixtures(db, "payment")
+
+	order := models.Order{
+		UserID: user.ID,
+		Items: []models.OrderItem{
+			{MenuMealID: menuMeal.ID, Quantity: 1},
+		},
+	}
+	db.Create(&order)
+
+	// Arrange
+	payment := models.Payment{
+		OrderID:      order.ID,
+		Provider:     "fake",
+		Reference:    "fake_pay_1",
+		Status:       models.PaymentStatusAuthorized,
+		Amount:       models.NewMoney(1150, "USD"),
+		AuthorizedAt: time.Now(),
+	}
+
+	// Act
+	result := db.Create(&payment)
+
+	// Assert
+	assert.Nil(t, result.Error)
+
+	var retrieved models.Order
+	result = db.Preload("Payment").First(&retrieved, order.ID)
+	assert.Nil(t, result.Error)
+	assert.NotNil(t, retrieved.Payment)
+	assert.Equal(t, "fake_pay_1", retrieved.Payment.Reference)
+
+	// An order has at most one payment
+	duplicate := models.Payment{OrderID: order.ID, Provider: "fake", Reference: "fake_pay_2", Status: models.PaymentStatusAuthorized}
+	assert.Error(t, db.Create(&duplicate).Error)
+}
+
+func TestPaymentWebhookEventsAreUnique(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+
+	event := models.PaymentWebhookEvent{Provider: "fake", EventID: "evt_1", Type: "payment.captured", ProcessedAt: time.Now()}
+	assert.Nil(t, db.Create(&event).Error)
+
+	// A replayed event is rejected for the same provider only
+	replay := models.PaymentWebhookEvent{Provider: "fake", EventID: "evt_1", Type: "payment.captured", ProcessedAt: time.Now()}
+	assert.Error(t, db.Create(&replay).Error)
+	other := models.PaymentWebhookEvent{Provider: "other", EventID: "evt_1", Type: "payment.captured", ProcessedAt: time.Now()}
+	assert.Nil(t, db.Create(&other).Error)
+}
+
+func TestPaymentRefundable(t *testing.T) {
+	payment := models.Payment{
+		Status:         models.PaymentStatusAuthorized,
+		Amount:         models.NewMoney(2000, "USD"),
+		CapturedAmount: models.NewMoney(0, "USD"),
+		RefundedAmount: models.NewMoney(0, "USD"),
+	}
+	assert.True(t, payment.CanCapture())
+	assert.True(t, payment.Refundable().IsZero())
+
+	payment.Status = models.PaymentStatusCaptured
+	payment.CapturedAmount = models.NewMoney(2000, "USD")
+	payment.RefundedAmount = models.NewMoney(500, "USD")
+	assert.False(t, payment.CanCapture())
+	assert.Equal(t, models.NewMoney(1500, "USD"), payment.Refundable())
+}
@@ -0,0 +1,71 @@
+package models_test
+
+import (
+	"meals/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductionPlanMeals(t *testing.T) {
+	rice := models.Ingredient{Name: "Rice"}
+	rice.ID = 1
+	stock := models.Ingredient{Name: "Stock"}
+	stock.ID = 2
+	egg := models.Ingredient{Name: "Egg", GramsPerPiece: 50}
+	egg.ID = 3
+
+	risotto := models.Meal{Name: "Risotto", Ingredients: []models.MealIngredient{
+		{IngredientID: rice.ID, Ingredient: rice, Quantity: 0.1, Unit: models.UnitKilogram},
+		{IngredientID: stock.ID, Ingredient: stock, Quantity: 300, Unit: models.UnitMilliliter},
+	}}
+	risotto.ID = 10
+	friedRice := models.Meal{Name: "Fried rice", Ingredients: []models.MealIngredient{
+		{IngredientID: rice.ID, Ingredient: rice, Quantity: 150, Unit: models.UnitGram},
+		{IngredientID: egg.ID, Ingredient: egg, Quantity: 1, Unit: models.UnitPiece},
+		{IngredientID: egg.ID, Ingredient: egg, Quantity: 0.5, Unit: models.UnitLiter},
+	}}
+	friedRice.ID = 11
+	salad := models.Meal{Name: "Salad"}
+	salad.ID = 12
+
+	var plan models.ProductionPlan
+	plan.PlanMeals([]models.Meal{risotto, friedRice, salad}, map[uint]int{risotto.ID: 4, friedRice.ID: 6})
+
+	// Meals are listed by name, including those nobody ordered
+	assert.Equal(t, []models.ProductionMeal{
+		{MealID: friedRice.ID, MealName: "Fried rice", Portions: 6, HasIngredients: true},
+		{MealID: risotto.ID, MealName: "Risotto", Portions: 4, HasIngredients: true},
+		{MealID: salad.ID, MealName: "Salad", Portions: 0, HasIngredients: false},
+	}, plan.Meals)
+
+	// kg and g are summed together, as are l and ml, and shown in kg or l from 1000 up
+	assert.Equal(t, []models.IngredientTotal{
+		{IngredientID: egg.ID, Name: "Egg", Quantity: 3, Unit: models.UnitLiter},
+		{IngredientID: egg.ID, Name: "Egg", Quantity: 6, Unit: models.UnitPiece},
+		{IngredientID: rice.ID, Name: "Rice", Quantity: 1.3, Unit: models.UnitKilogram},
+		{IngredientID: stock.ID, Name: "Stock", Quantity: 1.2, Unit: models.UnitLiter},
+	}, plan.Ingredients)
+}
+
+func TestProductionPlanSmallQuantities(t *testing.T) {
+	saffron := models.Ingredient{Name: "Saffron"}
+	saffron.ID = 1
+	meal := models.Meal{Name: "Paella", Ingredients: []models.MealIngredient{
+		{IngredientID: saffron.ID, Ingredient: saffron, Quantity: 0.0002, Unit: models.UnitKilogram},
+	}}
+	meal.ID = 1
+
+	var plan models.ProductionPlan
+	plan.PlanMeals([]models.Meal{meal}, map[uint]int{meal.ID: 3})
+
+	assert.Equal(t, []models.IngredientTotal{
+		{IngredientID: saffron.ID, Name: "Saffron", Quantity: 0.6, Unit: models.UnitGram},
+	}, plan.Ingredients)
+
+	// Empty plans list no meals or ingredients rather than null
+	var empty models.ProductionPlan
+	empty.PlanMeals(nil, nil)
+	assert.NotNil(t, empty.Meals)
+	assert.NotNil(t, empty.Ingredients)
+}
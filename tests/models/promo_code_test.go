@@ -0,0 +1,113 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromoCodeCreation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+
+	// Arrange
+	promo := models.PromoCode{
+		Code:           models.NormalizePromoCode(" welcome10 "),
+		DiscountType:   models.DiscountTypePercentage,
+		PercentOff:     10,
+		MinOrderValue:  models.NewMoney(2000, "USD"),
+		FirstOrderOnly: true,
+		Active:         true,
+		MealIDs:        []uint{1, 2},
+	}
+
+	// Act
+	result := db.Create(&promo)
+
+	// Assert
+	assert.Nil(t, result.Error)
+	assert.Equal(t, "WELCOME10", promo.Code)
+
+	var retrieved models.PromoCode
+	assert.Nil(t, db.First(&retrieved, promo.ID).Error)
+	assert.Equal(t, []uint{1, 2}, retrieved.MealIDs)
+	assert.Equal(t, models.NewMoney(2000, "USD"), retrieved.MinOrderValue)
+	assert.True(t, retrieved.FirstOrderOnly)
+
+	// Codes are unique
+	duplicate := models.PromoCode{Code: "WELCOME10", DiscountType: models.DiscountTypeFixed, AmountOff: models.NewMoney(500, "USD")}
+	assert.Error(t, db.Create(&duplicate).Error)
+}
+
+func TestPromoCodeValidation(t *testing.T) {
+	valid := models.PromoCode{Code: "SAVE5", DiscountType: models.DiscountTypeFixed, AmountOff: models.NewMoney(500, "USD")}
+	assert.Empty(t, valid.ValidatePromoCode())
+
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, -1)
+	invalid := models.PromoCode{
+		Code:         "HALF OFF",
+		DiscountType: models.DiscountTypePercentage,
+		PercentOff:   150,
+		MaxUses:      -1,
+		StartsAt:     &start,
+		EndsAt:       &end,
+	}
+	assert.Equal(t, []string{
+		"Code must be at most 64 characters without spaces",
+		"PercentOff must be between 1 and 100",
+		"Usage limits must not be negative",
+		"EndsAt must be after StartsAt",
+	}, invalid.ValidatePromoCode())
+}
+
+func TestPromoCodeAvailability(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	start, end := now.Add(-time.Hour), now.Add(time.Hour)
+	promo := models.PromoCode{Active: true, StartsAt: &start, EndsAt: &end, MaxUses: 2, UsageCount: 1}
+
+	assert.True(t, promo.IsAvailableAt(now))
+	assert.False(t, promo.IsAvailableAt(start.Add(-time.Second)))
+	assert.False(t, promo.IsAvailableAt(end))
+
+	promo.UsageCount = 2
+	assert.False(t, promo.IsAvailableAt(now))
+
+	promo.UsageCount = 0
+	promo.Active = false
+	assert.False(t, promo.IsAvailableAt(now))
+}
+
+func TestPromoCodeDiscount(t *testing.T) {
+	percentage := models.PromoCode{DiscountType: models.DiscountTypePercentage, PercentOff: 15}
+	// 15% of 12.99 is 1.9485, rounded to the nearest cent
+	assert.Equal(t, models.NewMoney(195, "USD"), percentage.Discount(models.NewMoney(1299, "USD")))
+
+	fixed := models.PromoCode{DiscountType: models.DiscountTypeFixed, AmountOff: models.NewMoney(1000, "USD")}
+	assert.Equal(t, models.NewMoney(1000, "USD"), fixed.Discount(models.NewMoney(2500, "USD")))
+	// Fixed discounts never exceed the eligible amount
+	assert.Equal(t, models.NewMoney(600, "USD"), fixed.Discount(models.NewMoney(600, "USD")))
+
+	// Restrictions match on both the meal and the menu
+	restricted := models.PromoCode{MealIDs: []uint{3}, MenuIDs: []uint{7}}
+	assert.True(t, restricted.AppliesTo(3, 7))
+	assert.False(t, restricted.AppliesTo(4, 7))
+	assert.False(t, restricted.AppliesTo(3, 8))
+	assert.True(t, (&models.PromoCode{}).AppliesTo(4, 8))
+}
+
+func TestOrderTotalsWithDiscount(t *testing.T) {
+	order := models.Order{Items: []models.OrderItem{{Quantity: 2}}}
+	order.Items[0].SnapshotMeal(models.Meal{Name: "Curry", Price: models.NewMoney(1250, "USD")})
+
+	order.ComputeTotals()
+	assert.Equal(t, models.NewMoney(0, "USD"), order.Discount)
+	assert.Equal(t, models.NewMoney(2500, "USD"), order.Total)
+
+	order.ApplyDiscount(models.NewMoney(300, "USD"))
+	assert.Equal(t, models.NewMoney(2500, "USD"), order.Subtotal)
+	assert.Equal(t, models.NewMoney(2200, "USD"), order.Total)
+}
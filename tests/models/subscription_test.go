@@ -0,0 +1,116 @@
+package models_test
+
+import (
+	"meals/models"
+	"meals/tests/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionCreation(t *testing.T) {
+	db := testutils.SetupTestDB()
+	defer testutils.CleanupTestDB(db)
+	user, _ := createOrderFixtures(db, "subscription")
+
+	// Arrange
+	subscription := models.Subscription{
+		UserID:       user.ID,
+		MealsPerWeek: 5,
+		DeliveryDays: []string{"Monday", "Thursday"},
+		DietaryRules: models.DietaryRules{
+			ExcludedKeywords: []string{"pork"},
+			MaxRepeats:       2,
+		},
+	}
+
+	// Act
+	result := db.Create(&subscription)
+
+	// Assert
+	assert.Nil(t, result.Error)
+	assert.NotZero(t, subscription.ID)
+
+	// Lists and rules round-trip through their JSON columns
+	var retrieved models.Subscription
+	result = db.Preload("Skips").First(&retrieved, subscription.ID)
+	assert.Nil(t, result.Error)
+	assert.Equal(t, models.SubscriptionStatusActive, retrieved.Status)
+	assert.Equal(t, []string{"Monday", "Thursday"}, retrieved.DeliveryDays)
+	assert.Equal(t, []string{"pork"}, retrieved.DietaryRules.ExcludedKeywords)
+	assert.Equal(t, 2, retrieved.DietaryRules.MaxRepeats)
+
+	// A week can only be skipped once
+	week := models.WeekStart(time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, db.Create(&models.SubscriptionSkip{SubscriptionID: subscription.ID, WeekStartDate: week}).Error)
+	assert.Error(t, db.Create(&models.SubscriptionSkip{SubscriptionID: subscription.ID, WeekStartDate: week}).Error)
+}
+
+func TestSubscriptionValidation(t *testing.T) {
+	var empty models.Subscription
+	errs := empty.ValidateSubscription()
+	assert.Contains(t, errs, "MealsPerWeek must be positive")
+	assert.Contains(t, errs, "At least one delivery day is required")
+
+	subscription := models.Subscription{
+		MealsPerWeek: 5,
+		DeliveryDays: []string{"Monday", "Someday"},
+	}
+	assert.Equal(t, []string{`Invalid delivery day "Someday"`}, subscription.ValidateSubscription())
+
+	subscription.DeliveryDays = []string{"Mon", "friday"}
+	assert.Empty(t, subscription.ValidateSubscription())
+}
+
+func TestSubscriptionIsActiveFor(t *testing.T) {
+	monday := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	nextMonday := monday.AddDate(0, 0, 7)
+
+	subscription := models.Subscription{Status: models.SubscriptionStatusActive}
+	assert.True(t, subscription.IsActiveFor(monday))
+
+	// Skips match menus starting anywhere in the skipped week
+	subscription.Skips = []models.SubscriptionSkip{{WeekStartDate: monday}}
+	assert.False(t, subscription.IsActiveFor(monday.AddDate(0, 0, 2)))
+	assert.True(t, subscription.IsActiveFor(nextMonday))
+
+	// Open-ended pauses last until resumed
+	subscription.Skips = nil
+	subscription.Status = models.SubscriptionStatusPaused
+	assert.False(t, subscription.IsActiveFor(nextMonday))
+
+	// Pauses with an end date lapse for weeks starting after it
+	until := monday.AddDate(0, 0, 6)
+	subscription.PausedUntil = &until
+	assert.False(t, subscription.IsActiveFor(monday))
+	assert.True(t, subscription.IsActiveFor(nextMonday))
+
+	subscription.Status = models.SubscriptionStatusCancelled
+	assert.False(t, subscription.IsActiveFor(nextMonday))
+}
+
+func TestDietaryRulesAllows(t *testing.T) {
+	rules := models.DietaryRules{
+		ExcludedMealIDs:  []uint{7},
+		ExcludedKeywords: []string{"Pork"},
+	}
+
+	meal := models.Meal{Name: "Pulled pork sandwich"}
+	meal.ID = 1
+	assert.False(t, rules.Allows(meal))
+
+	meal = models.Meal{Name: "Veggie curry"}
+	meal.ID = 7
+	assert.False(t, rules.Allows(meal))
+
+	meal.ID = 8
+	assert.True(t, rules.Allows(meal))
+}
+
+func TestWeekStart(t *testing.T) {
+	monday := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, monday, models.WeekStart(monday))
+	assert.Equal(t, monday, models.WeekStart(time.Date(2025, 3, 16, 23, 0, 0, 0, time.UTC)))
+	assert.Equal(t, monday, models.WeekStart(time.Date(2025, 3, 12, 9, 30, 0, 0, time.UTC)))
+}
@@ -0,0 +1,93 @@
+package payments_test
+
+import (
+	"context"
+	"encoding/json"
+	"meals/models"
+	"meals/payments"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeAuthorizeCaptureRefund(t *testing.T) {
+	ctx := context.Background()
+	fake := payments.NewFake("secret")
+	amount := models.NewMoney(2500, "USD")
+
+	// Authorizations are idempotent per key
+	auth, err := fake.Authorize(ctx, payments.AuthorizeRequest{Amount: amount, IdempotencyKey: "order-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, payments.StatusAuthorized, auth.Status)
+	again, err := fake.Authorize(ctx, payments.AuthorizeRequest{Amount: amount, IdempotencyKey: "order-1"})
+	assert.Nil(t, err)
+	assert.Equal(t, auth.Reference, again.Reference)
+
+	// Capturing twice only charges once
+	captured, err := fake.Capture(ctx, auth.Reference, amount)
+	assert.Nil(t, err)
+	assert.Equal(t, payments.StatusCaptured, captured.Status)
+	captured, err = fake.Capture(ctx, auth.Reference, amount)
+	assert.Nil(t, err)
+	assert.Equal(t, amount, captured.Amount)
+
+	// Partial refunds accumulate and cannot exceed the captured amount
+	refund, err := fake.Refund(ctx, auth.Reference, models.NewMoney(1000, "USD"))
+	assert.Nil(t, err)
+	assert.Equal(t, payments.StatusCaptured, refund.Status)
+	_, err = fake.Refund(ctx, auth.Reference, models.NewMoney(2000, "USD"))
+	assert.ErrorIs(t, err, payments.ErrInvalidState)
+	refund, err = fake.Refund(ctx, auth.Reference, models.NewMoney(1500, "USD"))
+	assert.Nil(t, err)
+	assert.Equal(t, payments.StatusRefunded, refund.Status)
+
+	_, err = fake.Capture(ctx, "fake_pay_missing", amount)
+	assert.ErrorIs(t, err, payments.ErrUnknownPayment)
+}
+
+func TestFakeDeclineAndVoid(t *testing.T) {
+	ctx := context.Background()
+	fake := payments.NewFake("secret")
+	fake.DeclineAmount = 402
+
+	_, err := fake.Authorize(ctx, payments.AuthorizeRequest{Amount: models.NewMoney(402, "USD")})
+	assert.ErrorIs(t, err, payments.ErrDeclined)
+
+	// Refunding an uncaptured authorization voids it
+	auth, err := fake.Authorize(ctx, payments.AuthorizeRequest{Amount: models.NewMoney(900, "USD")})
+	assert.Nil(t, err)
+	voided, err := fake.Refund(ctx, auth.Reference, auth.Amount)
+	assert.Nil(t, err)
+	assert.Equal(t, payments.StatusVoided, voided.Status)
+
+	_, err = fake.Capture(ctx, auth.Reference, auth.Amount)
+	assert.ErrorIs(t, err, payments.ErrInvalidState)
+}
+
+func TestFakeVerifyWebhook(t *testing.T) {
+	fake := payments.NewFake("secret")
+	payload, _ := json.Marshal(map[string]interface{}{
+		"id":          "evt_1",
+		"type":        payments.EventCaptured,
+		"reference":   "fake_pay_1",
+		"amount":      models.NewMoney(2500, "USD"),
+		"occurred_at": time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC),
+	})
+
+	headers := http.Header{}
+	headers.Set(payments.FakeSignatureHeader, fake.SignWebhook(payload))
+	event, err := fake.VerifyWebhook(payload, headers)
+	assert.Nil(t, err)
+	assert.Equal(t, "evt_1", event.ID)
+	assert.Equal(t, payments.EventCaptured, event.Type)
+	assert.Equal(t, int64(2500), event.Amount.Amount)
+
+	// Tampered payloads and foreign secrets are rejected
+	_, err = fake.VerifyWebhook(append(payload, ' '), headers)
+	assert.ErrorIs(t, err, payments.ErrInvalidSignature)
+	headers.Set(payments.FakeSignatureHeader, payments.NewFake("other").SignWebhook(payload))
+	_, err = fake.VerifyWebhook(payload, headers)
+	assert.ErrorIs(t, err, payments.ErrInvalidSignature)
+}
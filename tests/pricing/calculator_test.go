@@ -0,0 +1,108 @@
+package pricing_test
+
+import (
+	"errors"
+	"meals/config"
+	"meals/models"
+	"meals/pricing"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPricing() config.PricingConfig {
+	return config.PricingConfig{
+		DefaultZone: "central",
+		Zones: map[string]config.PricingZone{
+			"central": {DeliveryFee: 499, Jurisdiction: "ny"},
+			"outer":   {DeliveryFee: 799, Jurisdiction: "nj"},
+		},
+		Jurisdictions: map[string]config.TaxJurisdiction{
+			"ny": {Name: "New York", DefaultRate: 888, CategoryRates: map[string]int{"grocery": 0}},
+			"nj": {Name: "New Jersey", DefaultRate: 663},
+		},
+		SmallOrderThreshold: 2500,
+		SmallOrderSurcharge: 299,
+	}
+}
+
+// orderOf returns an order with one line per meal
+func orderOf(meals ...models.Meal) *models.Order {
+	order := &models.Order{}
+	for _, meal := range meals {
+		item := models.OrderItem{Quantity: 1}
+		item.SnapshotMeal(meal)
+		order.Items = append(order.Items, item)
+	}
+	return order
+}
+
+func TestPriceTaxesByCategory(t *testing.T) {
+	order := orderOf(
+		models.Meal{Name: "Curry", Price: models.NewMoney(2000, "USD"), Category: "prepared"},
+		models.Meal{Name: "Granola", Price: models.NewMoney(1000, "USD"), Category: "grocery"},
+	)
+
+	assert.NoError(t, pricing.NewCalculator(testPricing()).Price(order))
+
+	// Orders without a zone go to the default zone
+	assert.Equal(t, "central", order.DeliveryZone)
+
+	// 8.88% of 20.00 is 1.776, rounded to 1.78; groceries are exempt
+	assert.Equal(t, 888, order.Items[0].TaxRate)
+	assert.Equal(t, models.NewMoney(178, "USD"), order.Items[0].Tax)
+	assert.Equal(t, models.NewMoney(0, "USD"), order.Items[1].Tax)
+
+	assert.Equal(t, models.NewMoney(3000, "USD"), order.Subtotal)
+	assert.Equal(t, models.NewMoney(178, "USD"), order.Tax)
+	assert.Equal(t, models.NewMoney(499, "USD"), order.Fees)
+	assert.Equal(t, models.NewMoney(3677, "USD"), order.Total)
+
+	assert.Equal(t, order.Total, order.Breakdown.Total)
+	assert.Len(t, order.Breakdown.Taxes, 1)
+	assert.Equal(t, "prepared", order.Breakdown.Taxes[0].Code)
+	assert.Equal(t, models.NewMoney(2000, "USD"), *order.Breakdown.Taxes[0].Base)
+	assert.Equal(t, []string{pricing.FeeDelivery}, feeCodes(order))
+}
+
+func TestPriceTaxesAfterDiscount(t *testing.T) {
+	order := orderOf(
+		models.Meal{Name: "Curry", Price: models.NewMoney(2000, "USD")},
+		models.Meal{Name: "Soup", Price: models.NewMoney(1000, "USD")},
+	)
+	order.DeliveryZone = "OUTER"
+	order.PromoCode = "SAVE10"
+	order.ApplyDiscount(models.NewMoney(1000, "USD"))
+
+	assert.NoError(t, pricing.NewCalculator(testPricing()).Price(order))
+	assert.Equal(t, "outer", order.DeliveryZone)
+
+	// The discount is split 667/333 and each line taxed on the rest at 6.63%
+	assert.Equal(t, models.NewMoney(88, "USD"), order.Items[0].Tax)
+	assert.Equal(t, models.NewMoney(44, "USD"), order.Items[1].Tax)
+
+	// 20.00 after the discount is below the small-order threshold
+	assert.Equal(t, []string{pricing.FeeDelivery, pricing.FeeSmallOrder}, feeCodes(order))
+	assert.Equal(t, models.NewMoney(3000-1000+132+799+299, "USD"), order.Total)
+	assert.Equal(t, "SAVE10", order.Breakdown.Discounts[0].Code)
+}
+
+func TestPriceUnknownZone(t *testing.T) {
+	order := orderOf(models.Meal{Name: "Curry", Price: models.NewMoney(2000, "USD")})
+	order.DeliveryZone = "moon"
+
+	err := pricing.NewCalculator(testPricing()).Price(order)
+	assert.True(t, errors.Is(err, pricing.ErrUnknownZone))
+
+	// Without any zones configured orders are priced without taxes or fees
+	assert.NoError(t, pricing.NewCalculator(config.PricingConfig{}).Price(order))
+	assert.Equal(t, models.NewMoney(2000, "USD"), order.Total)
+}
+
+func feeCodes(order *models.Order) []string {
+	codes := make([]string, 0, len(order.Breakdown.Fees))
+	for _, fee := range order.Breakdown.Fees {
+		codes = append(codes, fee.Code)
+	}
+	return codes
+}
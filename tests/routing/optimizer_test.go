@@ -0,0 +1,106 @@
+package routing_test
+
+import (
+	"meals/routing"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	// One degree of latitude is about 111.2 km anywhere on Earth
+	a := routing.Point{Latitude: 40, Longitude: -74}
+	b := routing.Point{Latitude: 41, Longitude: -74}
+	assert.InDelta(t, 111195, routing.Distance(a, b), 10)
+	assert.Equal(t, routing.Distance(a, b), routing.Distance(b, a))
+	assert.Zero(t, routing.Distance(a, a))
+
+	// Times Square to the Empire State Building, roughly 1 km
+	timesSquare := routing.Point{Latitude: 40.7580, Longitude: -73.9855}
+	empireState := routing.Point{Latitude: 40.7484, Longitude: -73.9857}
+	assert.InDelta(t, 1068, routing.Distance(timesSquare, empireState), 5)
+}
+
+func TestTravelTime(t *testing.T) {
+	assert.Equal(t, 6*time.Minute, routing.TravelTime(2500, 25))
+	assert.Zero(t, routing.TravelTime(2500, 0))
+}
+
+func TestOptimizeVisitsEveryStopOnce(t *testing.T) {
+	optimizer := routing.Optimizer{Depot: routing.Point{}, SpeedKmh: 30}
+
+	// Stops on a line, listed out of order
+	stops := []routing.Point{
+		{Latitude: 0, Longitude: 0.03},
+		{Latitude: 0, Longitude: 0.01},
+		{Latitude: 0, Longitude: 0.04},
+		{Latitude: 0, Longitude: 0.02},
+	}
+
+	route := optimizer.Optimize(stops)
+	assert.Equal(t, []int{1, 3, 0, 2}, route.Order)
+
+	// One leg to each stop and one back to the depot
+	assert.Len(t, route.Legs, len(stops)+1)
+	out := routing.Distance(routing.Point{}, stops[2])
+	assert.InDelta(t, 2*out, route.Distance(), 1)
+	assert.Equal(t, routing.TravelTime(route.Legs[0].Distance, 30), route.Legs[0].Duration)
+}
+
+func TestOptimizeRemovesCrossings(t *testing.T) {
+	depot := routing.Point{}
+	optimizer := routing.Optimizer{Depot: depot, SpeedKmh: 30}
+
+	// Nearest neighbour walks to the close stop first and then has to cross its
+	// own path; 2-opt straightens the tour into the square's perimeter
+	stops := []routing.Point{
+		{Latitude: 0.010, Longitude: 0.000},
+		{Latitude: 0.010, Longitude: 0.010},
+		{Latitude: 0.000, Longitude: 0.010},
+		{Latitude: 0.005, Longitude: 0.004},
+	}
+
+	route := optimizer.Optimize(stops)
+	assert.ElementsMatch(t, []int{0, 1, 2, 3}, route.Order)
+
+	unoptimized := routing.Route{Legs: optimizer.Legs([]routing.Point{stops[3], stops[0], stops[2], stops[1]})}
+	assert.Less(t, route.Distance(), unoptimized.Distance())
+
+	// No tour through the stops is shorter
+	best := unoptimized.Distance()
+	permute([]int{0, 1, 2, 3}, func(order []int) {
+		points := make([]routing.Point, len(order))
+		for i, index := range order {
+			points[i] = stops[index]
+		}
+		if distance := (routing.Route{Legs: optimizer.Legs(points)}).Distance(); distance < best {
+			best = distance
+		}
+	})
+	assert.InDelta(t, best, route.Distance(), 1e-6)
+}
+
+func TestOptimizeWithoutStops(t *testing.T) {
+	route := routing.Optimizer{}.Optimize(nil)
+	assert.Empty(t, route.Order)
+	assert.Empty(t, route.Legs)
+	assert.Zero(t, route.Duration())
+}
+
+// permute calls visit with every ordering of ids
+func permute(ids []int, visit func([]int)) {
+	var walk func(int)
+	walk = func(k int) {
+		if k == len(ids) {
+			visit(ids)
+			return
+		}
+		for i := k; i < len(ids); i++ {
+			ids[k], ids[i] = ids[i], ids[k]
+			walk(k + 1)
+			ids[k], ids[i] = ids[i], ids[k]
+		}
+	}
+	walk(0)
+}
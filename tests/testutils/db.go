@@ -4,6 +4,7 @@ import (
 	"meals/config"
 	"meals/store"
 
+	"github.com/go-redis/redis"
 	"gorm.io/gorm"
 )
 
@@ -26,3 +27,10 @@ func CleanupTestDB(db *gorm.DB) error {
 	}
 	return nil
 }
+
+// SetupTestRedis connects to the test Redis instance
+func SetupTestRedis() *redis.Client {
+	config.InitConfig()
+	store.InitRedis()
+	return store.RedisClient
+}
@@ -0,0 +1,63 @@
+package tracking_test
+
+import (
+	"meals/tests/testutils"
+	"meals/tracking"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackerKeepsLastPosition(t *testing.T) {
+	tracker := tracking.NewTracker(testutils.SetupTestRedis(), time.Minute)
+	driverID := uint(time.Now().UnixNano() % 1000000)
+
+	last, err := tracker.Last(driverID)
+	assert.Nil(t, err)
+	assert.Nil(t, last)
+
+	recordedAt := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	assert.Nil(t, tracker.Publish(tracking.Position{DriverID: driverID, Latitude: 40.74, Longitude: -73.99, RecordedAt: recordedAt}))
+
+	last, err = tracker.Last(driverID)
+	assert.Nil(t, err)
+	assert.Equal(t, 40.74, last.Latitude)
+	assert.True(t, recordedAt.Equal(last.RecordedAt))
+}
+
+func TestTrackerFansOutToSubscribers(t *testing.T) {
+	client := testutils.SetupTestRedis()
+	driverID := uint(time.Now().UnixNano()%1000000) + 1000000
+
+	// Subscribers on separate trackers stand in for separate app instances
+	first, err := tracking.NewTracker(client, time.Minute).Subscribe(driverID)
+	assert.Nil(t, err)
+	defer first.Close()
+	second, err := tracking.NewTracker(client, time.Minute).Subscribe(driverID)
+	assert.Nil(t, err)
+	defer second.Close()
+	other, err := tracking.NewTracker(client, time.Minute).Subscribe(driverID + 1)
+	assert.Nil(t, err)
+	defer other.Close()
+
+	published := tracking.Position{DriverID: driverID, Latitude: 40.75, Longitude: -73.98, RecordedAt: time.Now().UTC()}
+	assert.Nil(t, tracking.NewTracker(client, time.Minute).Publish(published))
+
+	for _, subscription := range []*tracking.Subscription{first, second} {
+		select {
+		case position := <-subscription.Positions():
+			assert.Equal(t, published.Latitude, position.Latitude)
+			assert.Equal(t, driverID, position.DriverID)
+		case <-time.After(2 * time.Second):
+			t.Fatal("position was not delivered")
+		}
+	}
+
+	// Other drivers' subscribers hear nothing
+	select {
+	case <-other.Positions():
+		t.Fatal("position delivered to the wrong driver's subscriber")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,137 @@
+// Package tracking shares the live positions of drivers between app
+// instances through Redis.
+package tracking
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// keyPrefix namespaces last known positions and position channels in Redis
+const keyPrefix = "tracking:driver:"
+
+// Position is a driver's GPS fix
+type Position struct {
+	DriverID   uint      `json:"driver_id"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	Heading    *float64  `json:"heading,omitempty"`   // Degrees clockwise from north
+	SpeedKmh   *float64  `json:"speed_kmh,omitempty"` // As reported by the device
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Tracker publishes driver positions and lets subscribers follow them. The
+// last position of each driver is kept for ttl so new subscribers do not
+// wait for the next ping.
+type Tracker struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewTracker creates a tracker backed by client
+func NewTracker(client *redis.Client, ttl time.Duration) *Tracker {
+	return &Tracker{client: client, ttl: ttl}
+}
+
+func key(driverID uint) string {
+	return keyPrefix + strconv.FormatUint(uint64(driverID), 10)
+}
+
+// Publish stores a driver's position as the last known one and sends it to
+// every subscriber on any app instance
+func (t *Tracker) Publish(position Position) error {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return err
+	}
+
+	k := key(position.DriverID)
+	_, err = t.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(k, data, t.ttl)
+		pipe.Publish(k, data)
+		return nil
+	})
+	return err
+}
+
+// Last returns a driver's last known position, or nil if there is none
+func (t *Tracker) Last(driverID uint) (*Position, error) {
+	data, err := t.client.Get(key(driverID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var position Position
+	if err := json.Unmarshal(data, &position); err != nil {
+		return nil, err
+	}
+	return &position, nil
+}
+
+// Subscription receives the positions a driver publishes
+type Subscription struct {
+	pubsub    *redis.PubSub
+	positions chan Position
+	done      chan struct{}
+}
+
+// Subscribe starts following a driver. The subscription is active when
+// Subscribe returns, so no position published afterwards is missed. Callers
+// must Close the subscription.
+func (t *Tracker) Subscribe(driverID uint) (*Subscription, error) {
+	pubsub := t.client.Subscribe(key(driverID))
+
+	// Wait for the confirmation so the subscription is in place before returning
+	if _, err := pubsub.Receive(); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	s := &Subscription{pubsub: pubsub, positions: make(chan Position), done: make(chan struct{})}
+	go s.forward()
+	return s, nil
+}
+
+// Positions returns the channel positions are delivered on. It is closed
+// when the subscription is closed.
+func (s *Subscription) Positions() <-chan Position {
+	return s.positions
+}
+
+// Close ends the subscription. It must be called at most once.
+func (s *Subscription) Close() error {
+	close(s.done)
+	return s.pubsub.Close()
+}
+
+// forward decodes messages until the subscription is closed. Malformed
+// messages are skipped.
+func (s *Subscription) forward() {
+	defer close(s.positions)
+	messages := s.pubsub.Channel()
+	for {
+		select {
+		case <-s.done:
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			var position Position
+			if err := json.Unmarshal([]byte(message.Payload), &position); err != nil {
+				continue
+			}
+			select {
+			case s.positions <- position:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}